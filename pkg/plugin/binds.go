@@ -0,0 +1,324 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// multiValueSep joins the values of a multi-value dashboard variable bound
+// as a single string, as they used to be interpolated in the query text so
+// that regular expressions like '$name' match any of them.
+const multiValueSep = "|"
+
+// sqlBuiltinBinds are the bind variables of the time range and step, which
+// take precedence over the dashboard variables of the same name.
+var sqlBuiltinBinds = map[string]bool{
+	"start_time": true,
+	"end_time":   true,
+	"interval_s": true,
+	"time_from":  true,
+	"time_to":    true,
+}
+
+// sqlQueryBinds returns the values of the bind variables of a SQL query by
+// lower case name: the dashboard variables of the query, see
+// variableBindName, then the time range in epoch seconds, :start_time and
+// :end_time, the step in seconds, :interval_s, and the time range as
+// TIMESTAMP WITH TIME ZONE values, :time_from and :time_to, see sqlTimeBound.
+// A dashboard variable is bound as its values joined with multiValueSep, and
+// when it has many values as each of its values, see sqlVariableBinds.
+func sqlQueryBinds(qm *QueryModel, from, to time.Time,
+	step int64) map[string]interface{} {
+	binds := make(map[string]interface{}, len(qm.Variables)+5)
+	for name, values := range qm.Variables {
+		bind := variableBindName(name)
+		binds[bind] = strings.Join(values, multiValueSep)
+		if len(values) > 1 {
+			for i, value := range values {
+				binds[bind+"_"+strconv.Itoa(i+1)] = value
+			}
+		}
+	}
+	binds["start_time"] = from.Unix()
+	binds["end_time"] = to.Unix()
+	binds["interval_s"] = step
+	binds["time_from"] = from
+	binds["time_to"] = to
+	return binds
+}
+
+// sqlBindArgs returns the named arguments of the bind variables of sqlText
+// that have a value in values, keyed by lower case name. Bind variables in
+// string literals, quoted identifiers and comments are not bind variables,
+// and only the bind variables of the text are bound, Oracle refuses others.
+func sqlBindArgs(sqlText string, values map[string]interface{}) []interface{} {
+	var args []interface{}
+	bound := map[string]bool{}
+	for i := 0; i < len(sqlText); {
+		if next := skipSQLLiteral(sqlText, i); next > i {
+			i = next
+			continue
+		}
+		if sqlText[i] != ':' || i+1 == len(sqlText) || !isSQLLetter(sqlText[i+1]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(sqlText) && isSQLIdentChar(sqlText[j]) {
+			j++
+		}
+		name := sqlText[i+1 : j]
+		key := strings.ToLower(name)
+		if value, ok := values[key]; ok && !bound[key] {
+			bound[key] = true
+			args = append(args, sql.Named(name, value))
+		}
+		i = j
+	}
+	return args
+}
+
+// variableBindName returns the name of the bind variable of a dashboard
+// variable. Dashboard variables are often named after reserved words like
+// user, level or date, which Oracle refuses as bind variables, or start with
+// an underscore, so they are prefixed.
+func variableBindName(name string) string {
+	return "v_" + strings.ToLower(name)
+}
+
+// sqlVariableBinds returns sqlText with the references to the dashboard
+// variables of the query replaced by their bind variables, see
+// variableBindName: the bind variable :name and the references in the syntax
+// of Grafana, $name, ${name}, ${name:format} and [[name]]. The variables used
+// to be interpolated into the text of SQL queries, queries written then are
+// bound the same way. A variable with many values is replaced by the list of
+// the bind variables of its values, :v_name_1, ..., :v_name_n, so that
+// IN (:name) matches any of them.
+//
+// A string literal holding nothing but a reference, '$name', is replaced by
+// the bind variable of the values joined with multiValueSep, the text it was
+// interpolated as. A reference in another string literal cannot be bound and
+// is an error rather than the text $name matching nothing. References in
+// quoted identifiers and comments are kept.
+//
+// A bind variable is a value, it cannot name a table or a column: a
+// reference in the position of a name, see sqlNamePosition, is an error
+// rather than a statement Oracle refuses with ORA-00903.
+func sqlVariableBinds(sqlText string, variables map[string]flexValue) (
+	string, error) {
+	if len(variables) == 0 {
+		return sqlText, nil
+	}
+	bindList := func(name string) string {
+		bind := ":" + variableBindName(name)
+		values := variables[name]
+		if len(values) <= 1 {
+			return bind
+		}
+		list := make([]string, len(values))
+		for i := range values {
+			list[i] = bind + "_" + strconv.Itoa(i+1)
+		}
+		return strings.Join(list, ", ")
+	}
+	var out strings.Builder
+	for i := 0; i < len(sqlText); {
+		if next := skipSQLLiteral(sqlText, i); next > i {
+			literal := sqlText[i:next]
+			bind, err := sqlLiteralBind(literal, variables)
+			if err != nil {
+				return "", err
+			}
+			if bind != "" {
+				literal = ":" + variableBindName(bind)
+			}
+			out.WriteString(literal)
+			i = next
+			continue
+		}
+		name, next := sqlVariableRef(sqlText, i)
+		if _, ok := variables[name]; !ok || name == "" {
+			name, next = sqlBindRef(sqlText, i, variables)
+		}
+		if name != "" {
+			if position := sqlNamePosition(sqlText, i, next); position != "" {
+				return "", fmt.Errorf("the dashboard variable %s is used as "+
+					"a name %s, variables are bound as values and cannot "+
+					"name a table or a column", name, position)
+			}
+			out.WriteString(bindList(name))
+			i = next
+			continue
+		}
+		out.WriteByte(sqlText[i])
+		i++
+	}
+	return out.String(), nil
+}
+
+// sqlNameKeywords are the keywords followed by the name of a table or a
+// column rather than by a value.
+var sqlNameKeywords = map[string]bool{
+	"FROM":   true,
+	"JOIN":   true,
+	"INTO":   true,
+	"UPDATE": true,
+	"TABLE":  true,
+	"BY":     true,
+	"AS":     true,
+}
+
+// sqlNamePosition describes the position of the reference from start to end
+// of sqlText when it is the position of a name: after a keyword of
+// sqlNameKeywords, as in FROM $table or GROUP BY $col, or in a qualified
+// name, as in $schema.table. It returns an empty string for the position of
+// a value.
+func sqlNamePosition(sqlText string, start, end int) string {
+	if end < len(sqlText) && sqlText[end] == '.' || start > 0 && sqlText[start-1] == '.' {
+		return "in a qualified name"
+	}
+	j := start
+	for j > 0 && (sqlText[j-1] == ' ' || sqlText[j-1] == '\t' ||
+		sqlText[j-1] == '\n' || sqlText[j-1] == '\r') {
+		j--
+	}
+	k := j
+	for k > 0 && isSQLIdentChar(sqlText[k-1]) {
+		k--
+	}
+	if keyword := strings.ToUpper(sqlText[k:j]); sqlNameKeywords[keyword] {
+		return "after " + keyword
+	}
+	return ""
+}
+
+// sqlLiteralBind returns the name of the dashboard variable a string literal
+// holding nothing but a reference to it is bound as, or an empty name when
+// the literal is kept. A reference to a variable in the text of a longer
+// string literal is an error.
+func sqlLiteralBind(literal string, variables map[string]flexValue) (
+	string, error) {
+	var text string
+	switch {
+	case len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'':
+		text = literal[1 : len(literal)-1]
+		if name, end := sqlVariableRef(text, 0); name != "" && end == len(text) {
+			if _, ok := variables[name]; ok {
+				return name, nil
+			}
+		}
+	case literal[0] == 'q' || literal[0] == 'Q':
+		text = literal
+	default:
+		//quoted identifiers and comments
+		return "", nil
+	}
+	for i := 0; i < len(text); i++ {
+		name, _ := sqlVariableRef(text, i)
+		if _, ok := variables[name]; ok && name != "" {
+			return "", fmt.Errorf("the dashboard variable %s is used in the "+
+				"string literal %s, variables are bound and cannot be part "+
+				"of a literal, use :%s outside of it, e.g. '%%' || :%s || '%%'",
+				name, literal, name, name)
+		}
+	}
+	return "", nil
+}
+
+// sqlBindRef returns the name of the dashboard variable bound as :name at
+// offset i of sqlText, compared without case like Oracle bind variables, and
+// the offset following the bind variable, or an empty name if there is none.
+// The bind variables of the time range and step are not dashboard
+// variables.
+func sqlBindRef(sqlText string, i int, variables map[string]flexValue) (
+	string, int) {
+	if sqlText[i] != ':' || i+1 == len(sqlText) || !isSQLLetter(sqlText[i+1]) ||
+		i > 0 && isSQLIdentChar(sqlText[i-1]) {
+		return "", i
+	}
+	j := i + 1
+	for j < len(sqlText) && isSQLIdentChar(sqlText[j]) {
+		j++
+	}
+	ref := sqlText[i+1 : j]
+	if sqlBuiltinBinds[strings.ToLower(ref)] {
+		return "", i
+	}
+	if _, ok := variables[ref]; ok {
+		return ref, j
+	}
+	for name := range variables {
+		if strings.EqualFold(name, ref) {
+			return name, j
+		}
+	}
+	return "", i
+}
+
+// sqlVariableRef returns the name of the dashboard variable referenced at
+// offset i of sqlText and the offset following the reference, or an empty
+// name if there is none. A $name inside an identifier, as in V$SESSION, is
+// not a reference.
+func sqlVariableRef(sqlText string, i int) (string, int) {
+	identEnd := func(j int) int {
+		for j < len(sqlText) && (isSQLLetter(sqlText[j]) || sqlText[j] == '_' ||
+			sqlText[j] >= '0' && sqlText[j] <= '9') {
+			j++
+		}
+		return j
+	}
+	switch {
+	case strings.HasPrefix(sqlText[i:], "${"):
+		end := strings.IndexByte(sqlText[i:], '}')
+		if end < 0 {
+			return "", i
+		}
+		name, _, _ := strings.Cut(sqlText[i+2:i+end], ":")
+		return name, i + end + 1
+	case strings.HasPrefix(sqlText[i:], "[["):
+		end := strings.Index(sqlText[i:], "]]")
+		if end < 0 {
+			return "", i
+		}
+		name, _, _ := strings.Cut(sqlText[i+2:i+end], ":")
+		return name, i + end + 2
+	case sqlText[i] == '$' && (i == 0 || !isSQLIdentChar(sqlText[i-1])):
+		j := identEnd(i + 1)
+		return sqlText[i+1 : j], j
+	}
+	return "", i
+}
+
+// isSQLLetter reports whether c can start an unquoted identifier.
+func isSQLLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
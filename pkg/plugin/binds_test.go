@@ -0,0 +1,221 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestSQLBindArgs(t *testing.T) {
+	values := map[string]interface{}{"time_from": 1, "time_to": 2}
+	tests := []struct {
+		sql  string
+		want []interface{}
+	}{
+		{"select 1 from dual", nil},
+		{"where ts >= :time_from and ts <= :time_to", []interface{}{
+			sql.Named("time_from", 1), sql.Named("time_to", 2)}},
+		{"where a > :time_from or b > :TIME_FROM", []interface{}{sql.Named("time_from", 1)}},
+		{"where a = ':time_from' and b = \"x:time_to\" -- :time_to\n", nil},
+		{"where a = :time_fromx and b = :other /* :time_to */", nil},
+		{"begin x := :time_to; end;", []interface{}{sql.Named("time_to", 2)}},
+	}
+	for _, tc := range tests {
+		if got := sqlBindArgs(tc.sql, values); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sqlBindArgs(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+// anyValueConverter lets sqlmock accept the godror options passed with the
+// bind variables.
+type anyValueConverter struct{}
+
+func (anyValueConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	return v, nil
+}
+
+// optionArg matches the godror options of a query.
+type optionArg struct{}
+
+func (optionArg) Match(v driver.Value) bool {
+	return true
+}
+
+func TestQuery_TimeRangeBinds(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(anyValueConverter{}))
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`where ts >= CAST\(:time_from AT LOCAL AS DATE\)`).
+		WithArgs(sql.Named("time_from", testTimeRange.From),
+			sql.Named("time_to", testTimeRange.To), optionArg{}).
+		WillReturnRows(sqlmock.NewRows([]string{"V"}).AddRow("1"))
+	resp := query(context.Background(), backend.DataQuery{
+		RefID: "A",
+		JSON: json.RawMessage(`{"queryLang":"sql","format":"table",` +
+			`"exprSql":"select v from t where $__timeFilter(ts)"}`),
+		TimeRange: testTimeRange,
+	}, db, queryConfig{})
+	if resp.Error != nil {
+		t.Fatalf("query: %v", resp.Error)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLVariableBinds(t *testing.T) {
+	variables := map[string]flexValue{"host": {"a"}, "dc": {"eu", "us"},
+		"SESSION": {"x"}, "user": {"scott"}, "_x": {"1"}, "start_time": {"0"}}
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"where host = $host", "where host = :v_host"},
+		{"where host = ${host} and dc in (${dc:csv})",
+			"where host = :v_host and dc in (:v_dc_1, :v_dc_2)"},
+		{"where host = [[host]]", "where host = :v_host"},
+		{"where h=$host||'x'", "where h=:v_host||'x'"},
+		// bind variables of dashboard variables, reserved words included
+		{"where dc in (:dc) and name = :USER and n = $_x",
+			"where dc in (:v_dc_1, :v_dc_2) and name = :v_user and n = :v__x"},
+		{"begin :host := 1; end;", "begin :v_host := 1; end;"},
+		// the time range takes precedence
+		{"where ts > :start_time", "where ts > :start_time"},
+		// quoted references are bound as the values joined by |
+		{"where host = '$host' and regexp_like(dc, '${dc}')",
+			"where host = :v_host and regexp_like(dc, :v_dc)"},
+		{"where host = '[[host]]'", "where host = :v_host"},
+		// unknown variables, macros and identifiers are kept
+		{"where zone = $zone and $__timeFilter(ts)", "where zone = $zone and $__timeFilter(ts)"},
+		{"select * from v$SESSION where a = 'v$host'", "select * from v$SESSION where a = 'v$host'"},
+		{"where $hostname = 1 and z = :zone", "where $hostname = 1 and z = :zone"},
+		{"where a = '$zone' and b = 'a:host'", "where a = '$zone' and b = 'a:host'"},
+		// as are quoted identifiers and comments
+		{`where "$host" = 1 -- $host`, `where "$host" = 1 -- $host`},
+		{"where x = ${host", "where x = ${host"},
+	}
+	for _, tc := range tests {
+		got, err := sqlVariableBinds(tc.sql, variables)
+		if err != nil || got != tc.want {
+			t.Errorf("sqlVariableBinds(%q) = %q, %v, want %q", tc.sql, got, err, tc.want)
+		}
+	}
+
+	failures := []struct {
+		sql  string
+		want string
+	}{
+		{"where host like '%$host%'", "the dashboard variable host is used in the string literal"},
+		{"where host = 'x${host}'", "the dashboard variable host is used in the string literal"},
+		{"where host = q'[$host]'", "the dashboard variable host is used in the string literal"},
+		// bind variables are values, not names
+		{"select v from $host", "the dashboard variable host is used as a name after FROM"},
+		{"select 1 from t join\n  [[host]] h on 1=1", "used as a name after JOIN"},
+		{"select count(*) from t group by ${host}", "used as a name after BY"},
+		{"select :host.v from t", "used as a name in a qualified name"},
+		{"select v from t.$host", "used as a name in a qualified name"},
+	}
+	for _, tc := range failures {
+		if _, err := sqlVariableBinds(tc.sql, variables); err == nil ||
+			!strings.Contains(err.Error(), tc.want) {
+			t.Errorf("sqlVariableBinds(%q) error = %v, want %q", tc.sql, err, tc.want)
+		}
+	}
+}
+
+func TestSQLQueryBinds(t *testing.T) {
+	qm := &QueryModel{Variables: map[string]flexValue{
+		"Host": {"a"}, "start_time": {"0"}, "dc": {"eu", "us"}, "none": {}}}
+	from := time.Unix(1700000000, 0)
+	got := sqlQueryBinds(qm, from, from.Add(time.Hour), 30)
+	want := map[string]interface{}{
+		"v_host":       "a",
+		"v_start_time": "0",
+		"v_dc":         "eu|us",
+		"v_dc_1":       "eu",
+		"v_dc_2":       "us",
+		"v_none":       "",
+		"start_time":   int64(1700000000),
+		"end_time":     int64(1700003600),
+		"interval_s":   int64(30),
+		"time_from":    from,
+		"time_to":      from.Add(time.Hour),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sqlQueryBinds = %v, want %v", got, want)
+	}
+}
+
+func TestQuery_BindVariables(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(anyValueConverter{}))
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	//only the dashboard variables are renamed, literals are not changed
+	const sqlText = "select v from t where ts > :start_time and ts < :end_time" +
+		" and host = :host and dc in (:dc) and step = :interval_s and note = ':end_time'"
+	const want = "select v from t where ts > :start_time and ts < :end_time" +
+		" and host = :v_host and dc in (:v_dc_1, :v_dc_2) and step = :interval_s and note = ':end_time'"
+	mock.ExpectQuery("^"+regexp.QuoteMeta(want)+"$").
+		WithArgs(sql.Named("start_time", testTimeRange.From.Unix()),
+			sql.Named("end_time", testTimeRange.To.Unix()),
+			sql.Named("v_host", "a' or '1'='1"),
+			sql.Named("v_dc_1", "eu"), sql.Named("v_dc_2", "us"),
+			sql.Named("interval_s", int64(60)), optionArg{}).
+		WillReturnRows(sqlmock.NewRows([]string{"V"}).AddRow("1"))
+	body, _ := json.Marshal(map[string]interface{}{
+		"queryLang": "sql", "format": "table", "exprSql": sqlText,
+		"stepTextSql": "60",
+		"variables":   map[string]interface{}{"host": "a' or '1'='1", "dc": []string{"eu", "us"}},
+	})
+	resp := query(context.Background(), backend.DataQuery{
+		RefID: "A", JSON: body, TimeRange: testTimeRange,
+	}, db, queryConfig{})
+	if resp.Error != nil {
+		t.Fatalf("query: %v", resp.Error)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
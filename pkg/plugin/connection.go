@@ -0,0 +1,254 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	godror "github.com/godror/godror"
+)
+
+// connectionConfig holds the datasource settings needed to reach the
+// database. It is the only place turning them into godror.ConnectionParams,
+// so the user and password are never concatenated into a connect string and
+// may contain any character.
+type connectionConfig struct {
+	QueryAuth string
+	User      string
+	Password  string
+	//for TNS connection type
+	ConnectString string
+	//for BASIC connection type
+	HostName    string
+	Port        string
+	ServiceName string
+	//Easy Connect Plus options, 0 means the Oracle client default
+	RetryCount              int
+	ConnectTimeout          time.Duration
+	TransportConnectTimeout time.Duration
+	//directory of the wallet used for TLS connections of this datasource
+	WalletLocation string
+	//session pool settings
+	PoolMinSessions int
+	PoolMaxSessions int
+	PoolIdleTimeout time.Duration
+	PoolMaxLifetime time.Duration
+	//time zone of the sessions and of the DATE and TIMESTAMP values, nil
+	//for the time zone of the database
+	TimeZone *time.Location
+}
+
+// getConnectionConfig returns the connection settings of the datasource.
+func (jd *OracleDatasource) getConnectionConfig() connectionConfig {
+	return connectionConfig{
+		QueryAuth:               jd.QueryAuth,
+		User:                    jd.DbUser,
+		Password:                jd.secureCredData.DecryptedSecureJSONData["dbPassword"],
+		ConnectString:           jd.DbConnectString,
+		HostName:                jd.DbHostName,
+		Port:                    jd.DbPortName,
+		ServiceName:             jd.DbServiceName,
+		RetryCount:              jd.RetryCount,
+		ConnectTimeout:          jd.ConnectTimeout,
+		TransportConnectTimeout: jd.TransportConnectTimeout,
+		WalletLocation:          jd.getWalletLocation(),
+		PoolMinSessions:         jd.PoolMinSessions,
+		PoolMaxSessions:         jd.PoolMaxSessions,
+		PoolIdleTimeout:         jd.PoolIdleTimeout,
+		PoolMaxLifetime:         jd.PoolMaxLifetime,
+		TimeZone:                jd.TimeZone,
+	}
+}
+
+// hasEasyConnectOptions reports whether any Easy Connect Plus option is set.
+func (cc connectionConfig) hasEasyConnectOptions() bool {
+	return cc.RetryCount > 0 || cc.ConnectTimeout > 0 ||
+		cc.TransportConnectTimeout > 0
+}
+
+// isEasyConnect reports whether connectString is an Easy Connect string, to
+// which options can be appended, rather than a net service name or a full
+// connect descriptor.
+func isEasyConnect(connectString string) bool {
+	connectString = strings.TrimSpace(connectString)
+	return !strings.HasPrefix(connectString, "(") &&
+		strings.ContainsAny(connectString, "/:")
+}
+
+// validate checks that the settings are complete enough to connect and
+// returns an error naming the first setting which is not.
+func (cc connectionConfig) validate() error {
+	if strings.TrimSpace(cc.User) == "" {
+		return errors.New("database username is not set")
+	}
+	if cc.QueryAuth == "BASIC" {
+		if strings.TrimSpace(cc.HostName) == "" {
+			return errors.New("database hostname is not set")
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(cc.Port))
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid database port %q", cc.Port)
+		}
+		if strings.TrimSpace(cc.ServiceName) == "" {
+			return errors.New("database service name is not set")
+		}
+	} else {
+		if strings.TrimSpace(cc.ConnectString) == "" {
+			return errors.New("database connection identifier is not set")
+		}
+		if cc.hasEasyConnectOptions() && !isEasyConnect(cc.ConnectString) {
+			return errors.New("retry count and connect timeouts can only be " +
+				"set for an Easy Connect identifier, set them in the " +
+				"connect descriptor instead")
+		}
+	}
+	if cc.RetryCount < 0 {
+		return fmt.Errorf("invalid retry count %d", cc.RetryCount)
+	}
+	if cc.ConnectTimeout < 0 || cc.TransportConnectTimeout < 0 {
+		return errors.New("connect timeouts cannot be negative")
+	}
+	if cc.WalletLocation != "" {
+		return validateWallet(cc.WalletLocation)
+	}
+	return nil
+}
+
+// getConnectString returns the connect string handed to the Oracle client:
+// an Easy Connect string built from host, port and service for BASIC, the
+// connection identifier as given otherwise, with the Easy Connect Plus
+// options appended. When a wallet is configured it is referenced from the
+// connect string itself rather than from a process wide TNS_ADMIN, so each
+// datasource can use its own wallet; a net service name is then resolved
+// from the tnsnames.ora of that wallet.
+func (cc connectionConfig) getConnectString() (string, error) {
+	connectString := strings.TrimSpace(cc.ConnectString)
+	if cc.QueryAuth == "BASIC" {
+		// net.JoinHostPort brackets IPv6 addresses as Easy Connect expects
+		connectString = net.JoinHostPort(strings.TrimSpace(cc.HostName),
+			strings.TrimSpace(cc.Port)) + "/" + strings.TrimSpace(cc.ServiceName)
+		if cc.WalletLocation != "" {
+			// a wallet is only used by TLS connections
+			connectString = "tcps://" + connectString
+		}
+	}
+	var options []string
+	if cc.RetryCount > 0 {
+		options = append(options, "retry_count="+strconv.Itoa(cc.RetryCount))
+	}
+	if cc.ConnectTimeout > 0 {
+		options = append(options, "connect_timeout="+
+			strconv.FormatInt(int64(cc.ConnectTimeout/time.Second), 10))
+	}
+	if cc.TransportConnectTimeout > 0 {
+		options = append(options, "transport_connect_timeout="+
+			strconv.FormatInt(int64(cc.TransportConnectTimeout/time.Second), 10))
+	}
+	if cc.WalletLocation != "" {
+		if !isEasyConnect(connectString) {
+			descriptor := connectString
+			if !strings.HasPrefix(descriptor, "(") {
+				var err error
+				descriptor, err = resolveTNSAlias(cc.WalletLocation, connectString)
+				if err != nil {
+					return "", err
+				}
+			}
+			return addWalletToDescriptor(descriptor, cc.WalletLocation)
+		}
+		if !strings.Contains(strings.ToLower(connectString), "wallet_location=") {
+			options = append(options, "wallet_location="+cc.WalletLocation)
+		}
+	}
+	if len(options) == 0 {
+		return connectString, nil
+	}
+	sep := "?"
+	if strings.Contains(connectString, "?") {
+		sep = "&"
+	}
+	return connectString + sep + strings.Join(options, "&"), nil
+}
+
+// connectionParams validates the settings and returns the godror parameters
+// of the session pool.
+func (cc connectionConfig) connectionParams() (godror.ConnectionParams, error) {
+	var params godror.ConnectionParams
+	if err := cc.validate(); err != nil {
+		return params, err
+	}
+	connectString, err := cc.getConnectString()
+	if err != nil {
+		return params, err
+	}
+	params.Username = strings.TrimSpace(cc.User)
+	params.Password = godror.NewPassword(cc.Password)
+	params.ConnectString = connectString
+	params.ConnClass = godror.DefaultConnectionClass
+	params.StandaloneConnection = false
+	params.MinSessions = cc.PoolMinSessions
+	params.MaxSessions = cc.PoolMaxSessions
+	params.SessionIncrement = godror.DefaultSessionIncrement
+	params.WaitTimeout = godror.DefaultWaitTimeout
+	params.SessionTimeout = cc.PoolIdleTimeout
+	params.MaxLifeTime = cc.PoolMaxLifetime
+	if cc.TimeZone != nil {
+		//the sessions compare and convert the times of time zone aware
+		//values in the time zone of the data, and godror reads DATE and
+		//TIMESTAMP values in it
+		params.Timezone = cc.TimeZone
+		params.SetSessionParamOnInit("TIME_ZONE", cc.TimeZone.String())
+	}
+	return params, nil
+}
+
+// parseTimeZone parses the time zone of the data, a region name known to
+// both Go and Oracle such as Europe/Paris, or an offset such as +02:00.
+func parseTimeZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if len(name) == 6 && (name[0] == '+' || name[0] == '-') && name[3] == ':' {
+		hours, errHours := strconv.Atoi(name[1:3])
+		minutes, errMinutes := strconv.Atoi(name[4:])
+		if errHours != nil || errMinutes != nil || hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid time zone offset %q", name)
+		}
+		offset := hours*3600 + minutes*60
+		if name[0] == '-' {
+			offset = -offset
+		}
+		return time.FixedZone(name, offset), nil
+	}
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("invalid time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,364 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	godror "github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func makeTestConnectionConfig() connectionConfig {
+	return connectionConfig{
+		QueryAuth:   "BASIC",
+		User:        "scott",
+		Password:    "tiger",
+		HostName:    "dbhost",
+		Port:        "1521",
+		ServiceName: "orclpdb1",
+	}
+}
+
+func TestConnectionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cc *connectionConfig)
+		wantErr string
+	}{
+		{name: "basic ok", modify: func(cc *connectionConfig) {}},
+		{
+			name:    "missing user",
+			modify:  func(cc *connectionConfig) { cc.User = " " },
+			wantErr: "database username is not set",
+		},
+		{
+			name:    "missing host",
+			modify:  func(cc *connectionConfig) { cc.HostName = "" },
+			wantErr: "database hostname is not set",
+		},
+		{
+			name:    "invalid port",
+			modify:  func(cc *connectionConfig) { cc.Port = "15x21" },
+			wantErr: `invalid database port "15x21"`,
+		},
+		{
+			name:    "port out of range",
+			modify:  func(cc *connectionConfig) { cc.Port = "70000" },
+			wantErr: `invalid database port "70000"`,
+		},
+		{
+			name:    "missing service",
+			modify:  func(cc *connectionConfig) { cc.ServiceName = "" },
+			wantErr: "database service name is not set",
+		},
+		{
+			name: "tns ok",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "prod_high"
+			},
+		},
+		{
+			name:    "missing connection identifier",
+			modify:  func(cc *connectionConfig) { cc.QueryAuth = "TNS" },
+			wantErr: "database connection identifier is not set",
+		},
+		{
+			name: "options on descriptor",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "(DESCRIPTION=(ADDRESS=(HOST=h)(PORT=1521))(CONNECT_DATA=(SERVICE_NAME=s)))"
+				cc.RetryCount = 3
+			},
+			wantErr: "retry count and connect timeouts can only be set for an Easy Connect identifier",
+		},
+		{
+			name:    "negative retry count",
+			modify:  func(cc *connectionConfig) { cc.RetryCount = -1 },
+			wantErr: "invalid retry count -1",
+		},
+		{
+			name:    "negative timeout",
+			modify:  func(cc *connectionConfig) { cc.ConnectTimeout = -time.Second },
+			wantErr: "connect timeouts cannot be negative",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cc := makeTestConnectionConfig()
+			tc.modify(&cc)
+			err := cc.validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_GetConnectString(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cc *connectionConfig)
+		want   string
+	}{
+		{
+			name:   "basic",
+			modify: func(cc *connectionConfig) {},
+			want:   "dbhost:1521/orclpdb1",
+		},
+		{
+			name:   "basic ipv6",
+			modify: func(cc *connectionConfig) { cc.HostName = "::1" },
+			want:   "[::1]:1521/orclpdb1",
+		},
+		{
+			name: "basic with options",
+			modify: func(cc *connectionConfig) {
+				cc.RetryCount = 3
+				cc.ConnectTimeout = 10 * time.Second
+				cc.TransportConnectTimeout = 2 * time.Second
+			},
+			want: "dbhost:1521/orclpdb1?retry_count=3&connect_timeout=10&transport_connect_timeout=2",
+		},
+		{
+			name: "tns alias",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = " prod_high "
+			},
+			want: "prod_high",
+		},
+		{
+			name: "tns easy connect with existing options",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "tcps://adb.example.com:1522/svc_high?wallet_location=/w"
+				cc.RetryCount = 2
+			},
+			want: "tcps://adb.example.com:1522/svc_high?wallet_location=/w&retry_count=2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cc := makeTestConnectionConfig()
+			tc.modify(&cc)
+			got, err := cc.getConnectString()
+			if err != nil {
+				t.Fatalf("getConnectString: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("getConnectString() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_ConnectionParams(t *testing.T) {
+	// passwords which broke the user/password@host connect strings
+	for _, password := range []string{`p@ss`, `pa/ss`, `pa"ss`, `a@b/c"d=e f`} {
+		t.Run(password, func(t *testing.T) {
+			cc := makeTestConnectionConfig()
+			cc.Password = password
+			cc.PoolMinSessions = 2
+			cc.PoolMaxSessions = 8
+			cc.PoolIdleTimeout = time.Minute
+			cc.PoolMaxLifetime = time.Hour
+
+			params, err := cc.connectionParams()
+			if err != nil {
+				t.Fatalf("connectionParams: %v", err)
+			}
+			if params.Username != "scott" {
+				t.Errorf("Username = %q, want scott", params.Username)
+			}
+			if params.Password.Secret() != password {
+				t.Errorf("Password = %q, want %q", params.Password.Secret(), password)
+			}
+			if params.ConnectString != "dbhost:1521/orclpdb1" {
+				t.Errorf("ConnectString = %q", params.ConnectString)
+			}
+			if params.IsStandalone() {
+				t.Errorf("expected a session pool")
+			}
+			if params.MinSessions != 2 || params.MaxSessions != 8 ||
+				params.SessionTimeout != time.Minute ||
+				params.MaxLifeTime != time.Hour {
+				t.Errorf("unexpected pool params %+v", params.PoolParams)
+			}
+			if strings.Contains(params.String(), password) {
+				t.Errorf("password leaked in %s", params.String())
+			}
+		})
+	}
+
+	cc := makeTestConnectionConfig()
+	cc.User = ""
+	if _, err := cc.connectionParams(); err == nil {
+		t.Fatalf("expected validation error")
+	}
+}
+
+func TestConnectionConfig_TimeZone(t *testing.T) {
+	cc := makeTestConnectionConfig()
+	params, err := cc.connectionParams()
+	if err != nil {
+		t.Fatalf("connectionParams: %v", err)
+	}
+	if params.Timezone != nil || len(params.AlterSession) != 0 {
+		t.Fatalf("time zone set without a setting: %v %v", params.Timezone,
+			params.AlterSession)
+	}
+
+	cc.TimeZone, err = parseTimeZone("Europe/Paris")
+	if err != nil {
+		t.Fatalf("parseTimeZone: %v", err)
+	}
+	params, err = cc.connectionParams()
+	if err != nil {
+		t.Fatalf("connectionParams: %v", err)
+	}
+	if params.Timezone != cc.TimeZone {
+		t.Errorf("Timezone = %v, want Europe/Paris", params.Timezone)
+	}
+	want := [][2]string{{"TIME_ZONE", "Europe/Paris"}}
+	if !reflect.DeepEqual(params.AlterSession, want) {
+		t.Errorf("AlterSession = %v, want %v", params.AlterSession, want)
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantOffset int
+		wantErr    bool
+	}{
+		{name: "UTC"},
+		{name: " Asia/Tokyo ", wantOffset: 9 * 3600},
+		{name: "+02:00", wantOffset: 2 * 3600},
+		{name: "-03:30", wantOffset: -(3*3600 + 30*60)},
+		{name: "+25:00", wantErr: true},
+		{name: "Local", wantErr: true},
+		{name: "Mars/Olympus", wantErr: true},
+	}
+	for _, tc := range tests {
+		loc, err := parseTimeZone(tc.name)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("parseTimeZone(%q) error = %v", tc.name, err)
+		}
+		if err != nil {
+			continue
+		}
+		//in winter, when Tokyo has no daylight saving time either
+		_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone()
+		if offset != tc.wantOffset {
+			t.Errorf("parseTimeZone(%q) offset = %d, want %d", tc.name, offset, tc.wantOffset)
+		}
+	}
+}
+
+func TestNewOracleDatasource_TimeZone(t *testing.T) {
+	for setting, want := range map[string]string{
+		"": "", "Europe/Paris": "Europe/Paris", "+05:30": "+05:30", "nowhere": "",
+	} {
+		raw, _ := json.Marshal(map[string]interface{}{"timeZone": setting})
+		instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{JSONData: raw})
+		if err != nil {
+			t.Fatalf("NewOracleDatasource: %v", err)
+		}
+		ds := instance.(*OracleDatasource)
+		got := ""
+		if ds.TimeZone != nil {
+			got = ds.TimeZone.String()
+		}
+		if got != want || ds.getConnectionConfig().TimeZone != ds.TimeZone ||
+			ds.getQueryConfig().TimeZone != ds.TimeZone {
+			t.Errorf("timeZone %q: got %q, want %q", setting, got, want)
+		}
+	}
+}
+
+func TestCheckHealth_PasswordWithSpecialCharacters(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	var got godror.ConnectionParams
+	orig := dbConnector
+	dbConnector = func(_ context.Context, params godror.ConnectionParams) (*sql.DB, error) {
+		got = params
+		return db, nil
+	}
+	defer func() { dbConnector = orig }()
+
+	raw, _ := json.Marshal(map[string]interface{}{
+		"queryAuth":       "TNS",
+		"dbUser":          "scott",
+		"dbConnectString": "dbhost:1521/orclpdb1",
+		"retryCount":      3,
+		"connectTimeout":  15,
+	})
+	instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{
+		JSONData:                raw,
+		DecryptedSecureJSONData: map[string]string{"dbPassword": `Gen@rated/"pw`},
+	})
+	if err != nil {
+		t.Fatalf("NewOracleDatasource: %v", err)
+	}
+
+	result, err := instance.(*OracleDatasource).CheckHealth(context.Background(),
+		&backend.CheckHealthRequest{})
+	if err != nil {
+		t.Fatalf("CheckHealth: %v", err)
+	}
+	if result.Status != backend.HealthStatusOk {
+		t.Fatalf("status = %v (%s), want OK", result.Status, result.Message)
+	}
+	if got.Password.Secret() != `Gen@rated/"pw` {
+		t.Fatalf("password = %q", got.Password.Secret())
+	}
+	if got.ConnectString != "dbhost:1521/orclpdb1?retry_count=3&connect_timeout=15" {
+		t.Fatalf("connect string = %q", got.ConnectString)
+	}
+}
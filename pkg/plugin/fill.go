@@ -0,0 +1,205 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// fill modes of the fill argument of $__timeGroup
+const (
+	fillNull     = "null"
+	fillPrevious = "previous"
+	fillValue    = "value"
+)
+
+// maxFillRows is the max number of buckets of the time range the rows of a
+// series are filled to.
+const maxFillRows = 100000
+
+// sqlFill fills the buckets of a $__timeGroup interval that have no row, in
+// each series of a SQL query over its time range.
+type sqlFill struct {
+	Mode string
+	//value of the filled rows for the value mode
+	Value float64
+	//interval of the buckets
+	Interval time.Duration
+	//time range of the query
+	From time.Time
+	To   time.Time
+	//time zone the buckets are aligned in, nil for UTC
+	Location *time.Location
+}
+
+// parseSQLFill parses the fill argument of $__timeGroup, NULL, previous or
+// a number.
+func parseSQLFill(arg string, interval time.Duration, from,
+	to time.Time, location *time.Location) (*sqlFill, error) {
+	fill := &sqlFill{Interval: interval, From: from, To: to,
+		Location: location}
+	switch strings.ToLower(strings.Trim(arg, "'")) {
+	case fillNull:
+		fill.Mode = fillNull
+	case fillPrevious:
+		fill.Mode = fillPrevious
+	default:
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fill %q, expected NULL, previous"+
+				" or a number", arg)
+		}
+		fill.Mode, fill.Value = fillValue, value
+	}
+	if to.Sub(from)/interval > maxFillRows {
+		return nil, fmt.Errorf("fill of more than %d rows, the interval is"+
+			" too small for the time range", maxFillRows)
+	}
+	return fill, nil
+}
+
+// fillFrames fills the frames of time series, whose first field is the time
+// field and whose rows are sorted by time. Frames are returned as they are
+// when there is no fill.
+func (f *sqlFill) fillFrames(frames data.Frames) data.Frames {
+	if f == nil {
+		return frames
+	}
+	for i, frame := range frames {
+		frames[i] = f.fillFrame(frame)
+	}
+	return frames
+}
+
+// fillFrame returns the frame with a row for each bucket of the time range
+// that has none. The buckets are aligned to the interval from the epoch in
+// the time zone of the data, like the times of $__timeGroup.
+func (f *sqlFill) fillFrame(frame *data.Frame) *data.Frame {
+	if len(frame.Fields) == 0 || !frame.Fields[0].Type().Time() {
+		return frame
+	}
+	rowTime := func(row int) *time.Time {
+		switch t := frame.Fields[0].At(row).(type) {
+		case time.Time:
+			return &t
+		case *time.Time:
+			return t
+		}
+		return nil
+	}
+	location := f.Location
+	if location == nil {
+		location = time.UTC
+	}
+	//buckets are counted in seconds of the wall clock of the time zone, the
+	//offset is taken at each bucket as it changes with daylight saving time
+	secs := int64(f.Interval / time.Second)
+	_, offset := f.From.In(location).Zone()
+	wall := floorDiv(f.From.Unix()+int64(offset), secs) * secs
+	bucketAt := func(wall int64) time.Time {
+		t := time.Unix(wall, 0).UTC()
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(),
+			t.Second(), 0, location).UTC()
+	}
+	filled := frame.EmptyCopy()
+	//values of the last row, the values of the previous mode
+	previous := make([]interface{}, len(frame.Fields))
+	appendRow := func(row int) {
+		values := frame.RowCopy(row)
+		filled.AppendRow(values...)
+		copy(previous, values)
+	}
+	rows := frame.Rows()
+	row := 0
+	last := bucketAt(wall - secs)
+	for ; ; wall += secs {
+		bucket := bucketAt(wall)
+		if bucket.After(f.To) {
+			break
+		}
+		//a wall clock time skipped by daylight saving time is the same
+		//bucket as the next one
+		if !bucket.After(last) {
+			continue
+		}
+		last = bucket
+		found := false
+		//rows without time sort last, after the buckets
+		for ; row < rows; row++ {
+			t := rowTime(row)
+			if t == nil || t.After(bucket) {
+				break
+			}
+			found = found || t.Equal(bucket)
+			appendRow(row)
+		}
+		if !found {
+			f.appendFillRow(filled, bucket, previous)
+		}
+	}
+	for ; row < rows; row++ {
+		appendRow(row)
+	}
+	return filled
+}
+
+// floorDiv returns a/b rounded down.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// appendFillRow appends the row of a bucket without rows to frame.
+func (f *sqlFill) appendFillRow(frame *data.Frame, bucket time.Time,
+	previous []interface{}) {
+	//fields are extended with null, or zero when they are not nullable
+	n := frame.Rows()
+	for i, field := range frame.Fields {
+		field.Extend(1)
+		switch {
+		case i == 0 && field.Type() == data.FieldTypeNullableTime:
+			field.Set(n, &bucket)
+		case i == 0:
+			field.Set(n, bucket)
+		case f.Mode == fillPrevious && previous[i] != nil:
+			field.Set(n, previous[i])
+		case f.Mode == fillValue && field.Type() == data.FieldTypeNullableFloat64:
+			value := f.Value
+			field.Set(n, &value)
+		case f.Mode == fillValue && field.Type() == data.FieldTypeFloat64:
+			field.Set(n, f.Value)
+		}
+	}
+}
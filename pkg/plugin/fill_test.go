@@ -0,0 +1,220 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// fieldText returns the values of a field as text, null for null values and
+// epoch seconds for times.
+func fieldText(field *data.Field) string {
+	values := make([]string, field.Len())
+	for i := range values {
+		switch v := field.At(i).(type) {
+		case *time.Time:
+			values[i] = "null"
+			if v != nil {
+				values[i] = fmt.Sprint(v.Unix())
+			}
+		case time.Time:
+			values[i] = fmt.Sprint(v.Unix())
+		case *float64:
+			values[i] = "null"
+			if v != nil {
+				values[i] = fmt.Sprint(*v)
+			}
+		default:
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+func TestGetDataFrameFromRows_Fill(t *testing.T) {
+	from := time.Unix(1700000000, 0).UTC()
+	fill := func(mode string, value float64) *sqlFill {
+		return &sqlFill{Mode: mode, Value: value, Interval: 5 * time.Minute,
+			From: from, To: from.Add(20 * time.Minute)}
+	}
+	//the buckets are aligned to the interval, the first one starts before
+	//the time range
+	const times = "1699999800 1700000100 1700000400 1700000700 1700001000"
+	tests := []struct {
+		name string
+		opts frameOptions
+		want string
+	}{
+		{"null", frameOptions{Fill: fill(fillNull, 0)}, "null 1 null 3 null"},
+		{"previous", frameOptions{Fill: fill(fillPrevious, 0)}, "null 1 1 3 3"},
+		{"value", frameOptions{Fill: fill(fillValue, -1)}, "-1 1 -1 3 -1"},
+		{"null zero filled", frameOptions{Fill: fill(fillNull, 0), ZeroFillNulls: true}, "0 1 0 3 0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+				AddRow("1700000700", "3", "up", `{"host":"a"}`).
+				AddRow("1700000100", "1", "up", `{"host":"a"}`)
+			frames := sqlFrames(t, rows, formatTimeSeries, tc.opts)
+			if len(frames) != 1 {
+				t.Fatalf("frames = %d, want 1", len(frames))
+			}
+			if got := fieldText(frames[0].Fields[0]); got != times {
+				t.Fatalf("times = %s, want %s", got, times)
+			}
+			if got := fieldText(frames[0].Fields[1]); got != tc.want {
+				t.Fatalf("values = %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("each series and rows off the grid", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("NUMBER", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).
+			AddRow("1700000100", "a", "1").
+			AddRow("1700000500", "b", "2")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{
+			Columns: seriesColumns{Time: "TIME"}, Fill: fill(fillValue, 0)})
+		want := map[string]string{
+			"HOST=a": "0 1 0 0 0",
+			"HOST=b": "0 0 0 2 0 0",
+		}
+		if len(frames) != len(want) {
+			t.Fatalf("frames = %d, want %d", len(frames), len(want))
+		}
+		for labels, field := range seriesByLabels(frames) {
+			if got := fieldText(field); got != want[labels] {
+				t.Fatalf("values of %s = %s, want %s", labels, got, want[labels])
+			}
+		}
+	})
+
+	t.Run("aligned in the time zone of the data", func(t *testing.T) {
+		//day buckets start at midnight in Tokyo, 15:00 UTC
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+			AddRow("1700060400", "1", "up", "")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{Fill: &sqlFill{
+			Mode: fillNull, Interval: 24 * time.Hour, From: from,
+			To: from.Add(48 * time.Hour), Location: tokyo}})
+		const want = "1699974000 1700060400 1700146800"
+		if got := fieldText(frames[0].Fields[0]); got != want {
+			t.Fatalf("times = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("day buckets across daylight saving time", func(t *testing.T) {
+		//days start at midnight in Paris, 23:00 UTC before the change on
+		//March 31, 22:00 UTC after it
+		paris, err := time.LoadLocation("Europe/Paris")
+		if err != nil {
+			t.Fatal(err)
+		}
+		day := func(d int) string {
+			return fmt.Sprint(time.Date(2024, 3, d, 0, 0, 0, 0, paris).Unix())
+		}
+		rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+			AddRow(day(32), "1", "up", "")
+		start := time.Date(2024, 3, 30, 12, 0, 0, 0, paris)
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{Fill: &sqlFill{
+			Mode: fillNull, Interval: 24 * time.Hour, From: start,
+			To: start.Add(48 * time.Hour), Location: paris}})
+		want := strings.Join([]string{day(30), day(31), day(32)}, " ")
+		if got := fieldText(frames[0].Fields[0]); got != want {
+			t.Fatalf("times = %s, want %s", got, want)
+		}
+		if got := fieldText(frames[0].Fields[1]); got != "null null 1" {
+			t.Fatalf("values = %s, want null null 1", got)
+		}
+	})
+
+	t.Run("rows without time last", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("NUMBER", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).
+			AddRow(nil, "9").
+			AddRow("1700000100", "1")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{
+			Columns: seriesColumns{Time: "TIME"}, Fill: fill(fillValue, 0)})
+		if got := fieldText(frames[0].Fields[1]); got != "0 1 0 0 0 9" {
+			t.Fatalf("values = %s, want 0 1 0 0 0 9", got)
+		}
+		if got := fieldText(frames[0].Fields[0]); !strings.HasSuffix(got, " null") {
+			t.Fatalf("times = %s, want the row without time last", got)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE"}).
+			AddRow("1700000100", "1")
+		frames := sqlFrames(t, rows, formatTable, frameOptions{Fill: fill(fillNull, 0)})
+		if n := frames[0].Rows(); n != 1 {
+			t.Fatalf("rows of the table = %d, want 1", n)
+		}
+	})
+}
+
+func TestParseSQLFill(t *testing.T) {
+	from := time.Unix(1700000000, 0)
+	tests := []struct {
+		arg       string
+		wantMode  string
+		wantValue float64
+		wantErr   bool
+	}{
+		{arg: "NULL", wantMode: fillNull},
+		{arg: "previous", wantMode: fillPrevious},
+		{arg: "0", wantMode: fillValue},
+		{arg: "-1.5", wantMode: fillValue, wantValue: -1.5},
+		{arg: "next", wantErr: true},
+	}
+	for _, tc := range tests {
+		fill, err := parseSQLFill(tc.arg, time.Minute, from, from.Add(time.Hour), nil)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("parseSQLFill(%q) error = %v", tc.arg, err)
+		}
+		if err == nil && (fill.Mode != tc.wantMode || fill.Value != tc.wantValue) {
+			t.Fatalf("parseSQLFill(%q) = %+v", tc.arg, fill)
+		}
+	}
+	if _, err := parseSQLFill("0", time.Second, from, from.Add(31*24*time.Hour), nil); err == nil {
+		t.Fatalf("parseSQLFill of a month by the second, want an error")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// dataplaneTypeVersion is the version of the data plane contract followed by
+// the frames of every result mode.
+var dataplaneTypeVersion = data.FrameTypeVersion{0, 1}
+
+// setFrameType declares the data plane type of frames in their metadata. A
+// time series or numeric result without data is returned as a single frame
+// without fields, as the data plane contract expects.
+func setFrameType(frames data.Frames, frameType data.FrameType) data.Frames {
+	if len(frames) == 0 && frameType.Kind() != data.KindUnknown {
+		frames = append(frames, data.NewFrame(""))
+	}
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.Type = frameType
+		frame.Meta.TypeVersion = dataplaneTypeVersion
+	}
+	return frames
+}
+
+// tableFrameType returns the data plane type of the frame of a table result.
+// A table holding a time series in the long format, a single time field in
+// ascending order without NULL, numeric fields and string fields naming the
+// series, is declared TimeSeriesLong so that expressions and alerting use it
+// as is. Other tables are Table.
+func tableFrameType(frame *data.Frame) data.FrameType {
+	timeIdx, numbers := -1, 0
+	for i, field := range frame.Fields {
+		switch fieldType := field.Type().NonNullableType(); {
+		case fieldType.Time():
+			if timeIdx >= 0 {
+				return data.FrameTypeTable
+			}
+			timeIdx = i
+		case fieldType.Numeric():
+			numbers++
+		case fieldType != data.FieldTypeString:
+			return data.FrameTypeTable
+		}
+	}
+	if timeIdx < 0 || numbers == 0 {
+		return data.FrameTypeTable
+	}
+	var last time.Time
+	for row := 0; row < frame.Rows(); row++ {
+		value, ok := frame.Fields[timeIdx].ConcreteAt(row)
+		if !ok || value.(time.Time).Before(last) {
+			return data.FrameTypeTable
+		}
+		last = value.(time.Time)
+	}
+	return data.FrameTypeTimeSeriesLong
+}
+
+// sortSeriesFrames orders the frames of a multi time series result by the
+// name and labels of their value field, so that series built from maps are
+// returned in the same order on every refresh.
+func sortSeriesFrames(frames data.Frames) {
+	key := func(frame *data.Frame) string {
+		if len(frame.Fields) < 2 {
+			return ""
+		}
+		return frame.Fields[1].Name + "{" + frame.Fields[1].Labels.String() + "}"
+	}
+	sort.SliceStable(frames, func(i, j int) bool {
+		return key(frames[i]) < key(frames[j])
+	})
+}
+
+// nameFrames names the frames of a query after its refID and records the
+// query run against the database, so that every result mode is named the
+// same way and the query inspector shows the executed query.
+func nameFrames(frames data.Frames, refID string, executedQuery string) {
+	for _, frame := range frames {
+		frame.Name = refID
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.ExecutedQueryString = redactString(executedQuery)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestGetDataFrameFromRows_FrameTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   func() *sqlmock.Rows
+		format string
+		want   data.FrameType
+	}{
+		{
+			name: "table",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"HOST"}).AddRow("a")
+			},
+			format: formatTable,
+			want:   data.FrameTypeTable,
+		},
+		{
+			name: "long table",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRowsWithColumnDefinition(
+					sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+					sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+					sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+				).
+					AddRow("1700000000", "a", "1").
+					AddRow("1700000000", "b", "2").
+					AddRow("1700000060", "a", "3")
+			},
+			format: formatTable,
+			want:   data.FrameTypeTimeSeriesLong,
+		},
+		{
+			name: "table out of time order",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRowsWithColumnDefinition(
+					sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+					sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+				).
+					AddRow("1700000060", "1").
+					AddRow("1700000000", "2")
+			},
+			format: formatTable,
+			want:   data.FrameTypeTable,
+		},
+		{
+			name: "metric columns",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+					AddRow("1700000000", "1", "up", `{"host":"b"}`).
+					AddRow("1700000000", "1", "up", `{"host":"a"}`)
+			},
+			format: formatTimeSeries,
+			want:   data.FrameTypeTimeSeriesMulti,
+		},
+		{
+			name: "derived labels",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRowsWithColumnDefinition(
+					sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+					sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+					sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+				).
+					AddRow("1700000000", "b", "1").
+					AddRow("1700000000", "a", "1")
+			},
+			format: formatTimeSeries,
+			want:   data.FrameTypeTimeSeriesWide,
+		},
+		{
+			name: "time and values",
+			rows: func() *sqlmock.Rows {
+				return sqlmock.NewRowsWithColumnDefinition(
+					sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+					sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+				).AddRow("1700000000", "1")
+			},
+			format: formatTimeSeries,
+			want:   data.FrameTypeTimeSeriesWide,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			frames := sqlFrames(t, tc.rows(), tc.format, frameOptions{})
+			for _, frame := range frames {
+				if frame.Meta == nil || frame.Meta.Type != tc.want ||
+					frame.Meta.TypeVersion != dataplaneTypeVersion {
+					t.Fatalf("meta = %+v, want type %s %s", frame.Meta, tc.want,
+						dataplaneTypeVersion)
+				}
+			}
+			if tc.want == data.FrameTypeTimeSeriesMulti &&
+				frames[0].Fields[1].Labels.String() > frames[1].Fields[1].Labels.String() {
+				t.Fatalf("series not sorted: %v then %v", frames[0].Fields[1].Labels,
+					frames[1].Fields[1].Labels)
+			}
+		})
+	}
+}
+
+func TestPromResponseToFrames_FrameTypes(t *testing.T) {
+	tests := []struct {
+		body   string
+		want   data.FrameType
+		fields []data.FieldType
+	}{
+		{
+			body:   `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1,"1"]]}]}}`,
+			want:   data.FrameTypeTimeSeriesMulti,
+			fields: []data.FieldType{data.FieldTypeTime, data.FieldTypeFloat64},
+		},
+		{
+			body:   `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`,
+			want:   data.FrameTypeTimeSeriesMulti,
+			fields: []data.FieldType{data.FieldTypeTime, data.FieldTypeFloat64},
+		},
+		{
+			body:   `{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`,
+			want:   data.FrameTypeTimeSeriesWide,
+			fields: []data.FieldType{data.FieldTypeTime, data.FieldTypeFloat64},
+		},
+		{
+			body:   `{"status":"success","data":{"resultType":"string","result":[1,"a"]}}`,
+			want:   data.FrameTypeTable,
+			fields: []data.FieldType{data.FieldTypeTime, data.FieldTypeString},
+		},
+	}
+	for _, tc := range tests {
+		frames, _, err := promResponseToFrames([]byte(tc.body), "", frameOptions{})
+		if err != nil {
+			t.Fatalf("promResponseToFrames: %v", err)
+		}
+		if len(frames) != 1 || frames[0].Meta.Type != tc.want ||
+			frames[0].Meta.TypeVersion != dataplaneTypeVersion {
+			t.Fatalf("%s: meta = %+v, want type %s", tc.body, frames[0].Meta, tc.want)
+		}
+		var fields []data.FieldType
+		for _, field := range frames[0].Fields {
+			fields = append(fields, field.Type())
+		}
+		if !reflect.DeepEqual(fields, tc.fields) {
+			t.Fatalf("%s: fields = %v, want %v", tc.body, fields, tc.fields)
+		}
+	}
+}
+
+func TestSetFrameType_NoData(t *testing.T) {
+	frames := setFrameType(nil, data.FrameTypeTimeSeriesMulti)
+	if len(frames) != 1 || len(frames[0].Fields) != 0 ||
+		frames[0].Meta.Type != data.FrameTypeTimeSeriesMulti {
+		t.Fatalf("expected one typed frame without fields, got %v", frames)
+	}
+	if frames := setFrameType(nil, data.FrameTypeTable); len(frames) != 0 {
+		t.Fatalf("expected no table frame, got %d", len(frames))
+	}
+}
+
+func TestQuery_FrameNames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`promql_range`).WillReturnRows(promRangeRows("a"))
+	resp := query(context.Background(), backend.DataQuery{
+		RefID:     "A",
+		JSON:      json.RawMessage(`{"queryLang":"promql","exprProm":"up"}`),
+		TimeRange: testTimeRange,
+	}, db, queryConfig{DeploymentType: "ONPREM"})
+	if resp.Error != nil {
+		t.Fatalf("query: %v", resp.Error)
+	}
+	if len(resp.Frames) == 0 {
+		t.Fatalf("expected frames")
+	}
+	for _, frame := range resp.Frames {
+		if frame.Name != "A" {
+			t.Fatalf("frame name = %q, want the refID", frame.Name)
+		}
+		if frame.Meta.ExecutedQueryString == "" ||
+			frame.Meta.Type != data.FrameTypeTimeSeriesMulti {
+			t.Fatalf("meta = %+v, want the executed query and type", frame.Meta)
+		}
+	}
+}
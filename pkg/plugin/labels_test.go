@@ -0,0 +1,131 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestParseMetricTags(t *testing.T) {
+	tests := []struct {
+		tags    string
+		want    data.Labels
+		wantErr bool
+	}{
+		{tags: "", want: nil},
+		{tags: `{"host":"a","dc":"eu"}`, want: data.Labels{"host": "a", "dc": "eu"}},
+		{tags: `{"cpu":3,"up":true,"zone":null}`, want: data.Labels{"cpu": "3", "up": "true"}},
+		{tags: `not json`, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseMetricTags(tc.tags)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("parseMetricTags(%q) error = %v", tc.tags, err)
+		}
+		if !got.Equals(tc.want) {
+			t.Fatalf("parseMetricTags(%q) = %v, want %v", tc.tags, got, tc.want)
+		}
+	}
+}
+
+// seriesByLabels indexes the value fields of frames, one per series in
+// multi frames or all but the time in wide frames, by their labels.
+func seriesByLabels(frames data.Frames) map[string]*data.Field {
+	fields := map[string]*data.Field{}
+	for _, frame := range frames {
+		for _, field := range frame.Fields[1:] {
+			fields[field.Labels.String()] = field
+		}
+	}
+	return fields
+}
+
+func TestGetDataFrameFromRows_SeriesLabels(t *testing.T) {
+	t.Run("metric tags", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+			AddRow("1700000000", "1", "up", `{"host":"a"}`).
+			AddRow("1700000000", "2", "up", `{"host":"b"}`)
+		fields := seriesByLabels(sqlFrames(t, rows, formatTimeSeries, frameOptions{}))
+		if len(fields) != 2 || fields["host=a"] == nil || fields["host=b"] == nil {
+			t.Fatalf("series labels = %v", fields)
+		}
+	})
+
+	t.Run("char columns", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("DC").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).
+			AddRow("1700000000", "ab", "c", "1").
+			AddRow("1700000000", "a", "bc", "2").
+			AddRow("1700000000", "a", nil, "3")
+		fields := seriesByLabels(sqlFrames(t, rows, formatTimeSeries, frameOptions{}))
+		if len(fields) != 3 {
+			t.Fatalf("series = %d, want 3: %v", len(fields), fields)
+		}
+		for _, labels := range []string{"DC=c, HOST=ab", "DC=bc, HOST=a", "HOST=a"} {
+			if fields[labels] == nil {
+				t.Fatalf("no series with labels %s in %v", labels, fields)
+			}
+		}
+		//named by Grafana after the labels
+		if field := fields["DC=c, HOST=ab"]; field.Config != nil {
+			t.Fatalf("display name = %q, want none", field.Config.DisplayNameFromDS)
+		}
+	})
+
+	t.Run("char columns and many values", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("CPU").OfType("NUMBER", ""),
+			sqlmock.NewColumn("MEM").OfType("NUMBER", ""),
+		).AddRow("1700000000", "a", "1", "2")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{})
+		if len(frames) != 1 || len(frames[0].Fields) != 3 {
+			t.Fatalf("frames = %v, want one frame with time, CPU and MEM", frames)
+		}
+		names := map[string]bool{}
+		for _, field := range frames[0].Fields[1:] {
+			if !field.Labels.Equals(data.Labels{"HOST": "a"}) {
+				t.Fatalf("labels = %v, want HOST=a", field.Labels)
+			}
+			if field.Config != nil {
+				t.Fatalf("display name = %q, want none", field.Config.DisplayNameFromDS)
+			}
+			names[field.Name] = true
+		}
+		if !names["CPU"] || !names["MEM"] {
+			t.Fatalf("field names = %v, want CPU and MEM", names)
+		}
+	})
+}
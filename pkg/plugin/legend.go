@@ -0,0 +1,245 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// legendTemplate is a compiled legend format. The format is text with
+// {{name}} actions replaced by the value of the label, or column, name of a
+// series. The value of an action goes through the functions that follow it:
+//
+//	{{instance | regex "([^:]+):.*"}}  first group matched, or the match
+//	{{zone | default "n/a"}}           the text when the value is empty
+//
+// Function arguments are Go strings, in double quotes or backquotes. A \{{
+// in the text is written as {{.
+type legendTemplate struct {
+	parts []legendPart
+}
+
+// legendPart is either text or an action of a legend template.
+type legendPart struct {
+	text  string
+	name  string
+	funcs []legendFunc
+}
+
+// legendFunc is a function applied to the value of an action.
+type legendFunc struct {
+	name string
+	arg  string
+	re   *regexp.Regexp
+}
+
+// newLegend compiles a legend format. A format that cannot be compiled is
+// used as text, so that a typo does not fail the query.
+func newLegend(format string) *legendTemplate {
+	format = strings.TrimSpace(format)
+	t, err := parseLegend(format)
+	if err != nil {
+		customLogger("warning", "invalid legend format, used as text", err)
+		return &legendTemplate{parts: []legendPart{{text: format}}}
+	}
+	return t
+}
+
+// parseLegend compiles a legend format.
+func parseLegend(format string) (*legendTemplate, error) {
+	t := &legendTemplate{}
+	var text strings.Builder
+	for i := 0; i < len(format); {
+		switch {
+		case strings.HasPrefix(format[i:], `\{{`):
+			text.WriteString("{{")
+			i += 3
+		case strings.HasPrefix(format[i:], "{{"):
+			end := legendActionEnd(format, i+2)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed {{ at offset %d", i)
+			}
+			part, err := parseLegendAction(format[i+2 : end])
+			if err != nil {
+				return nil, fmt.Errorf("action at offset %d: %w", i, err)
+			}
+			if text.Len() > 0 {
+				t.parts = append(t.parts, legendPart{text: text.String()})
+				text.Reset()
+			}
+			t.parts = append(t.parts, part)
+			i = end + 2
+		default:
+			text.WriteByte(format[i])
+			i++
+		}
+	}
+	if text.Len() > 0 {
+		t.parts = append(t.parts, legendPart{text: text.String()})
+	}
+	return t, nil
+}
+
+// legendActionEnd returns the offset of the }} closing the action starting at
+// start, or -1. Quoted arguments may hold }}.
+func legendActionEnd(format string, start int) int {
+	seps := splitLegendAction(format[start:], "}}")
+	if len(seps) == 0 {
+		return -1
+	}
+	return start + seps[0]
+}
+
+// splitLegendAction returns the offsets of sep in an action, out of quoted
+// arguments.
+func splitLegendAction(action string, sep string) []int {
+	var offsets []int
+	var quote byte
+	for i := 0; i < len(action); i++ {
+		c := action[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(action[i:], sep):
+			offsets = append(offsets, i)
+			i += len(sep) - 1
+		}
+	}
+	return offsets
+}
+
+// parseLegendAction compiles the text of an action, between {{ and }}.
+func parseLegendAction(action string) (legendPart, error) {
+	var segments []string
+	last := 0
+	for _, offset := range splitLegendAction(action, "|") {
+		segments = append(segments, action[last:offset])
+		last = offset + 1
+	}
+	segments = append(segments, action[last:])
+
+	part := legendPart{name: strings.TrimSpace(segments[0])}
+	if part.name == "" {
+		return part, errors.New("missing label name")
+	}
+	for _, segment := range segments[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(segment), " ")
+		arg, err := strconv.Unquote(strings.TrimSpace(arg))
+		if err != nil {
+			return part, fmt.Errorf("%s expects a quoted argument", name)
+		}
+		fn := legendFunc{name: name, arg: arg}
+		switch name {
+		case "default":
+		case "regex":
+			if fn.re, err = regexp.Compile(arg); err != nil {
+				return part, err
+			}
+		default:
+			return part, fmt.Errorf("unknown function %q", name)
+		}
+		part.funcs = append(part.funcs, fn)
+	}
+	return part, nil
+}
+
+// execute returns the legend of a series with the given labels, a missing
+// label is empty. A label is looked up by its exact name first, then by its
+// name in any case, as SQL column names are upper case unless quoted.
+func (t *legendTemplate) execute(labels map[string]string) string {
+	var legend strings.Builder
+	for _, part := range t.parts {
+		if part.name == "" {
+			legend.WriteString(part.text)
+			continue
+		}
+		value := labelValueOf(labels, part.name)
+		for _, fn := range part.funcs {
+			switch fn.name {
+			case "default":
+				if value == "" {
+					value = fn.arg
+				}
+			case "regex":
+				match := fn.re.FindStringSubmatch(value)
+				switch {
+				case match == nil:
+					value = ""
+				case len(match) > 1:
+					value = match[1]
+				default:
+					value = match[0]
+				}
+			}
+		}
+		legend.WriteString(value)
+	}
+	return legend.String()
+}
+
+// legendLabels returns the labels a legend template of a series is executed
+// with, the labels of the series, its metric name as __name__, and the
+// values of the columns the series was built from by column name.
+func legendLabels(labels map[string]string, name string,
+	columns map[string]string) map[string]string {
+	vars := make(map[string]string, len(labels)+len(columns)+1)
+	for k, v := range columns {
+		vars[k] = v
+	}
+	for k, v := range labels {
+		vars[k] = v
+	}
+	vars["__name__"] = name
+	return vars
+}
+
+// labelValueOf returns the value of the label name, matched exactly if the
+// labels have it, else in any case. Of the labels differing only in case,
+// the first name in sorted order wins so that legends are stable.
+func labelValueOf(labels map[string]string, name string) string {
+	if value, ok := labels[name]; ok {
+		return value
+	}
+	found := ""
+	value := ""
+	for label, labelValue := range labels {
+		if strings.EqualFold(label, name) && (found == "" || label < found) {
+			found, value = label, labelValue
+		}
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLegendTemplate(t *testing.T) {
+	labels := map[string]string{"job": "node", "instance": "host:9100",
+		"__name__": "up", "HOST": "db1", "empty": ""}
+	tests := []struct {
+		legend string
+		want   string
+	}{
+		{"plain", "plain"},
+		{"  {{job}}  ", "node"},
+		{"{{job}} on {{instance}}", "node on host:9100"},
+		{"cpu {{job}} total", "cpu node total"},
+		{"{{missing}}", ""},
+		{"{{__name__}}/{{HOST}}", "up/db1"},
+		// labels are matched in any case when no name matches exactly
+		{"{{host}}", "db1"},
+		{"{{Job}} {{INSTANCE | regex `\\d+`}}", "node 9100"},
+		{`{{EMPTY | default "n/a"}}`, "n/a"},
+		{"{{ job }}", "node"},
+		{`{{zone | default "n/a"}}`, "n/a"},
+		{`{{empty | default "n/a"}}`, "n/a"},
+		{`{{job | default "n/a"}}`, "node"},
+		{`{{instance | regex "([^:]+):.*"}}`, "host"},
+		{"{{instance | regex `\\d+`}}", "9100"},
+		{`{{instance | regex "^x"}}`, ""},
+		{`{{instance | regex "^x" | default "other"}}`, "other"},
+		{`{{job | default "a|b}}c"}}`, "node"},
+		{`{{zone | default "say \"hi\""}}`, `say "hi"`},
+		{`\{{job}} is {{job}}`, "{{job}} is node"},
+		{"{{job}}}", "node}"},
+		// malformed formats are used as text
+		{"{{job", "{{job"},
+		{"}}{{", "}}{{"},
+		{"{{}}", "{{}}"},
+		{"a {{job | upper}}", "a {{job | upper}}"},
+		{`{{job | default n/a}}`, `{{job | default n/a}}`},
+		{`{{job | regex "("}}`, `{{job | regex "("}}`},
+		{`{{job | default "n/a}}`, `{{job | default "n/a}}`},
+	}
+	for _, tt := range tests {
+		if got := newLegend(tt.legend).execute(labels); got != tt.want {
+			t.Errorf("legend %q = %q, want %q", tt.legend, got, tt.want)
+		}
+	}
+
+	//an exact match wins over the labels differing in case
+	cased := map[string]string{"HOST": "a", "Host": "b", "host": "c"}
+	for legend, want := range map[string]string{"{{Host}}": "b", "{{host}}": "c",
+		"{{hOsT}}": "a"} {
+		if got := newLegend(legend).execute(cased); got != want {
+			t.Errorf("legend %q = %q, want %q", legend, got, want)
+		}
+	}
+}
+
+func TestGetDataFrameFromRows_DerivedLabelsLegend(t *testing.T) {
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+		sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+		sqlmock.NewColumn("CPU").OfType("NUMBER", ""),
+		sqlmock.NewColumn("MEM").OfType("NUMBER", ""),
+	).
+		AddRow("1700000000", "a", "1", "2")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	sqlRows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("db.Query: %v", err)
+	}
+	defer sqlRows.Close()
+
+	var processed int
+	var after time.Time
+	frames, _, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries,
+		"SELECT", "{{host}} {{__name__}}", "query", frameOptions{}, &processed,
+		&after)
+	if err != nil {
+		t.Fatalf("getDataFrameFromRows: %v", err)
+	}
+	var names []string
+	for _, frame := range frames {
+		for _, field := range frame.Fields[1:] {
+			names = append(names, field.Config.DisplayNameFromDS)
+		}
+	}
+	if got := strings.Join(names, ","); got != "a CPU,a MEM" {
+		t.Fatalf("legends = %s", got)
+	}
+}
@@ -0,0 +1,457 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macroContext holds the values the macros of a SQL query expand to.
+type macroContext struct {
+	//time range of the query
+	from time.Time
+	to   time.Time
+	//step of the query in seconds, the value of $__interval
+	step int64
+	//scrape interval of the datasource, 0 when not set
+	scrapeInterval time.Duration
+	//time zone of the data, nil for the time zone of the session
+	location *time.Location
+	//fill of the series set by the fill argument of $__timeGroup
+	fill *sqlFill
+}
+
+// defaultRateScrapeInterval is the scrape interval $__rate_interval assumes
+// when the datasource has none, as Grafana does.
+const defaultRateScrapeInterval = 15 * time.Second
+
+// sqlMacro is a macro of SQL queries, $__name(args...). The arguments are
+// expanded before the macro.
+type sqlMacro struct {
+	//number of arguments, a macro without arguments may be written without
+	//parentheses
+	args int
+	//number of optional arguments following them
+	optArgs int
+	//expand returns the SQL text replacing the macro
+	expand func(mc *macroContext, args []string) (string, error)
+}
+
+// sqlTimeBound returns the DATE of a bind variable of the time range,
+// :time_from or :time_to, in the time zone of the data. The bind variables
+// are TIMESTAMP WITH TIME ZONE values. DATE and TIMESTAMP columns are
+// compared with the DATE as they are, so their indexes are used, time zone
+// aware columns convert it from the time zone of the session, which is the
+// time zone of the data when it is set.
+func (mc *macroContext) sqlTimeBound(bind string) string {
+	zone := "LOCAL"
+	if mc.location != nil {
+		zone = "TIME ZONE '" + strings.ReplaceAll(mc.location.String(), "'", "''") + "'"
+	}
+	return "CAST(" + bind + " AT " + zone + " AS DATE)"
+}
+
+// sqlTimeGroup rounds a DATE or TIMESTAMP column down to an interval in
+// seconds, the same way as the Oracle datasource of Grafana. Time zone aware
+// columns are rounded in the time zone of the session.
+func sqlTimeGroup(col string, secs int64) string {
+	interval := strconv.FormatInt(secs, 10)
+	return "TO_DATE('19700101', 'YYYYMMDD') +" +
+		" ( 1 / 24 / 60 / 60 / 1000) * FLOOR((" + col +
+		" - TO_TIMESTAMP('1970-01-01 00:00:00'," +
+		"'yyyy-mm-dd hh24:mi:ss') + " +
+		"TO_DATE ('1970-01-01 00:00:00', 'YYYY-mm-dd HH24:MI:SS') " +
+		"- TO_DATE ('1970-01-01 00:00:00'," +
+		" 'YYYY-mm-dd HH24:MI:SS'))*24*60*60*1000/" + interval +
+		"/1000)*" + interval + "*1000"
+}
+
+// sqlEpochGroup rounds an epoch seconds column down to an interval in seconds.
+func sqlEpochGroup(col string, secs int64) string {
+	interval := strconv.FormatInt(secs, 10)
+	return "floor(" + col + "/" + interval + ")*" + interval
+}
+
+// sqlMacros are the macros of SQL queries by name, without the $__ prefix,
+// the macros of the SQL datasources of Grafana. Columns are DATE or TIMESTAMP
+// columns, with or without time zone, unless the macro is named unixEpoch.
+var sqlMacros = map[string]sqlMacro{
+	// $__time(col) names the time column of the result
+	"time": {args: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		return args[0] + " as time", nil
+	}},
+	// $__timeFilter(col) keeps the rows in the time range of the panel
+	"timeFilter": {args: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		return args[0] + " >= " + mc.sqlTimeBound(":time_from") + " and " +
+			args[0] + " <= " + mc.sqlTimeBound(":time_to"), nil
+	}},
+	// $__timeFrom() and $__timeTo() are the bounds of the time range
+	"timeFrom": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return mc.sqlTimeBound(":time_from"), nil
+	}},
+	"timeTo": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return mc.sqlTimeBound(":time_to"), nil
+	}},
+	// $__timeGroup(col, interval[, fill]) rounds the column down to the
+	// interval, $__timeGroupAlias names it as the time column. The fill,
+	// NULL, previous or a number, fills the intervals without rows.
+	"timeGroup": {args: 2, optArgs: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		secs, err := mc.timeGroupSecs(args)
+		if err != nil {
+			return "", err
+		}
+		return sqlTimeGroup(args[0], secs), nil
+	}},
+	"timeGroupAlias": {args: 2, optArgs: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		secs, err := mc.timeGroupSecs(args)
+		if err != nil {
+			return "", err
+		}
+		return sqlTimeGroup(args[0], secs) + " as time", nil
+	}},
+	// $__unixEpochFilter(col) keeps the rows of an epoch milliseconds column
+	// in the time range, as it always has unlike the seconds of the SQL
+	// datasources of Grafana, $__unixEpochMsFilter is the same and
+	// $__unixEpochNanoFilter filters an epoch nanoseconds column
+	"unixEpochFilter": {args: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		return args[0] + " >= :start_time*1000 and " + args[0] +
+			" <= :end_time*1000", nil
+	}},
+	"unixEpochMsFilter": {args: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		return args[0] + " >= :start_time*1000 and " + args[0] +
+			" <= :end_time*1000", nil
+	}},
+	"unixEpochNanoFilter": {args: 1, expand: func(mc *macroContext, args []string) (string, error) {
+		return args[0] + " >= :start_time*1000000000 and " + args[0] +
+			" <= :end_time*1000000000", nil
+	}},
+	// $__unixEpochFrom() and $__unixEpochTo() are the bounds of the time
+	// range in epoch seconds
+	"unixEpochFrom": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return ":start_time", nil
+	}},
+	"unixEpochTo": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return ":end_time", nil
+	}},
+	// $__unixEpochGroup(col, interval) rounds an epoch seconds column down to
+	// the interval, $__unixEpochGroupAlias names it as the time column
+	"unixEpochGroup": {args: 2, expand: func(mc *macroContext, args []string) (string, error) {
+		secs, err := mc.intervalSecs(args[1])
+		if err != nil {
+			return "", err
+		}
+		return sqlEpochGroup(args[0], secs), nil
+	}},
+	"unixEpochGroupAlias": {args: 2, expand: func(mc *macroContext, args []string) (string, error) {
+		secs, err := mc.intervalSecs(args[1])
+		if err != nil {
+			return "", err
+		}
+		return sqlEpochGroup(args[0], secs) + " as time", nil
+	}},
+	// $__interval is the step as a duration such as 30s or 1m, and
+	// $__interval_ms the step in milliseconds
+	"interval": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return formatIntervalText(mc.step), nil
+	}},
+	"interval_ms": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return strconv.FormatInt(mc.step*1000, 10), nil
+	}},
+	// $__range_s and $__range_ms are the length of the time range
+	"range_s": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return strconv.FormatInt(int64(mc.to.Sub(mc.from).Seconds()), 10), nil
+	}},
+	"range_ms": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		return strconv.FormatInt(mc.to.Sub(mc.from).Milliseconds(), 10), nil
+	}},
+	// $__rate_interval is the interval rate() needs to see two samples,
+	// computed as Grafana does from the step and the scrape interval
+	"rate_interval": {args: 0, expand: func(mc *macroContext, args []string) (string, error) {
+		scrape := int64(mc.scrapeInterval.Seconds())
+		if scrape <= 0 {
+			scrape = int64(defaultRateScrapeInterval.Seconds())
+		}
+		rate := mc.step + scrape
+		if 4*scrape > rate {
+			rate = 4 * scrape
+		}
+		return formatIntervalText(rate), nil
+	}},
+}
+
+// formatIntervalText returns seconds as a duration in the largest unit
+// dividing them, 90s, 5m or 1h.
+func formatIntervalText(secs int64) string {
+	for _, unit := range []struct {
+		secs int64
+		name string
+	}{{86400, "d"}, {3600, "h"}, {60, "m"}} {
+		if secs >= unit.secs && secs%unit.secs == 0 {
+			return strconv.FormatInt(secs/unit.secs, 10) + unit.name
+		}
+	}
+	return strconv.FormatInt(secs, 10) + "s"
+}
+
+// timeGroupSecs returns the interval of the arguments of $__timeGroup and
+// sets the fill of the query from the fill argument.
+func (mc *macroContext) timeGroupSecs(args []string) (int64, error) {
+	secs, err := mc.intervalSecs(args[1])
+	if err != nil || len(args) < 3 {
+		return secs, err
+	}
+	mc.fill, err = parseSQLFill(args[2], time.Duration(secs)*time.Second,
+		mc.from, mc.to, mc.location)
+	return secs, err
+}
+
+// intervalSecs parses the interval argument of a macro, seconds or a number
+// followed by s, m, h or d, quoted or not.
+func (mc *macroContext) intervalSecs(arg string) (int64, error) {
+	arg = strings.Trim(strings.TrimSpace(arg), "'")
+	units := map[byte]int64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400}
+	num, unit := arg, int64(1)
+	if n := len(arg); n > 0 && units[arg[n-1]] > 0 {
+		num, unit = arg[:n-1], units[arg[n-1]]
+	}
+	value, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid interval %q", arg)
+	}
+	return value * unit, nil
+}
+
+// macroError is a macro of a SQL query that cannot be expanded, at a line and
+// column of the query text.
+type macroError struct {
+	Macro  string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *macroError) Error() string {
+	return fmt.Sprintf("macro $__%s at line %d, column %d: %s", e.Macro,
+		e.Line, e.Column, e.Msg)
+}
+
+// macroExpander expands the macros of the text of a SQL query.
+type macroExpander struct {
+	src string
+	mc  *macroContext
+}
+
+// expandSQLMacros returns the text of a SQL query with every macro replaced
+// by SQL. Macros inside string literals, quoted identifiers and comments are
+// left as they are, unknown $__ names are left for Grafana. Macros may set
+// the fill of mc.
+func expandSQLMacros(sqlText string, mc *macroContext) (string, error) {
+	e := &macroExpander{src: sqlText, mc: mc}
+	return e.expand(0, len(sqlText))
+}
+
+// errorAt returns a macroError at offset pos of the query text.
+func (e *macroExpander) errorAt(pos int, name string, format string,
+	args ...interface{}) error {
+	line := strings.Count(e.src[:pos], "\n") + 1
+	column := pos - strings.LastIndex(e.src[:pos], "\n")
+	return &macroError{Macro: name, Line: line, Column: column,
+		Msg: fmt.Sprintf(format, args...)}
+}
+
+// expand expands the macros of src[start:end].
+func (e *macroExpander) expand(start, end int) (string, error) {
+	var out strings.Builder
+	for i := start; i < end; {
+		if next := skipSQLLiteral(e.src[:end], i); next > i {
+			out.WriteString(e.src[i:next])
+			i = next
+			continue
+		}
+		if !strings.HasPrefix(e.src[i:end], "$__") {
+			out.WriteByte(e.src[i])
+			i++
+			continue
+		}
+		j := i + 3
+		for j < end && isSQLIdentChar(e.src[j]) {
+			j++
+		}
+		name := e.src[i+3 : j]
+		macro, ok := sqlMacros[name]
+		if !ok {
+			out.WriteString(e.src[i:j])
+			i = j
+			continue
+		}
+		args, next, err := e.macroArgs(i, j, end, name)
+		if err != nil {
+			return "", err
+		}
+		if macro.optArgs == 0 && len(args) != macro.args {
+			return "", e.errorAt(i, name, "expected %d arguments, got %d",
+				macro.args, len(args))
+		}
+		if len(args) < macro.args || len(args) > macro.args+macro.optArgs {
+			return "", e.errorAt(i, name, "expected %d to %d arguments, got %d",
+				macro.args, macro.args+macro.optArgs, len(args))
+		}
+		text, err := macro.expand(e.mc, args)
+		if err != nil {
+			return "", e.errorAt(i, name, "%v", err)
+		}
+		out.WriteString(text)
+		i = next
+	}
+	return out.String(), nil
+}
+
+// macroArgs returns the expanded arguments of the macro at pos whose name
+// ends at open, and the offset following the macro.
+func (e *macroExpander) macroArgs(pos, open, end int, name string) (
+	[]string, int, error) {
+	if open >= end || e.src[open] != '(' {
+		return nil, open, nil
+	}
+	var args []string
+	depth := 0
+	argStart := open + 1
+	for i := open + 1; i < end; {
+		if next := skipSQLLiteral(e.src[:end], i); next > i {
+			i = next
+			continue
+		}
+		switch e.src[i] {
+		case '(':
+			depth++
+		case ')', ',':
+			if depth > 0 {
+				if e.src[i] == ')' {
+					depth--
+				}
+				break
+			}
+			arg, err := e.expand(argStart, i)
+			if err != nil {
+				return nil, 0, err
+			}
+			arg = strings.TrimSpace(arg)
+			if arg == "" && (e.src[i] == ',' || len(args) > 0) {
+				return nil, 0, e.errorAt(pos, name, "empty argument %d",
+					len(args)+1)
+			}
+			if arg != "" {
+				args = append(args, arg)
+			}
+			if e.src[i] == ')' {
+				return args, i + 1, nil
+			}
+			argStart = i + 1
+		}
+		i++
+	}
+	return nil, 0, e.errorAt(pos, name, "missing closing parenthesis")
+}
+
+// isSQLIdentChar reports whether c can be part of an unquoted identifier.
+func isSQLIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c >= '0' && c <= '9' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// skipSQLLiteral returns the offset following the string literal, quoted
+// identifier or comment starting at offset i of text, or i if there is none.
+// Literals and comments left open end with the text.
+func skipSQLLiteral(text string, i int) int {
+	rest := text[i:]
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		if n := strings.IndexByte(rest, '\n'); n >= 0 {
+			return i + n + 1
+		}
+		return len(text)
+	case strings.HasPrefix(rest, "/*"):
+		if n := strings.Index(rest[2:], "*/"); n >= 0 {
+			return i + 2 + n + 2
+		}
+		return len(text)
+	case rest[0] == '\'':
+		//a quote inside a literal is doubled
+		for j := i + 1; j < len(text); j++ {
+			if text[j] == '\'' {
+				if j+1 < len(text) && text[j+1] == '\'' {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+		return len(text)
+	case rest[0] == '"':
+		if n := strings.IndexByte(rest[1:], '"'); n >= 0 {
+			return i + 1 + n + 1
+		}
+		return len(text)
+	case (rest[0] == 'q' || rest[0] == 'Q') && len(rest) > 2 && rest[1] == '\'' &&
+		!isIdentifierEnd(text, i):
+		//alternative quoting, q'[...]', the closing delimiter is followed
+		//by a quote
+		closing := rest[2]
+		switch closing {
+		case '[':
+			closing = ']'
+		case '{':
+			closing = '}'
+		case '<':
+			closing = '>'
+		case '(':
+			closing = ')'
+		}
+		if n := strings.Index(rest[3:], string(closing)+"'"); n >= 0 {
+			return i + 3 + n + 2
+		}
+		return len(text)
+	}
+	return i
+}
+
+// isIdentifierEnd reports whether the q at offset i of text ends an
+// identifier rather than starting a q'...' literal, the n of nq'...'
+// literals excepted.
+func isIdentifierEnd(text string, i int) bool {
+	if i == 0 || !isSQLIdentChar(text[i-1]) {
+		return false
+	}
+	if (text[i-1] == 'n' || text[i-1] == 'N') &&
+		(i == 1 || !isSQLIdentChar(text[i-2])) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestExpandSQLMacros(t *testing.T) {
+	const filter = " >= CAST(:time_from AT LOCAL AS DATE) and "
+	const filterEnd = " <= CAST(:time_to AT LOCAL AS DATE)"
+	group := func(col, secs string) string {
+		return "TO_DATE('19700101', 'YYYYMMDD') + ( 1 / 24 / 60 / 60 / 1000) * FLOOR((" +
+			col + " - TO_TIMESTAMP('1970-01-01 00:00:00','yyyy-mm-dd hh24:mi:ss') + " +
+			"TO_DATE ('1970-01-01 00:00:00', 'YYYY-mm-dd HH24:MI:SS') - " +
+			"TO_DATE ('1970-01-01 00:00:00', 'YYYY-mm-dd HH24:MI:SS'))*24*60*60*1000/" +
+			secs + "/1000)*" + secs + "*1000"
+	}
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "time",
+			sql:  "select $__time(ts), v from t",
+			want: "select ts as time, v from t",
+		},
+		{
+			name: "time filter with nested parentheses",
+			sql:  "where $__timeFilter(CAST(ts AS DATE))",
+			want: "where CAST(ts AS DATE)" + filter + "CAST(ts AS DATE)" + filterEnd,
+		},
+		{
+			name: "every occurrence",
+			sql:  "$__time(a) $__time(b) $__timeFilter(a) or $__timeFilter(b)",
+			want: "a as time b as time a" + filter + "a" + filterEnd + " or b" + filter + "b" + filterEnd,
+		},
+		{
+			name: "unix epoch filter",
+			sql:  "where $__unixEpochFilter( ms )",
+			want: "where ms >= :start_time*1000 and ms <= :end_time*1000",
+		},
+		{
+			name: "unix epoch milliseconds filter",
+			sql:  "where $__unixEpochMsFilter( ms )",
+			want: "where ms >= :start_time*1000 and ms <= :end_time*1000",
+		},
+		{
+			name: "time group",
+			sql:  "select $__timeGroup(ts, 5m) from t group by $__timeGroup(ts,'1h')",
+			want: "select " + group("ts", "300") + " from t group by " + group("ts", "3600"),
+		},
+		{
+			name: "time group with the step",
+			sql:  "$__timeGroup(ts, $__interval)",
+			want: group("ts", "30"),
+		},
+		{
+			name: "nested macros",
+			sql:  "$__time($__timeGroup(ts, 30))",
+			want: group("ts", "30") + " as time",
+		},
+		{
+			name: "literals and comments",
+			sql: "select '$__time(a)', 'it''s $__time(b)', q'[$__time(c)]', Q'{x}'," +
+				" nq'<$__time(d)>', \"$__time(e)\" -- $__time(f)\n" +
+				"/* $__time(g) */ $__time(h)",
+			want: "select '$__time(a)', 'it''s $__time(b)', q'[$__time(c)]', Q'{x}'," +
+				" nq'<$__time(d)>', \"$__time(e)\" -- $__time(f)\n" +
+				"/* $__time(g) */ h as time",
+		},
+		{
+			name: "identifier ending with q",
+			sql:  "select seq'$__time(a)' from t",
+			want: "select seq'$__time(a)' from t",
+		},
+		{
+			name: "argument with literal",
+			sql:  "$__timeFilter(decode(x, ')', a, b))",
+			want: "decode(x, ')', a, b)" + filter + "decode(x, ')', a, b)" + filterEnd,
+		},
+		{
+			name: "unknown macro",
+			sql:  "select $__interval_xyz, $__timeFilterx from t",
+			want: "select $__interval_xyz, $__timeFilterx from t",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandSQLMacros(tt.sql, &macroContext{step: 30})
+			if err != nil {
+				t.Fatalf("expandSQLMacros: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expandSQLMacros(%q)\n got %q\nwant %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "update the golden files of testdata")
+
+// macroGoldenQueries are the queries of testdata/macros.golden, expanded
+// for a one hour range, a 30s step and a 1m scrape interval, with the time
+// zone of the session and with the time zone of the data set.
+var macroGoldenQueries = []string{
+	"select $__time(ts), v from t",
+	"where $__timeFilter(ts)",
+	"where $__timeFilter(created_tstz)",
+	"where ts between $__timeFrom() and $__timeTo()",
+	"select $__timeGroup(ts, 5m), avg(v) from t group by $__timeGroup(ts, 5m)",
+	"select $__timeGroupAlias(ts, $__interval), avg(v) from t",
+	"select $__timeGroupAlias(ts, 5m, previous), avg(v) from t",
+	"where $__unixEpochFilter(epoch_ms)",
+	"where $__unixEpochMsFilter(epoch_ms)",
+	"where $__unixEpochNanoFilter(epoch_ns)",
+	"where epoch_s between $__unixEpochFrom() and $__unixEpochTo()",
+	"select $__unixEpochGroup(epoch_s, '1h'), count(*) from t",
+	"select $__unixEpochGroupAlias(epoch_s, $__interval), count(*) from t",
+	"select $__interval, $__interval_ms, $__range_s, $__range_ms, $__rate_interval from dual",
+}
+
+func TestExpandSQLMacros_Golden(t *testing.T) {
+	from := time.Unix(1700000000, 0).UTC()
+	mc := macroContext{
+		from:           from,
+		to:             from.Add(time.Hour),
+		step:           30,
+		scrapeInterval: time.Minute,
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got strings.Builder
+	for _, location := range []*time.Location{nil, paris} {
+		mc.location = location
+		for _, sql := range macroGoldenQueries {
+			expanded, err := expandSQLMacros(sql, &mc)
+			if err != nil {
+				t.Fatalf("expandSQLMacros(%q): %v", sql, err)
+			}
+			if location != nil {
+				sql += " -- time zone " + location.String()
+			}
+			got.WriteString("-- " + sql + "\n" + expanded + "\n\n")
+		}
+	}
+	path := filepath.Join("testdata", "macros.golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Fatalf("expanded macros differ from %s, run go test -update\n got:\n%s", path, got.String())
+	}
+}
+
+func TestExpandSQLMacros_Fill(t *testing.T) {
+	from := time.Unix(1700000000, 0)
+	mc := macroContext{from: from, to: from.Add(time.Hour), step: 30}
+	if _, err := expandSQLMacros("select $__timeGroup(ts, 5m) from t", &mc); err != nil || mc.fill != nil {
+		t.Fatalf("fill = %+v, %v, want no fill", mc.fill, err)
+	}
+	if _, err := expandSQLMacros("select $__timeGroupAlias(ts, $__interval, 0) from t", &mc); err != nil {
+		t.Fatalf("expandSQLMacros: %v", err)
+	}
+	want := sqlFill{Mode: fillValue, Interval: 30 * time.Second, From: mc.from, To: mc.to}
+	if mc.fill == nil || *mc.fill != want {
+		t.Fatalf("fill = %+v, want %+v", mc.fill, want)
+	}
+}
+
+func TestFormatIntervalText(t *testing.T) {
+	tests := map[int64]string{1: "1s", 90: "90s", 300: "5m", 5400: "90m", 7200: "2h", 86400: "1d"}
+	for secs, want := range tests {
+		if got := formatIntervalText(secs); got != want {
+			t.Errorf("formatIntervalText(%d) = %q, want %q", secs, got, want)
+		}
+	}
+}
+
+func TestExpandSQLMacros_Errors(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"select 1\nwhere $__timeFilter(ts", "macro $__timeFilter at line 2, column 7: missing closing parenthesis"},
+		{"where $__timeFilter", "macro $__timeFilter at line 1, column 7: expected 1 arguments, got 0"},
+		{"$__timeGroup(ts)", "macro $__timeGroup at line 1, column 1: expected 2 to 3 arguments, got 1"},
+		{"$__timeGroup(ts, 5x)", `macro $__timeGroup at line 1, column 1: invalid interval "5x"`},
+		{"$__timeGroup(ts, 5m, next)", `macro $__timeGroup at line 1, column 1: invalid fill "next", expected NULL, previous or a number`},
+		{"$__timeGroup(ts, 5m, 0, 1)", "macro $__timeGroup at line 1, column 1: expected 2 to 3 arguments, got 4"},
+		{"$__timeGroup(ts, )", "macro $__timeGroup at line 1, column 1: empty argument 2"},
+		{"$__timeGroup(ts,,5m)", "macro $__timeGroup at line 1, column 1: empty argument 2"},
+		{"$__time($__timeGroup(ts))", "macro $__timeGroup at line 1, column 9: expected 2 to 3 arguments, got 1"},
+	}
+	for _, tt := range tests {
+		_, err := expandSQLMacros(tt.sql, &macroContext{step: 30})
+		var macroErr *macroError
+		if !errors.As(err, &macroErr) || err.Error() != tt.want {
+			t.Errorf("expandSQLMacros(%q) = %v, want %q", tt.sql, err, tt.want)
+		}
+	}
+}
+
+func TestQuery_MacroError(t *testing.T) {
+	resp := query(context.Background(), backend.DataQuery{
+		RefID: "A",
+		JSON: json.RawMessage(`{"queryLang":"sql",` +
+			`"exprSql":"select * from t where $__timeFilter(ts"}`),
+		TimeRange: testTimeRange,
+	}, nil, queryConfig{})
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "missing closing parenthesis") {
+		t.Fatalf("error = %v, want the macro error", resp.Error)
+	}
+}
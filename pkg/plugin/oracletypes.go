@@ -0,0 +1,205 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLOBTextSize is the size in bytes above which LOB and binary values are
+// truncated in the frames returned to Grafana.
+const maxLOBTextSize = 64 * 1024
+
+// truncatedSuffix marks a value truncated to maxLOBTextSize.
+const truncatedSuffix = "..."
+
+// secondsPerMonth is the average length of a month, used to return INTERVAL
+// YEAR TO MONTH values as a duration.
+const secondsPerMonth = 365.25 * 24 * 60 * 60 / 12
+
+// oracleTypeVector is the database type name godror reports for VECTOR
+// columns, which it has no name for, OTHER[DPI_ORACLE_TYPE_VECTOR].
+const oracleTypeVector = "OTHER[2033]"
+
+// oracleTypeKinds maps the database type names reported by godror to the
+// kind of field their values are returned in. godror names BINARY_FLOAT and
+// BINARY_DOUBLE FLOAT and DOUBLE, UROWID ROWID, and integer types NUMBER
+// with their precision, see sqlColumnKind. Types missing from the table are
+// returned as text.
+var oracleTypeKinds = map[string]sqlValueKind{
+	"NUMBER":         sqlNumberKind,
+	"FLOAT":          sqlNumberKind,
+	"DOUBLE":         sqlNumberKind,
+	"BINARY_INTEGER": sqlIntKind,
+	"BOOLEAN":        sqlBoolKind,
+
+	"DATE":                           sqlTimeKind,
+	"TIMESTAMP":                      sqlTimeKind,
+	"TIMESTAMP WITH TIME ZONE":       sqlTimeKind,
+	"TIMESTAMP WITH LOCAL TIME ZONE": sqlTimeKind,
+	"INTERVAL DAY TO SECOND":         sqlDurationKind,
+	"INTERVAL YEAR TO MONTH":         sqlDurationKind,
+
+	"VARCHAR2":  sqlStringKind,
+	"CHAR":      sqlStringKind,
+	"NVARCHAR2": sqlStringKind,
+	"NCHAR":     sqlStringKind,
+
+	"ROWID":          sqlTextKind,
+	"JSON":           sqlTextKind,
+	oracleTypeVector: sqlTextKind,
+	"XMLTYPE":        sqlTextKind,
+
+	"LONG":  sqlLOBKind,
+	"CLOB":  sqlLOBKind,
+	"NCLOB": sqlLOBKind,
+
+	"RAW":      sqlBinaryKind,
+	"LONG RAW": sqlBinaryKind,
+	"BLOB":     sqlBinaryKind,
+}
+
+// timeColumnNames are the columns handled as time whatever their type.
+var timeColumnNames = map[string]bool{
+	"METRIC_TIME":       true,
+	"METRIC_TIME_EPOCH": true,
+	"TIME":              true,
+}
+
+// oracleTypeKind returns the kind of field of a database type name.
+func oracleTypeKind(dataType string) sqlValueKind {
+	if kind, ok := oracleTypeKinds[dataType]; ok {
+		return kind
+	}
+	return sqlTextKind
+}
+
+// sqlColumnKind returns the kind of field of a column of a SQL result.
+// NUMBER columns without decimals are returned as integers, INTEGER columns
+// are reported as NUMBER(38) by the database. A column holding a value
+// outside of the int64 range is returned as numbers instead, see
+// widenIntField.
+func sqlColumnKind(col *sql.ColumnType) sqlValueKind {
+	dataType := col.DatabaseTypeName()
+	if timeColumnNames[col.Name()] {
+		return sqlTimeKind
+	}
+	kind := oracleTypeKind(dataType)
+	if dataType == "NUMBER" {
+		precision, scale, ok := col.DecimalSize()
+		if ok && scale == 0 && precision > 0 {
+			return sqlIntKind
+		}
+	}
+	return kind
+}
+
+// isSeriesValueKind reports whether columns of kind can be the values of a
+// time series.
+func (k sqlValueKind) isSeriesValueKind() bool {
+	return k == sqlNumberKind || k == sqlIntKind || k == sqlDurationKind
+}
+
+// isSeriesLabelKind reports whether columns of kind can identify a time
+// series.
+func (k sqlValueKind) isSeriesLabelKind() bool {
+	return k == sqlStringKind || k == sqlBoolKind
+}
+
+// isLogLineKind reports whether columns of kind can hold the line of log
+// lines.
+func (k sqlValueKind) isLogLineKind() bool {
+	return k == sqlStringKind || k == sqlTextKind || k == sqlLOBKind
+}
+
+// unit returns the unit of the fields of kind.
+func (k sqlValueKind) unit() string {
+	if k == sqlDurationKind {
+		return "s"
+	}
+	return ""
+}
+
+// parseOracleInterval returns the seconds of an interval scanned as text,
+// either the nanoseconds of an INTERVAL DAY TO SECOND, or the Oracle literal
+// of an INTERVAL DAY TO SECOND ("+01 02:03:04.5") or YEAR TO MONTH ("+01-02").
+func parseOracleInterval(raw string) (float64, error) {
+	text := strings.TrimSpace(raw)
+	if nanos, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return time.Duration(nanos).Seconds(), nil
+	}
+	invalid := errors.New("invalid interval value " + raw)
+	sign := float64(1)
+	if strings.HasPrefix(text, "-") {
+		sign = -1
+	}
+	text = strings.TrimLeft(text, "+-")
+	if days, clock, ok := strings.Cut(text, " "); ok {
+		d, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		parts := strings.Split(clock, ":")
+		if len(parts) != 3 {
+			return 0, invalid
+		}
+		h, errH := strconv.ParseInt(parts[0], 10, 64)
+		m, errM := strconv.ParseInt(parts[1], 10, 64)
+		s, errS := strconv.ParseFloat(parts[2], 64)
+		if errH != nil || errM != nil || errS != nil {
+			return 0, invalid
+		}
+		return sign * (float64(((d*24)+h)*60+m)*60 + s), nil
+	}
+	if years, months, ok := strings.Cut(text, "-"); ok {
+		//godror signs both parts of a negative interval, "-1--2"
+		y, errY := strconv.ParseInt(years, 10, 64)
+		m, errM := strconv.ParseInt(strings.TrimLeft(months, "-"), 10, 64)
+		if errY != nil || errM != nil {
+			return 0, invalid
+		}
+		return sign * float64(y*12+m) * secondsPerMonth, nil
+	}
+	return 0, invalid
+}
+
+// capLOBText truncates text to maxLOBTextSize bytes on a character boundary.
+func capLOBText(text string) string {
+	if len(text) <= maxLOBTextSize {
+		return text
+	}
+	end := maxLOBTextSize
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end] + truncatedSuffix
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestSQLColumnKind(t *testing.T) {
+	tests := []struct {
+		column *sqlmock.Column
+		want   sqlValueKind
+	}{
+		{sqlmock.NewColumn("N").OfType("NUMBER", ""), sqlNumberKind},
+		{sqlmock.NewColumn("N").OfType("NUMBER", "").WithPrecisionAndScale(10, 2), sqlNumberKind},
+		{sqlmock.NewColumn("N").OfType("NUMBER", "").WithPrecisionAndScale(10, 0), sqlIntKind},
+		{sqlmock.NewColumn("N").OfType("NUMBER", "").WithPrecisionAndScale(38, 0), sqlIntKind},
+		{sqlmock.NewColumn("N").OfType("FLOAT", ""), sqlNumberKind},
+		{sqlmock.NewColumn("N").OfType("DOUBLE", ""), sqlNumberKind},
+		{sqlmock.NewColumn("N").OfType("BINARY_INTEGER", ""), sqlIntKind},
+		{sqlmock.NewColumn("B").OfType("BOOLEAN", ""), sqlBoolKind},
+		{sqlmock.NewColumn("D").OfType("DATE", ""), sqlTimeKind},
+		{sqlmock.NewColumn("D").OfType("TIMESTAMP", ""), sqlTimeKind},
+		{sqlmock.NewColumn("D").OfType("TIMESTAMP WITH TIME ZONE", ""), sqlTimeKind},
+		{sqlmock.NewColumn("D").OfType("TIMESTAMP WITH LOCAL TIME ZONE", ""), sqlTimeKind},
+		{sqlmock.NewColumn("TIME").OfType("NUMBER", ""), sqlTimeKind},
+		{sqlmock.NewColumn("METRIC_TIME_EPOCH").OfType("NUMBER", ""), sqlTimeKind},
+		{sqlmock.NewColumn("I").OfType("INTERVAL DAY TO SECOND", ""), sqlDurationKind},
+		{sqlmock.NewColumn("I").OfType("INTERVAL YEAR TO MONTH", ""), sqlDurationKind},
+		{sqlmock.NewColumn("S").OfType("VARCHAR2", ""), sqlStringKind},
+		{sqlmock.NewColumn("S").OfType("CHAR", ""), sqlStringKind},
+		{sqlmock.NewColumn("S").OfType("NVARCHAR2", ""), sqlStringKind},
+		{sqlmock.NewColumn("S").OfType("NCHAR", ""), sqlStringKind},
+		{sqlmock.NewColumn("S").OfType("ROWID", ""), sqlTextKind},
+		{sqlmock.NewColumn("S").OfType("JSON", ""), sqlTextKind},
+		//VECTOR, godror has no name for it
+		{sqlmock.NewColumn("S").OfType("OTHER[2033]", ""), sqlTextKind},
+		{sqlmock.NewColumn("S").OfType("OTHER[2030]", ""), sqlTextKind},
+		{sqlmock.NewColumn("L").OfType("LONG", ""), sqlLOBKind},
+		{sqlmock.NewColumn("L").OfType("CLOB", ""), sqlLOBKind},
+		{sqlmock.NewColumn("L").OfType("NCLOB", ""), sqlLOBKind},
+		{sqlmock.NewColumn("R").OfType("RAW", ""), sqlBinaryKind},
+		{sqlmock.NewColumn("R").OfType("LONG RAW", ""), sqlBinaryKind},
+		{sqlmock.NewColumn("R").OfType("BLOB", ""), sqlBinaryKind},
+	}
+
+	for _, tc := range tests {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock.New: %v", err)
+		}
+		mock.ExpectQuery("SELECT").WillReturnRows(
+			sqlmock.NewRowsWithColumnDefinition(tc.column))
+		rows, err := db.Query("SELECT")
+		if err != nil {
+			t.Fatalf("db.Query: %v", err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatalf("ColumnTypes: %v", err)
+		}
+		if got := sqlColumnKind(types[0]); got != tc.want {
+			t.Errorf("%s %s: kind = %v, want %v", tc.column.Name(),
+				tc.column.DbType(), got, tc.want)
+		}
+		rows.Close()
+		db.Close()
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	long := strings.Repeat("é", maxLOBTextSize)
+	tests := []struct {
+		name string
+		kind sqlValueKind
+		raw  sql.NullString
+		want interface{}
+	}{
+		{"number", sqlNumberKind, sql.NullString{String: "1.5", Valid: true}, 1.5},
+		{"int", sqlIntKind, sql.NullString{String: "9007199254740993", Valid: true}, int64(9007199254740993)},
+		{"bool", sqlBoolKind, sql.NullString{String: "true", Valid: true}, true},
+		{"bool digit", sqlBoolKind, sql.NullString{String: "0", Valid: true}, false},
+		{"interval nanoseconds", sqlDurationKind, sql.NullString{String: "90000000000", Valid: true}, 90.0},
+		{"interval day to second", sqlDurationKind, sql.NullString{String: "+01 02:03:04.5", Valid: true}, 93784.5},
+		{"interval year to month", sqlDurationKind, sql.NullString{String: "1-6", Valid: true}, 18 * secondsPerMonth},
+		{"negative interval", sqlDurationKind, sql.NullString{String: "-1--6", Valid: true}, -18 * secondsPerMonth},
+		{"string", sqlStringKind, sql.NullString{String: "a", Valid: true}, "a"},
+		{"rowid", sqlTextKind, sql.NullString{String: "AAAR3sAAEAAAACXAAA", Valid: true}, "AAAR3sAAEAAAACXAAA"},
+		{"clob", sqlLOBKind, sql.NullString{String: "doc", Valid: true}, "doc"},
+		{"raw", sqlBinaryKind, sql.NullString{String: "\x01\xab", Valid: true}, "01ab"},
+		{"time", sqlTimeKind, sql.NullString{String: "1700000000", Valid: true}, time.Unix(1700000000, 0)},
+		{"null int", sqlIntKind, sql.NullString{}, nil},
+		{"null bool", sqlBoolKind, sql.NullString{}, nil},
+		{"null interval", sqlDurationKind, sql.NullString{}, nil},
+		{"null clob", sqlLOBKind, sql.NullString{}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := sqlValue(tc.raw, tc.kind, frameOptions{})
+			if err != nil {
+				t.Fatalf("sqlValue: %v", err)
+			}
+			field := newSQLField("f", nil, tc.kind, frameOptions{})
+			field.Append(value)
+			got, ok := field.ConcreteAt(0)
+			if tc.want == nil {
+				if ok {
+					t.Fatalf("value = %v, want null", got)
+				}
+				return
+			}
+			if want, isFloat := tc.want.(float64); isFloat {
+				if math.Abs(got.(float64)-want) > 1e-6 {
+					t.Fatalf("value = %v, want %v", got, want)
+				}
+				return
+			}
+			if wantTime, isTime := tc.want.(time.Time); isTime {
+				if !got.(time.Time).Equal(wantTime) {
+					t.Fatalf("value = %v, want %v", got, wantTime)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Fatalf("value = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("lob capped", func(t *testing.T) {
+		value, _ := sqlValue(sql.NullString{String: long, Valid: true},
+			sqlLOBKind, frameOptions{})
+		text := *value.(*string)
+		if len(text) > maxLOBTextSize+len(truncatedSuffix) ||
+			!strings.HasSuffix(text, "é"+truncatedSuffix) {
+			t.Fatalf("capped value has %d bytes, ends with %q", len(text),
+				text[len(text)-8:])
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for kind, raw := range map[sqlValueKind]string{
+			sqlIntKind: "1.5", sqlBoolKind: "maybe", sqlDurationKind: "1 day",
+		} {
+			if _, err := sqlValue(sql.NullString{String: raw, Valid: true},
+				kind, frameOptions{}); err == nil {
+				t.Errorf("kind %v: expected an error for %q", kind, raw)
+			}
+		}
+	})
+}
+
+func TestGetDataFrameFromRows_OracleTypes(t *testing.T) {
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("ID").OfType("NUMBER", "").WithPrecisionAndScale(10, 0),
+		sqlmock.NewColumn("ENABLED").OfType("BOOLEAN", ""),
+		sqlmock.NewColumn("ELAPSED").OfType("INTERVAL DAY TO SECOND", ""),
+		sqlmock.NewColumn("RATIO").OfType("DOUBLE", ""),
+		sqlmock.NewColumn("DOC").OfType("CLOB", ""),
+		sqlmock.NewColumn("HASH").OfType("RAW", ""),
+	).AddRow("7", "true", "+00 00:01:30.000000", "0.25", "{}", "\xff")
+
+	frame := sqlFrames(t, rows, formatTable, frameOptions{})[0]
+	want := []data.FieldType{data.FieldTypeNullableInt64,
+		data.FieldTypeNullableBool, data.FieldTypeNullableFloat64,
+		data.FieldTypeNullableFloat64, data.FieldTypeNullableString,
+		data.FieldTypeNullableString}
+	for i, field := range frame.Fields {
+		if field.Type() != want[i] {
+			t.Errorf("field %s type = %v, want %v", field.Name, field.Type(), want[i])
+		}
+	}
+	if *frame.Fields[2].At(0).(*float64) != 90 || frame.Fields[2].Config.Unit != "s" {
+		t.Fatalf("interval = %v %q, want 90 s", *frame.Fields[2].At(0).(*float64),
+			frame.Fields[2].Config.Unit)
+	}
+	if *frame.Fields[5].At(0).(*string) != "ff" {
+		t.Fatalf("raw = %q, want ff", *frame.Fields[5].At(0).(*string))
+	}
+
+	series := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+		sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+		sqlmock.NewColumn("DOC").OfType("CLOB", ""),
+		sqlmock.NewColumn("WAIT").OfType("INTERVAL DAY TO SECOND", ""),
+	).AddRow("1700000000", "a", "ignored", "2000000000")
+	frames := sqlFrames(t, series, formatTimeSeries, frameOptions{})
+	if len(frames) != 1 || *frames[0].Fields[1].At(0).(*float64) != 2 {
+		t.Fatalf("expected one series of the interval in seconds, got %d frames", len(frames))
+	}
+}
@@ -24,8 +24,6 @@
 //
 //-----------------------------------------------------------------------------
 
-
-
 /* Copyright (c) 2013, 2025, Oracle and/or its affiliates. */
 /* All rights reserved.*/
 
@@ -55,20 +53,21 @@ import (
 	godror "github.com/godror/godror"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Make sure OracleDatasource implements required interfaces. This is important
 // to do since otherwise we will only get a not implemented error response from
 // plugin in runtime. In this example datasource instance implements backend.
-// QueryDataHandler, backend.CheckHealthHandler, backend.StreamHandler
-// interfaces. Plugin should not implement all these interfaces - only those
+// QueryDataHandler, backend.CheckHealthHandler, backend.CallResourceHandler,
+// backend.StreamHandler interfaces. Plugin should not implement all these interfaces - only those
 // which are required for a particular task.
 // For example if plugin does not need streaming functionality then you are
 // free to remove methods that implement backend.StreamHandler. Implementing
@@ -78,10 +77,39 @@ import (
 var (
 	_ backend.QueryDataHandler      = (*OracleDatasource)(nil)
 	_ backend.CheckHealthHandler    = (*OracleDatasource)(nil)
+	_ backend.CallResourceHandler   = (*OracleDatasource)(nil)
 	_ backend.StreamHandler         = (*OracleDatasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*OracleDatasource)(nil)
 )
 
+// Default sizing of the session pool owned by each datasource instance. These
+// are used whenever the corresponding jsonData setting is missing or not
+// positive.
+const (
+	defaultPoolMinSessions = 1
+	defaultPoolMaxSessions = 10
+	defaultPoolIdleTimeout = 5 * time.Minute
+	defaultPoolMaxLifetime = time.Hour
+)
+
+// Default number of queries of a QueryDataRequest run at the same time.
+const defaultMaxParallelQueries = 4
+
+// nanValuesNull is the nanValues setting returning NaN samples as null
+const nanValuesNull = "null"
+
+// seriesKeySep separates the values of the char columns identifying a series
+// of a SQL result.
+const seriesKeySep = "\x1f"
+
+// dbConnector opens the session pool for a datasource instance. It is a
+// variable so that tests can replace the godror connection with a mock.
+var dbConnector = GetSqlDBWithGoDror
+
+// now returns the current time. It is a variable so that tests can freeze the
+// timestamps recorded for query statistics.
+var now = time.Now
+
 // OracleDatasource is an example datasource which can respond to data queries,
 // reports its health and has streaming skills.
 type OracleDatasource struct {
@@ -93,7 +121,50 @@ type OracleDatasource struct {
 	DbHostName      string
 	DbPortName      string
 	DbServiceName   string
-	secureCredData  backend.DataSourceInstanceSettings
+	//Easy Connect Plus options
+	RetryCount              int
+	ConnectTimeout          time.Duration
+	TransportConnectTimeout time.Duration
+	//directory of an auto-login wallet on the Grafana host
+	WalletLocation string
+	//session pool settings
+	PoolMinSessions int
+	PoolMaxSessions int
+	PoolIdleTimeout time.Duration
+	PoolMaxLifetime time.Duration
+	//default timeout of every query, 0 means no timeout
+	QueryTimeout time.Duration
+	//number of queries of a request run concurrently
+	MaxParallelQueries int
+	//NaN samples of PromQL results are returned as null instead of NaN
+	NaNAsNull bool
+	//NULL values of SQL results are returned as zero instead of null
+	ZeroFillNulls bool
+	//interval the metrics are collected at, the min step of the queries
+	//with an auto step
+	ScrapeInterval time.Duration
+	//time zone of the DATE and TIMESTAMP values of the data, it is the time
+	//zone of the sessions. nil keeps the time zone of the database sessions.
+	TimeZone       *time.Location
+	secureCredData backend.DataSourceInstanceSettings
+	//private copy of the wallet uploaded with the settings, removed in
+	//Dispose
+	walletDir string
+	//session pool shared by all requests served by this instance. It is
+	//opened on first use and closed in Dispose.
+	dbMu   sync.Mutex
+	dbPool *sql.DB
+	//logon in progress, the other requests wait for it, see getDBPool
+	dbOpen *dbOpen
+	//set by Dispose, a pool opened afterwards is closed
+	disposed bool
+}
+
+// dbOpen is the logon of a session pool in progress, done is closed once the
+// pool is open or err is set.
+type dbOpen struct {
+	done chan struct{}
+	err  error
 }
 
 // NewOracleDatasource creates a new datasource instance.
@@ -108,33 +179,134 @@ func NewOracleDatasource(setting backend.DataSourceInstanceSettings) (
 		DbHostName      string `json:"dbHostName"`
 		DbPortName      string `json:"dbPortName"`
 		DbServiceName   string `json:"dbServiceName"`
+		// Easy Connect Plus options, timeouts are in seconds
+		RetryCount              int `json:"retryCount"`
+		ConnectTimeout          int `json:"connectTimeout"`
+		TransportConnectTimeout int `json:"transportConnectTimeout"`
+		// Directory of the wallet for TLS connections
+		WalletLocation string `json:"walletLocation"`
+		// Session pool settings, timeouts are in seconds
+		PoolMinSessions int `json:"poolMinSessions"`
+		PoolMaxSessions int `json:"poolMaxSessions"`
+		PoolIdleTimeout int `json:"poolIdleTimeout"`
+		PoolMaxLifetime int `json:"poolMaxLifetime"`
+		// Default query timeout in seconds, 0 means no timeout
+		QueryTimeout int `json:"queryTimeout"`
+		// Number of queries of a request run concurrently
+		MaxParallelQueries int `json:"maxParallelQueries"`
+		// How NaN samples of PromQL results are returned, "nan" or "null"
+		NaNValues string `json:"nanValues"`
+		// How NULL values of SQL results are returned, "null" or "zero"
+		NullValues string `json:"nullValues"`
+		// Interval the metrics are collected at, seconds or a duration
+		ScrapeInterval string `json:"scrapeInterval"`
+		// Time zone of the data, a region name or an offset like +02:00
+		TimeZone string `json:"timeZone"`
 	}
+	// register the secure settings first so they are masked in every log
+	// line and error from here on, they are released in Dispose
+	secrets.add(secureValues(setting)...)
+
 	var jd JSONData
 	err := json.Unmarshal(setting.JSONData, &jd)
 	if err != nil {
-		log.DefaultLogger.Info("Marsheling failed for new datasource", "err", err)
+		pluginLogger.Info("Marsheling failed for new datasource", "err", err)
 	}
 	customLogger("info", "instantiated global object", "")
 	customLogger("info", "calling dumpstruct from", "neworacledatasource")
 
 	dumpStruct(jd, "info")
+
+	poolMin := defaultPoolMinSessions
+	if jd.PoolMinSessions > 0 {
+		poolMin = jd.PoolMinSessions
+	}
+	poolMax := defaultPoolMaxSessions
+	if jd.PoolMaxSessions > 0 {
+		poolMax = jd.PoolMaxSessions
+	}
+	if poolMax < poolMin {
+		poolMax = poolMin
+	}
+	poolIdle := defaultPoolIdleTimeout
+	if jd.PoolIdleTimeout > 0 {
+		poolIdle = time.Duration(jd.PoolIdleTimeout) * time.Second
+	}
+	poolLifetime := defaultPoolMaxLifetime
+	if jd.PoolMaxLifetime > 0 {
+		poolLifetime = time.Duration(jd.PoolMaxLifetime) * time.Second
+	}
+	queryTimeout := time.Duration(0)
+	if jd.QueryTimeout > 0 {
+		queryTimeout = time.Duration(jd.QueryTimeout) * time.Second
+	}
+	scrapeInterval := time.Duration(0)
+	if jd.ScrapeInterval != "" {
+		interval, errInterval := parseScrapeInterval(jd.ScrapeInterval)
+		if errInterval != nil {
+			customLogger("warning", "ignoring the scrape interval", errInterval)
+		} else {
+			scrapeInterval = interval
+		}
+	}
+	var timeZone *time.Location
+	if strings.TrimSpace(jd.TimeZone) != "" {
+		zone, errZone := parseTimeZone(jd.TimeZone)
+		if errZone != nil {
+			customLogger("warning", "ignoring the time zone", errZone)
+		} else {
+			timeZone = zone
+		}
+	}
+	maxParallel := defaultMaxParallelQueries
+	if jd.MaxParallelQueries > 0 {
+		maxParallel = jd.MaxParallelQueries
+	}
+
+	// A wallet uploaded with the settings takes precedence over the wallet
+	// location and is extracted to a directory private to this instance.
+	walletDir := ""
+	if walletZip := setting.DecryptedSecureJSONData["walletZip"]; walletZip != "" {
+		walletDir, err = materialiseWallet(walletZip)
+		if err != nil {
+			customLogger("error", "failed to extract wallet", err)
+			secrets.remove(secureValues(setting)...)
+			return nil, redactError(err)
+		}
+	}
+
 	return &OracleDatasource{
 		QueryAuth:      jd.QueryAuth,
 		DeploymentType: jd.DeploymentType,
 		//for db datasource type
-		DbUser:          jd.DbUser,
-		DbConnectString: jd.DbConnectString,
-		DbHostName:      jd.DbHostName,
-		DbPortName:      jd.DbPortName,
-		DbServiceName:   jd.DbServiceName,
-		secureCredData:  setting,
+		DbUser:                  jd.DbUser,
+		DbConnectString:         jd.DbConnectString,
+		DbHostName:              jd.DbHostName,
+		DbPortName:              jd.DbPortName,
+		DbServiceName:           jd.DbServiceName,
+		RetryCount:              jd.RetryCount,
+		ConnectTimeout:          time.Duration(jd.ConnectTimeout) * time.Second,
+		TransportConnectTimeout: time.Duration(jd.TransportConnectTimeout) * time.Second,
+		WalletLocation:          strings.TrimSpace(jd.WalletLocation),
+		PoolMinSessions:         poolMin,
+		PoolMaxSessions:         poolMax,
+		PoolIdleTimeout:         poolIdle,
+		PoolMaxLifetime:         poolLifetime,
+		QueryTimeout:            queryTimeout,
+		MaxParallelQueries:      maxParallel,
+		NaNAsNull:               strings.EqualFold(strings.TrimSpace(jd.NaNValues), nanValuesNull),
+		ZeroFillNulls:           strings.EqualFold(strings.TrimSpace(jd.NullValues), nullValuesZero),
+		ScrapeInterval:          scrapeInterval,
+		TimeZone:                timeZone,
+		secureCredData:          setting,
+		walletDir:               walletDir,
 	}, nil
 }
 
 // Function to dump the structure fields and their values
 func dumpStruct(s interface{}, dumpctx string) {
 	customLogger(dumpctx, "starting the dump", "=================")
-	v := reflect.ValueOf(s)
+	v := reflect.Indirect(reflect.ValueOf(s))
 	customLogger(dumpctx, "value curkind", v.Kind())
 	customLogger(dumpctx, "value struct", reflect.Struct)
 	customLogger(dumpctx, "value map", reflect.Map)
@@ -142,9 +314,15 @@ func dumpStruct(s interface{}, dumpctx string) {
 		typeOfS := v.Type()
 		customLogger(dumpctx, "dumping Struct", "=================")
 		for i := 0; i < v.NumField(); i++ {
-			if typeOfS.Field(i).Name != "secureCredData" {
-				customLogger(dumpctx, typeOfS.Field(i).Name, v.Field(i).Interface())
+			name := typeOfS.Field(i).Name
+			if !typeOfS.Field(i).IsExported() {
+				continue
 			}
+			if sensitiveKeyRegex.MatchString(name) {
+				customLogger(dumpctx, name, redactedText)
+				continue
+			}
+			customLogger(dumpctx, name, v.Field(i).Interface())
 		}
 		customLogger(dumpctx, "ending the dump", "=================")
 		return
@@ -154,17 +332,17 @@ func dumpStruct(s interface{}, dumpctx string) {
 			keyStr := key.Interface().(string) // Assuming the key is a string
 			if keyStr != "secureCredData" {
 				value := v.MapIndex(key)
+				if sensitiveKeyRegex.MatchString(keyStr) {
+					customLogger(dumpctx, keyStr, redactedText)
+					continue
+				}
 				// Check if the value is a map itself
 				if value.Kind() == reflect.Map {
 					customLogger(dumpctx, keyStr, "this is key in map values are====>")
 					dumpStruct(value.Interface(), dumpctx) // Recursively print the nested map
-				} else {
-					valueInterface := value.Interface()
-					fmt.Printf("Key: %v, Value: %v\n", keyStr, valueInterface)
+					continue
 				}
-
-				valueInterface := value.Interface()
-				customLogger(dumpctx, keyStr, valueInterface)
+				customLogger(dumpctx, keyStr, value.Interface())
 			}
 		}
 		customLogger(dumpctx, "ending the dump", "=================")
@@ -173,23 +351,111 @@ func dumpStruct(s interface{}, dumpctx string) {
 	customLogger(dumpctx, "error", "Expected a struct or Map")
 }
 
-func GetSqlDBWithGoDror(connectionString string) (*sql.DB, error) {
-	db, err := sql.Open("godror", connectionString)
+// GetSqlDBWithGoDror opens a godror session pool with the given parameters and
+// checks that a session can be obtained from it before ctx is done.
+func GetSqlDBWithGoDror(ctx context.Context, params godror.ConnectionParams) (
+	*sql.DB, error) {
+	db := sql.OpenDB(godror.NewConnector(params))
+	queryText := getConstants("sysdate_query_str", "")
+	rows, err := db.QueryContext(ctx, queryText)
 	if err != nil {
-		logError("error in godror sql.Open: %w", err)
-		return db, err
-	} else {
-		logInfo("successfully opened connection using", "godror")
-		queryText := getConstants("sysdate_query_str", "")
-		rows, err := db.Query(queryText)
-		if err != nil {
-			db.Close()
-			logError("error in query sql.query: %w", err)
-			return db, err
+		db.Close()
+		logError("error in query sql.query: %w", err)
+		return nil, err
+	}
+	defer rows.Close()
+	logInfo("successfully opened connection using", "godror")
+	return db, nil
+}
+
+// getWalletLocation returns the wallet directory used by the datasource, the
+// uploaded wallet if any, else the configured wallet location.
+func (jd *OracleDatasource) getWalletLocation() string {
+	if jd.walletDir != "" {
+		return jd.walletDir
+	}
+	return jd.WalletLocation
+}
+
+// getDBPool returns the session pool of the datasource instance, opening it
+// on first use. The pool is shared by QueryData, CheckHealth and RunStream
+// and is only closed when the instance is disposed. A single request logs on
+// at a time, the concurrent ones wait for its logon until their ctx is done
+// rather than each opening a pool of their own. A failed open is returned to
+// the requests waiting for it and is not cached, the next request retries
+// the logon, as do the waiting requests when the request logging on gave
+// up.
+func (jd *OracleDatasource) getDBPool(ctx context.Context) (*sql.DB, error) {
+	for {
+		jd.dbMu.Lock()
+		db, open := jd.dbPool, jd.dbOpen
+		if db == nil && open == nil {
+			open = &dbOpen{done: make(chan struct{})}
+			jd.dbOpen = open
+			jd.dbMu.Unlock()
+			db, open.err = jd.openDBPool(ctx)
+			jd.dbMu.Lock()
+			jd.dbOpen = nil
+			jd.dbMu.Unlock()
+			close(open.done)
+			return db, open.err
+		}
+		jd.dbMu.Unlock()
+		if db != nil {
+			return db, nil
+		}
+		select {
+		case <-open.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if open.err != nil && !errors.Is(open.err, context.Canceled) {
+			return nil, open.err
 		}
-		defer rows.Close()
 	}
-	return db, err
+}
+
+// openDBPool logs on and stores the session pool of the datasource
+// instance. The logon is bound to ctx and does not hold dbMu, so a slow
+// logon does not block the requests which find the pool open or give up.
+func (jd *OracleDatasource) openDBPool(ctx context.Context) (*sql.DB, error) {
+	params, err := jd.getConnectionConfig().connectionParams()
+	if err != nil {
+		customLogger("error", "invalid connection settings", err)
+		return nil, err
+	}
+	connectCtx := ctx
+	if jd.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		connectCtx, cancel = context.WithTimeout(ctx, jd.ConnectTimeout)
+		defer cancel()
+	}
+	db, err := dbConnector(connectCtx, params)
+	if err != nil {
+		return nil, err
+	}
+	jd.dbMu.Lock()
+	defer jd.dbMu.Unlock()
+	if jd.disposed {
+		db.Close()
+		return nil, errors.New("the datasource settings were changed, retry the request")
+	}
+	// keep database/sql from holding on to sessions longer than the godror
+	// pool would, so idle timeout and max lifetime apply end to end.
+	if jd.PoolMaxSessions > 0 {
+		db.SetMaxOpenConns(jd.PoolMaxSessions)
+		db.SetMaxIdleConns(jd.PoolMaxSessions)
+	}
+	if jd.PoolIdleTimeout > 0 {
+		db.SetConnMaxIdleTime(jd.PoolIdleTimeout)
+	}
+	if jd.PoolMaxLifetime > 0 {
+		db.SetConnMaxLifetime(jd.PoolMaxLifetime)
+	}
+	customLogger("info", "session pool opened, max sessions",
+		jd.PoolMaxSessions)
+	jd.dbPool = db
+	return db, nil
 }
 
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a
@@ -198,7 +464,36 @@ func GetSqlDBWithGoDror(connectionString string) (*sql.DB, error) {
 // NewOracleDatasource factory function.
 func (jd *OracleDatasource) Dispose() {
 	// Clean up datasource instance resources.
-	jd = nil
+	jd.dbMu.Lock()
+	defer jd.dbMu.Unlock()
+	jd.disposed = true
+	if jd.dbPool != nil {
+		if err := jd.dbPool.Close(); err != nil {
+			customLogger("error", "error closing session pool", err)
+		}
+		jd.dbPool = nil
+	}
+	if jd.walletDir != "" {
+		if err := os.RemoveAll(jd.walletDir); err != nil {
+			customLogger("error", "error removing wallet directory", err)
+		}
+		jd.walletDir = ""
+	}
+	secrets.remove(secureValues(jd.secureCredData)...)
+	jd.secureCredData.DecryptedSecureJSONData = nil
+}
+
+// secureValues returns the decrypted secure settings of the datasource
+// masked in messages. The uploaded wallet is a base64 zip archive, not text
+// that could end up in a message.
+func secureValues(setting backend.DataSourceInstanceSettings) []string {
+	values := make([]string, 0, len(setting.DecryptedSecureJSONData))
+	for key, value := range setting.DecryptedSecureJSONData {
+		if key != "walletZip" {
+			values = append(values, value)
+		}
+	}
+	return values
 }
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -209,55 +504,94 @@ func (jd *OracleDatasource) QueryData(
 	ctx context.Context,
 	req *backend.QueryDataRequest) (
 	*backend.QueryDataResponse, error) {
-	customLogger("debug", "My QueryData called with request values", req)
+	customLogger("debug", "My QueryData called, queries", len(req.Queries))
 
 	// create response struct
 	response := backend.NewQueryDataResponse()
 
 	customLogger("info", "calling dumpstruct from", "QueryData")
-	dumpStruct(*jd, "info")
-	Auth := jd.QueryAuth
-	DeploymentType := jd.DeploymentType
-	var dbConn *sql.DB
-	var err error
-	if Auth == "BASIC" {
-		connString := fmt.Sprintf("%s/%s@%s:%s/%s", jd.DbUser, jd.secureCredData.DecryptedSecureJSONData["dbPassword"], jd.DbHostName, jd.DbPortName, jd.DbServiceName)
-		dbConn, err = GetSqlDBWithGoDror(connString)
-	} else {
-
-		connString := jd.DbUser + "/" + jd.secureCredData.DecryptedSecureJSONData["dbPassword"] + "@" + jd.DbConnectString
-		dbConn, err = GetSqlDBWithGoDror(connString)
-	}
-
+	dumpStruct(jd, "info")
+	cfg := jd.getQueryConfig()
+	dbConn, err := jd.getDBPool(ctx)
 	if err != nil {
-		return response, err
+		return response, redactError(err)
 	} else {
 		customLogger("info", "My db connection success, now querying", "")
 	}
-	defer dbConn.Close() 
-	// loop over queries and execute them individually.
-	for _, curquery := range req.Queries {
-		response.Responses[curquery.RefID] = query(curquery, dbConn, DeploymentType)
+	// run the queries concurrently, at most MaxParallelQueries at a time.
+	// Each query gets its own DataResponse so a failing query does not
+	// affect the others.
+	parallel := jd.MaxParallelQueries
+	if parallel <= 0 {
+		parallel = defaultMaxParallelQueries
 	}
+	var wg sync.WaitGroup
+	var respMu sync.Mutex
+	slots := make(chan struct{}, parallel)
+	for i, curquery := range req.Queries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// the request was cancelled, the queries not started yet are
+			// not run
+			wg.Wait()
+			for _, skipped := range req.Queries[i:] {
+				response.Responses[skipped.RefID] = backend.DataResponse{
+					Error: fmt.Errorf("query %s not run: %w", skipped.RefID, ctx.Err()),
+				}
+			}
+			return response, nil
+		}
+		wg.Add(1)
+		go func(curquery backend.DataQuery) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			curResponse := runQuery(ctx, curquery, dbConn, cfg)
+			respMu.Lock()
+			response.Responses[curquery.RefID] = curResponse
+			respMu.Unlock()
+		}(curquery)
+	}
+	wg.Wait()
 
 	return response, nil
 }
 
+// runQuery runs query() and turns a panic while handling the query into an
+// error response, so that it cannot take down the other queries of the
+// request or the plugin process. Errors are redacted before they are
+// returned to the frontend.
+func runQuery(ctx context.Context, curquery backend.DataQuery, dbConn *sql.DB,
+	cfg queryConfig) (response backend.DataResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			customLogger("error", "query panicked, refId "+curquery.RefID, r)
+			response = backend.DataResponse{
+				Error: fmt.Errorf("query %s failed: %v", curquery.RefID, r),
+			}
+		}
+		response.Error = redactError(response.Error)
+	}()
+	return query(ctx, curquery, dbConn, cfg)
+}
+
 // custom logging infrastructure.
 func logError(logString string, logValue interface{}) {
-	log.DefaultLogger.Error(logString, "value", logValue)
+	pluginLogger.Error(logString, "value", logValue)
 }
 
 func logWarning(logString string, logValue interface{}) {
-	log.DefaultLogger.Warn(logString, "value", logValue)
+	pluginLogger.Warn(logString, "value", logValue)
 }
 
 func logInfo(logString string, logValue interface{}) {
-	log.DefaultLogger.Info(logString, "value", logValue)
+	pluginLogger.Info(logString, "value", logValue)
 }
 
 func logDebug(logString string, logValue interface{}) {
-	log.DefaultLogger.Debug(logString, "value", logValue)
+	pluginLogger.Debug(logString, "value", logValue)
 }
 
 func customLogger(logType string, logString string, logValue interface{}) {
@@ -274,11 +608,11 @@ func customLogger(logType string, logString string, logValue interface{}) {
 
 // logging query information
 func logQueryInfo(queryInfo string, timeInstance string, queryText string) {
-	log.DefaultLogger.Info(queryInfo+"::"+timeInstance+"::"+queryText,
+	pluginLogger.Info(queryInfo+"::"+timeInstance+"::"+queryText,
 		"", "")
-	log.DefaultLogger.Info(timeInstance+":Query in first way is:",
+	pluginLogger.Info(timeInstance+":Query in first way is:",
 		queryText, "!qry ends before'='")
-	log.DefaultLogger.Info(timeInstance+":Query in second way is:",
+	pluginLogger.Info(timeInstance+":Query in second way is:",
 		"query=", queryText)
 }
 
@@ -294,74 +628,49 @@ func logQueryStatsInfo(
 	rtt := timeAfterQuery.Sub(timeBeforeQuery).String()
 	processTime := time.Since(timeAfterQuery).String()
 
-	log.DefaultLogger.Info(queryInfo+"::RTT for query::"+queryText,
+	pluginLogger.Info(queryInfo+"::RTT for query::"+queryText,
 		"RTT=", rtt)
-	log.DefaultLogger.Info("Process Time:", "proctime=", processTime)
-	log.DefaultLogger.Info("Total Rows Processed:", "rows_proc=", rowsProcessed)
+	pluginLogger.Info("Process Time:", "proctime=", processTime)
+	pluginLogger.Info("Total Rows Processed:", "rows_proc=", rowsProcessed)
 
 	logQueryInfo(queryInfo, timeInstance, queryText)
 }
 
 // Following functions return true if oracle datatype of column matches
-// corresponding datatype which function is expecting.
+// corresponding datatype which function is expecting. The datatypes are
+// mapped by oracleTypeKinds.
 func isNumberColumn(dataType string) bool {
-	if dataType == "NUMBER" || dataType == "DOUBLE" ||
-		dataType == "FLOAT" || dataType == "LONG" {
-		return true
-	}
-	return false
+	return oracleTypeKind(dataType).isSeriesValueKind()
 }
 
 func isTimeColumn(dataType string, colName string) bool {
-	if dataType == "DATE" || dataType == "TIMESTAMP WITH LOCAL TIME ZONE" ||
-		dataType == "TIMESTAMP" || dataType == "TIMESTAMP WITH TIME ZONE" ||
-		colName == "METRIC_TIME" || colName == "METRIC_TIME_EPOCH" ||
-		colName == "TIME" {
-		return true
-	}
-	return false
+	return oracleTypeKind(dataType) == sqlTimeKind || timeColumnNames[colName]
 }
 
 func isCharColumn(dataType string) bool {
-	if dataType == "VARCHAR2" || dataType == "CHAR" ||
-		dataType == "NVARCHAR2" || dataType == "NCHAR" {
-		return true
-	}
-	return false
+	return oracleTypeKind(dataType).isSeriesLabelKind()
 }
 
 func getConstants(constName string, deploymentType string) string {
 	if deploymentType == "ADB" {
 		if constName == "query_range_str" {
-			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_range('%s',%d,%d,%d) from dual"
-		}
-		if constName == "query_fetchlabels_str" {
-			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label('__name__',%d,%d) from dual"
-		}
-		if constName == "adhock_key_query_str" {
-			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label(' ',0,0) from dual"
+			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_range(:expr,:start_ts,:end_ts,:step) from dual"
 		}
-		if constName == "adhock_value_query_str" {
-			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label('%s',0,0) from dual"
+		if constName == "query_label_str" {
+			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label(:label_name,:start_ts,:end_ts) from dual"
 		}
-		if constName == "variable_query_str" {
-			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_series('%s',%s,%s) from dual"
+		if constName == "query_series_str" {
+			return "select DBMS_CLOUD_TELEMETRY_QUERY.promql_series(:match,:start_ts,:end_ts) from dual"
 		}
 	} else {
 		if constName == "query_range_str" {
-			return "select DBMS_TELEMETRY_QUERY.promql_range('%s',%d,%d,%d) from dual"
+			return "select DBMS_TELEMETRY_QUERY.promql_range(:expr,:start_ts,:end_ts,:step) from dual"
 		}
-		if constName == "query_fetchlabels_str" {
-			return "select DBMS_TELEMETRY_QUERY.promql_label('__name__',%d,%d) from dual"
+		if constName == "query_label_str" {
+			return "select DBMS_TELEMETRY_QUERY.promql_label(:label_name,:start_ts,:end_ts) from dual"
 		}
-		if constName == "adhock_key_query_str" {
-			return "select DBMS_TELEMETRY_QUERY.promql_label(' ',0,0) from dual"
-		}
-		if constName == "adhock_value_query_str" {
-			return "select DBMS_TELEMETRY_QUERY.promql_label('%s',0,0) from dual"
-		}
-		if constName == "variable_query_str" {
-			return "select DBMS_TELEMETRY_QUERY.promql_series('%s',%s,%s) from dual"
+		if constName == "query_series_str" {
+			return "select DBMS_TELEMETRY_QUERY.promql_series(:match,:start_ts,:end_ts) from dual"
 		}
 	}
 	if constName == "sysdate_query_str" {
@@ -372,7 +681,7 @@ func getConstants(constName string, deploymentType string) string {
                     To plot time series data, the query must return:
                     • exactly one time column
                     • one or more numeric columns
-                    • at least one character column (used as series labels)`
+                    • optionally character columns (used as series labels)`
 	}
 	return ""
 }
@@ -429,10 +738,10 @@ func parseTime(timeStr string) (int64, int64, error) {
 	}
 }
 
-// This function converts Promql to proper format so that it can run on our
-// database as a sql query
+// This function converts Promql to the promql_range call that evaluates it on
+// our database
 func getPromQLToSQL(from time.Time, to time.Time, promql string,
-	stepStr string, deploymentType string) (string, error) {
+	step int64, deploymentType string) (telemetryQuery, error) {
 	var err error
 	var timeStr string = strconv.FormatInt(from.Unix(), 10) +
 		"_" + strconv.FormatInt(to.Unix(), 10) +
@@ -441,27 +750,13 @@ func getPromQLToSQL(from time.Time, to time.Time, promql string,
 	customLogger("debug", "promql text in getPromQLToSQL", promql)
 	customLogger("debug", "Time (From_To_Diff) is", timeStr)
 
-	/* we will manipulate steps here. That is if steps is such that total data
-	 * points between from and to is less than 720, we will let it be same but
-	 * if number data points is more than 720 between from and two, we will
-	 * change value of step such that number of data points gets reduced
-	 * to <=720.
-	 */
-	step, _ := strconv.ParseInt(stepStr, 10, 64)
-	dataPoints := (to.Unix() - from.Unix()) / step
-	newStep := int64(step)
-
-	customLogger("debug", "original data points", dataPoints)
-
-	if dataPoints > 720.0 {
-		if (to.Unix()-from.Unix())%720 == 0 {
-			newStep = (to.Unix() - from.Unix()) / 720
-			customLogger("debug", "case 1 newstep", newStep)
-		} else {
-			newStep = (to.Unix()-from.Unix())/720 + 1
-			customLogger("debug", "case 2 newstep", newStep)
-		}
+	//the step is resolved by resolveStep from the interval and max data
+	//points of the panel
+	if step <= 0 {
+		return telemetryQuery{}, fmt.Errorf("invalid step %d, the step must "+
+			"be a positive number of seconds", step)
 	}
+	customLogger("debug", "data points", (to.Unix()-from.Unix())/step)
 
 	//Adjusting from timestamps according to step so that graph appears sliding
 	fromTs := from.Unix()
@@ -470,83 +765,53 @@ func getPromQLToSQL(from time.Time, to time.Time, promql string,
 		remainder := fromTs % step
 		fromTs = fromTs - remainder
 	}
-	queryText := fmt.Sprintf(getConstants("query_range_str", deploymentType), promql, fromTs,
-		toTs, newStep)
-	logQueryInfo("Query query_range", "Before", queryText)
-	customLogger("debug", "returned value from getPromQLToSQL", queryText)
-	return queryText, err
-}
-
-// This function is called to convert the rows to dataframe for given promql
-// which has only one column in it.
-func getSingleColumnDF(rows *sql.Rows, rowsProcessed *int, timeAfterQuery *time.Time) (data.Frames, error) {
-	customLogger("info", "Rows inside getSingleColumnDF Function", rows)
-	// create a frame with only one field for tags
-	frames := data.Frames{}
-	frame := data.NewFrame("response")
-	cols, err := rows.Columns()
-	if err != nil {
-		customLogger("error", "Failed to get columns error occurred", err)
-		return frames, err
-	}
-	*timeAfterQuery = time.Now()
-
-	// here there is only one coulmn by default is we are projecting only one
-	frame.Fields = append(frame.Fields,
-		data.NewField(cols[0], nil, []string{}),
-	)
-
-	// make result interface
-	rawResult := make([]string, len(cols))
-
-	dest := make([]interface{}, len(cols))
-	for i := range rawResult {
-		// Put pointers to each string in the interface slice
-		dest[i] = &rawResult[i]
-	}
-	// for each row append the value in frame for tags
-	rowsTotal := 0
-	for rows.Next() {
-		vals := make([]interface{}, len(cols))
-		err = rows.Scan(dest...)
-		if err != nil {
-			customLogger("error", "scannning row error", err)
-			return frames, err
-		}
-
-		for i, raw := range rawResult {
-			vals[i] = string(raw)
-		}
-		frame.AppendRow(vals...)
-		rowsTotal = rowsTotal + 1
-	}
-	*rowsProcessed = rowsTotal
-	// Append the result in a single frame and return it.
-	frames = append(frames, frame)
-	return frames, err
+	rangeQuery := newPromQLRangeQuery(deploymentType, promql, fromTs, toTs,
+		step)
+	logQueryInfo("Query query_range", "Before", rangeQuery.String())
+	customLogger("debug", "returned value from getPromQLToSQL", rangeQuery.String())
+	return rangeQuery, err
 }
 
 // This function converts the rows returned from sql query ti the dataframe
 // format so that it can be returned to Grafana in required format
 func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
-	convertSqlResults bool, qryInputVal string,
-	legendTextVal string, queryTextConverted string,
+	format string, qryInputVal string,
+	legendTextVal string, queryTextConverted string, opts frameOptions,
 	rowsProcessed *int, timeAfterQuery *time.Time) (
 	data.Frames, string, error) {
 	//There can be 3 cases,
-	//1st Case: the user gives sql query (promqlflg is false) and doesnot wants
-	//          tabular results (convertSqlResults is false)
-	//2nd Case: the user gives sql query (promqlflg is false) and wants tabular
-	//          results (convertSqlResults is true)
+	//1st Case: the user gives sql query (promqlflg is false) and wants
+	//          tabular results or log lines (format is table or logs)
+	//2nd Case: the user gives sql query (promqlflg is false) and wants time
+	//          series (format is time_series)
 	//3rd Case: the user gives promql query. Here the result is already in
 	//          required format of grafana.
+	//The auto format of sql queries is resolved to time_series or table by
+	//the columns of the result, see sqlResultFormat.
 	execTime := ""
-	if !promqlflg && !convertSqlResults {
+	if !promqlflg {
+		var err error
+		format, err = sqlResultFormat(rows, format, opts.Columns)
+		if err != nil {
+			customLogger("error", "Failed to resolve the result format", err)
+			return data.Frames{}, execTime, err
+		}
+	}
+	if !promqlflg && format == formatTimeSeries && opts.Columns.isSet() {
+		//the time series are built from the columns set in the query editor
+		frames, err := sqlSeriesFrames(rows, opts.Columns, legendTextVal, opts,
+			rowsProcessed, timeAfterQuery)
+		if err != nil {
+			return data.Frames{}, execTime, err
+		}
+		return opts.Fill.fillFrames(frames), execTime, nil
+	}
+	if !promqlflg && format != formatTimeSeries {
 		//This is the case 1 that we have seen above
 		frames := data.Frames{}
 		customLogger("debug", "Inside getDataFrameFromRows Func 1 promqlflg:",
 			promqlflg)
-		customLogger("debug", "convertSqlResults value", convertSqlResults)
+		customLogger("debug", "format value", format)
 		//create a new Dataframe
 		frame := data.NewFrame(queryTextConverted)
 		//get columns for current sql rows.
@@ -568,16 +833,17 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 		if legendTextVal != "" {
 			dName = legendTextVal
 		}
-		*timeAfterQuery = time.Now()
+		*timeAfterQuery = now()
 		//Now add fields in dataframe that we created for each column we get
-		//in sql rows Notice here that if column type is time , we create
-		//field of type time.Time{}, if column type is number, we create
-		//field of type float64{} , else we create field of string type
+		//in sql rows. The type of the field is given by the type of the
+		//column, see oracleTypeKinds. The fields are nullable unless NULL
+		//values are zero filled.
+		kinds := make([]sqlValueKind, len(types))
 		for i, coltype := range types {
-			if isTimeColumn(coltype.DatabaseTypeName(), cols[i]) {
+			kinds[i] = sqlColumnKind(coltype)
+			if kinds[i] == sqlTimeKind {
 				frame.Fields = append(frame.Fields,
-					data.NewField(cols[i],
-						nil, []time.Time{}),
+					newSQLField(cols[i], nil, sqlTimeKind, opts),
 				)
 			} else {
 				if legendTextVal != "" {
@@ -585,34 +851,18 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 				} else {
 					dName = coltype.Name()
 				}
-				if isNumberColumn(coltype.DatabaseTypeName()) {
-					frame.Fields = append(frame.Fields,
-						data.NewField(cols[i],
-							nil, []float64{}).SetConfig(
-							&data.FieldConfig{DisplayNameFromDS: dName}),
-					)
-				} else {
-					frame.Fields = append(frame.Fields,
-						data.NewField(cols[i],
-							nil, []string{}).SetConfig(
-							&data.FieldConfig{DisplayNameFromDS: dName}),
-					)
-				}
+				frame.Fields = append(frame.Fields,
+					newSQLField(cols[i], nil, kinds[i], opts).SetConfig(
+						&data.FieldConfig{DisplayNameFromDS: dName,
+							Unit: kinds[i].unit()}),
+				)
 			}
 		}
 
-		customLogger("debug", "fields value", frame.Fields)
-
-		//Rawresult array will be used to fetch each row in for loop
-		rawResult := make([]sql.NullString, len(cols))
-
-		//dest array will store pointers to rawResult
-		dest := make([]interface{}, len(cols))
-		// A temporary interface{} slice
-		for i := range rawResult {
-			dest[i] = &rawResult[i]
-			// Put pointers to each string in the interface slice
-		}
+		//cells array will be used to fetch each row in for loop, each value
+		//is scanned in the native type of its column
+		//dest array will store pointers to cells
+		cells, dest := newSQLCells(kinds, types)
 		//for each row we iterate, we fetch the tuple value from rows and
 		//store in dataframe.
 		rowsTotal := 0
@@ -621,7 +871,7 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 			vals := make([]interface{}, len(cols))
 			// A temporary interface{} slice
 			//scan current row in dest array
-			//we can then access it with help of rawResult array
+			//we can then access it with help of cells array
 			err = rows.Scan(dest...)
 			if err != nil {
 				customLogger("error", "alert scan row error", err)
@@ -629,44 +879,27 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 			}
 
 			//for every element in current row we iterate
-			for i, raw := range rawResult {
-				//get the current colName and depending on column parse the
-				//value of current element as time , float64, or string and
-				//store it in vals array
-				if isTimeColumn(types[i].DatabaseTypeName(), cols[i]) {
-					//customLogger("debug", "raw time value", raw)
-					ts, err := time.Parse(time.RFC3339, raw.String)
-					if err != nil {
-						convraw := raw.String
-						if !raw.Valid {
-							convraw = "0"
-						}
-						parsedSecs, parsedNSecs, errTime := parseTime(convraw)
-						if errTime == nil && parsedSecs != -1 &&
-							parsedNSecs != -1 {
-							vals[i] = time.Unix(parsedSecs, parsedNSecs)
-						} else {
-							customLogger("error", "scan failed to parse timevalue:", errTime)
-							return frames, execTime, errTime
-						}
+			for i := range cells {
+				//convert the value of current element to the type of its
+				//field and store it in vals array
+				val, err := cells[i].value(opts)
+				if errors.Is(err, strconv.ErrRange) && kinds[i] == sqlIntKind {
+					//wider than an int64, the column is returned as
+					//numbers rather than failing the query
+					frame.Fields[i] = widenIntField(frame.Fields[i], opts)
+					kinds[i], cells[i].kind = sqlNumberKind, sqlNumberKind
+					val, err = cells[i].value(opts)
+				}
+				if err != nil {
+					if kinds[i] == sqlTimeKind {
+						customLogger("error", "scan failed to parse timevalue:", err)
 					} else {
-						vals[i] = ts
-					}
-
-				} else if isNumberColumn(types[i].DatabaseTypeName()) {
-					convraw := raw.String
-					if !raw.Valid {
-						convraw = "0"
-					}
-					vals[i], err = strconv.ParseFloat(convraw, 64)
-					if err != nil {
 						customLogger("error", "scan failed to parse value:",
 							err)
-						return frames, execTime, err
 					}
-				} else {
-					vals[i] = string(raw.String)
+					return frames, execTime, err
 				}
+				vals[i] = sqlFieldValue(val, opts)
 			}
 			//finnaly append the vals array (current row values) in
 			//the dataframe
@@ -678,12 +911,15 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 		customLogger("debug", "total rows processed", rowsTotal)
 		//append the frame in another dataframe and return the result.
 		frames = append(frames, frame)
-		return frames, execTime, err
-	} else if !promqlflg && convertSqlResults {
+		if format == formatLogs {
+			return logsFrames(frames), execTime, err
+		}
+		return setFrameType(frames, tableFrameType(frame)), execTime, err
+	} else if !promqlflg {
 		//this is case 2 that we described above
 		customLogger("debug", "Inside getDataFrameFromRows Func 2, promqlflg:",
 			promqlflg)
-		customLogger("debug", "case 2, convertSqlResults", convertSqlResults)
+		customLogger("debug", "case 2, format", format)
 		frames := data.Frames{}
 		cols, err := rows.Columns()
 
@@ -691,7 +927,7 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 			customLogger("error", "Failed to get columns case2 error", err)
 			return frames, execTime, err
 		}
-		*timeAfterQuery = time.Now()
+		*timeAfterQuery = now()
 		// find if following 4 columns exist in projection
 		var flgTimeFound bool = false
 		var flgValueFound bool = false
@@ -719,24 +955,35 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 				TagsStr   string // stable JSON encoding
 			}
 
+			//time or value is nil for NULL unless NULL values are zero filled
 			type TimeValuePair struct {
-				TimeStr string
-				Value   float64
+				Time  *time.Time
+				Value *float64
 			}
 
 			customLogger("debug",
 				"Inside getDataFrameFromRows Func case 2-1, promqlflg", promqlflg)
-			rawResult := make([]sql.NullString, len(cols))
-			dest := make([]interface{}, len(cols))
-			for i := range rawResult {
-				dest[i] = &rawResult[i]
-				// Put pointers to each string in the interface slice
+			kinds := make([]sqlValueKind, len(cols))
+			for i, colName := range cols {
+				switch colName {
+				case "METRIC_TIME_EPOCH":
+					kinds[i] = sqlTimeKind
+				case "METRIC_VALUE":
+					kinds[i] = sqlNumberKind
+				default:
+					kinds[i] = sqlStringKind
+				}
+			}
+			types, err := rows.ColumnTypes()
+			if err != nil {
+				customLogger("error", "Failed to get column types case2 error", err)
+				return frames, execTime, err
 			}
+			cells, dest := newSQLCells(kinds, types)
 			//create a map with key as combination of metric and tags
 			// and store list of time value pairs in it.
 			rowsTotal := 0
-			timeSlice := ""
-			valSlice := ""
+			var timeSlice, valSlice *sqlCell
 			metricNameSlice := ""
 			metricTagsSlice := ""
 			// Map: key -> list of time/value pairs
@@ -749,83 +996,60 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 					customLogger("error", "scan row error case 2-3", err)
 					return frames, execTime, err
 				}
-				metricNameSlice, metricTagsSlice, timeSlice, valSlice = "", "", "", ""
+				metricNameSlice, metricTagsSlice = "", ""
 
-				for i, raw := range rawResult {
+				for i := range cells {
 					colName := cols[i]
 					if colName == "METRIC_TIME_EPOCH" {
-						curStr := raw.String
-						if !raw.Valid {
-							curStr = "0"
-						}
-						timeSlice = curStr
+						timeSlice = &cells[i]
 					} else if colName == "METRIC_VALUE" {
-						curStr := raw.String
-						if !raw.Valid {
-							curStr = "0"
-						}
-						valSlice = curStr
+						valSlice = &cells[i]
 					} else if colName == "METRIC_NAME" {
-						curStr := raw.String
-						if !raw.Valid {
-							curStr = ""
-						}
-						metricNameSlice = curStr
+						//NULL is returned as empty string
+						metricNameSlice = cells[i].nullString().String
 					} else if colName == "METRIC_TAGS" {
-						curStr := raw.String
-						if !raw.Valid {
-							curStr = ""
-						}
-						metricTagsSlice = curStr
+						metricTagsSlice = cells[i].nullString().String
 					}
 				}
-				valNum, err := strconv.ParseFloat(valSlice, 64)
+				valNum, err := valSlice.float(opts)
 				if err != nil {
 					customLogger("error", "Failed to parse value", err)
 					return nil, execTime, err
 				}
+				rowsTotal++
+				ts, err := timeSlice.time(opts)
+				if err != nil {
+					customLogger("error", "Failed to parse time", err)
+					continue
+				}
 
 				key := MetricTagKey{MetricStr: metricNameSlice, TagsStr: metricTagsSlice}
 				timeseriesMap[key] = append(timeseriesMap[key], TimeValuePair{
-					TimeStr: timeSlice,
-					Value:   valNum,
+					Time:  ts,
+					Value: valNum,
 				})
-				rowsTotal++
 			}
 
+			legend := newLegend(legendTextVal)
 			for key, pairs := range timeseriesMap {
 				sort.Slice(pairs, func(i, j int) bool {
-					t1, err1 := time.Parse(time.RFC3339, pairs[i].TimeStr)
-					if err1 != nil {
-						sec, nsec, _ := parseTime(pairs[i].TimeStr)
-						t1 = time.Unix(sec, nsec)
-					}
-					t2, err2 := time.Parse(time.RFC3339, pairs[j].TimeStr)
-					if err2 != nil {
-						sec, nsec, _ := parseTime(pairs[j].TimeStr)
-						t2 = time.Unix(sec, nsec)
-					}
-					return t1.Before(t2)
+					return sqlTimeBefore(pairs[i].Time, pairs[j].Time)
 				})
 				timeseriesMap[key] = pairs
 
 				metricName := key.MetricStr
 				metricTagsJSON := key.TagsStr
 
-				var tagsMap map[string]string
-				var errjson error
-				if metricTagsJSON != "" {
-					errjson = json.Unmarshal([]byte(metricTagsJSON), &tagsMap)
-					if errjson != nil {
-						// keep tagsMap nil and continue; we won't fail because tags may be optional
-						customLogger("debug", "failed to unmarshal tags json for key", errjson)
-						tagsMap = nil
-					}
+				//the tags are the labels of the series
+				tagsMap, errjson := parseMetricTags(metricTagsJSON)
+				if errjson != nil {
+					// keep tagsMap nil and continue; we won't fail because tags may be optional
+					customLogger("debug", "failed to unmarshal tags json for key", errjson)
 				}
 				// Create new frame for this group
 				curFrame := data.NewFrame(queryTextConverted)
 				curFrame.Fields = append(curFrame.Fields,
-					data.NewField("METRIC_TIME", nil, []time.Time{}),
+					newSQLField("METRIC_TIME", nil, sqlTimeKind, opts),
 				)
 
 				dName := metricName + metricTagsJSON
@@ -833,364 +1057,51 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 				if legendTextVal == "" {
 					//customLogger("debug", "legends empty, tags value",
 					//                        tagsMap)
-					if errjson != nil {
-						//customLogger("debug", "Func 2-1, errjson",
-						// errjson)
-						curFrame.Fields = append(curFrame.Fields,
-							data.NewField(dName,
-								tagsMap, []float64{}).SetConfig(
-								&data.FieldConfig{DisplayNameFromDS: dName}),
-						)
-					} else {
-						curFrame.Fields = append(curFrame.Fields,
-							data.NewField(dName,
-								nil, []float64{}).SetConfig(
-								&data.FieldConfig{DisplayNameFromDS: dName}),
-						)
-					}
-
-				} else {
-					// manipulate the legendVal,if legendVal doesnot contain
-					//{$var1,$var2} then do nothing else if legendVal is
-					//something like lv{$node,$cpu}, we will replace $node
-					//by current value of node and $cpu by current value
-					//of cpu
-					legendVal := strings.TrimSpace(legendTextVal)
-					finalLegend := ""
-					if strings.Contains(legendVal, "{{") &&
-						strings.Contains(legendVal, "}}") {
-						startSep := "{{"
-						endSep := "}}"
-						tmp := strings.Split(legendVal, startSep)
-
-						for i, ind := range tmp {
-							if strings.Contains(ind, endSep) {
-								finalLegend = finalLegend +
-									strings.Split(tmp[i-1], endSep)[len(strings.Split(tmp[i-1], endSep))-1]
-								finalLegend = finalLegend +
-									tagsMap[strings.Split(ind, endSep)[0]]
-							}
-						}
-						if !strings.HasSuffix(legendVal, endSep) {
-							finalLegend = finalLegend +
-								strings.Split(legendVal, endSep)[len(strings.Split(legendVal, endSep))-1]
-						}
-					} else {
-						finalLegend = legendVal
-					}
-
-					if errjson != nil {
-						//customLogger("debug", "Func 2-3, errjson",
-						//errjson)
-						curFrame.Fields = append(curFrame.Fields,
-							data.NewField(finalLegend,
-								tagsMap, []float64{}).SetConfig(
-								&data.FieldConfig{DisplayNameFromDS: finalLegend}),
-						)
-					} else {
-						curFrame.Fields = append(curFrame.Fields,
-							data.NewField(finalLegend,
-								nil, []float64{}).SetConfig(
-								&data.FieldConfig{DisplayNameFromDS: finalLegend}),
-						)
-					}
-				}
-
-				for _, pair := range pairs {
-					ts, err := time.Parse(time.RFC3339, pair.TimeStr)
-					if err != nil {
-						sec, nsec, err2 := parseTime(pair.TimeStr)
-						if err2 != nil || sec == -1 {
-							customLogger("error", "Failed to parse time", err2)
-							continue
-						}
-						ts = time.Unix(sec, nsec)
-					}
-					curFrame.AppendRow(ts, pair.Value)
-				}
-
-				framesFinal = append(framesFinal, curFrame)
-			}
-			*rowsProcessed = rowsTotal
-			customLogger("debug", "rowsTotal:", rowsTotal)
-			//return the final frame consisting of all the frames we created
-			return framesFinal, execTime, err
-		} else {
-			// this is the case where user has asked results in timeseries
-			// format but has not projected the required 4 columns. In this
-			// case we try to find if we can form timeseries else return error
-			customLogger("debug",
-				"Inside getDataFrameFromRows Func case 2-2 promqlflg", promqlflg)
-			numberCols := 0
-			timeCols := 0
-			charCols := 0
-			otherCols := 0
-			types, err := rows.ColumnTypes()
-			if err != nil {
-				customLogger("error", "Failed to get column types error occurred ",
-					err)
-				return frames, execTime, err
-			}
-			for i, coltype := range types {
-				customLogger("info", "cur col type", coltype.DatabaseTypeName())
-				customLogger("info", "cur col name", cols[i])
-				if isTimeColumn(coltype.DatabaseTypeName(), cols[i]) {
-					timeCols = timeCols + 1
-				} else if isNumberColumn(coltype.DatabaseTypeName()) {
-					numberCols = numberCols + 1
-				} else if isCharColumn(coltype.DatabaseTypeName()) {
-					charCols = charCols + 1
-				} else {
-					otherCols = otherCols + 1
-				}
-			}
-			customLogger("info", "numberCols found count", numberCols)
-			customLogger("info", "timeCols found count", timeCols)
-			customLogger("info", "charCols found count", charCols)
-			customLogger("info", "otherCols found count", otherCols)
-
-			framesFinal := data.Frames{}
-			rawResult := make([]sql.NullString, len(cols))
-			dest := make([]interface{}, len(cols))
-
-			type TimeValuePair struct {
-				TimeStr string
-				Value   float64
-			}
-
-			//create a map with key as combination of all char columns
-			// and store list of time value pairs in it.
-			timeseriesMap := make(map[string][]TimeValuePair)
-
-			// In this case, we will club the character columns to form unique
-			// timeseries out of them
-			if timeCols == 1 && numberCols == 1 && charCols >= 1 {
-				customLogger("info",
-					"Inside getDataFrameFromRows Function case1 charCols", charCols)
-				*timeAfterQuery = time.Now()
-				// A temporary interface{} slice
-				for i := range rawResult {
-					dest[i] = &rawResult[i]
-					// Put pointers to each string in the interface slice
-				}
-
-				//itearate for each row (tuple) in sql results
-				for rows.Next() {
-					err = rows.Scan(dest...)
-					if err != nil {
-						customLogger("error", "scan row error part2-3", err)
-						return frames, execTime, err
-					}
-					//declare key string and val string
-					key := "" // concatenated char columns
-					var tvp TimeValuePair
-					//for each element in row create strings
-					//first string will be concatenation of all char columns in row
-					//and will act as key in map.
-					//second string will be time and value pair which will be
-					//inserted in list of pairs in map for the corresponding key.
-					for i, raw := range rawResult {
-						dbType := types[i].DatabaseTypeName()
-						colName := cols[i]
-						if isTimeColumn(dbType, colName) {
-							if raw.Valid {
-								tvp.TimeStr = raw.String
-							} else {
-								tvp.TimeStr = "0"
-							}
-							continue
-						} else if isNumberColumn(dbType) {
-							if raw.Valid {
-								v, err := strconv.ParseFloat(raw.String, 64)
-								if err != nil {
-									return nil, execTime, fmt.Errorf("invalid numeric value '%s' in column %s: %w", raw.String, colName, err)
-								}
-								tvp.Value = v
-							} else {
-								tvp.Value = 0
-							}
-							continue
-						} else if isCharColumn(dbType) {
-							if raw.Valid {
-								key += raw.String
-							}
-							continue
-						}
-					}
-					//customLogger("info", "map1key", keyColsConcatenated);
-					//customLogger("info", "map1value", valueTimeVal);
-					//insert key val pair in map tag ts
-					timeseriesMap[key] = append(timeseriesMap[key], tvp)
-
-				}
-				//customLogger("info", "done map", "done map");
-				//print the map
-				//customLogger("info", "map1value", len(mapCharColmnsTimeseries));
-				//customLogger("info", "done map2", "done map2");
-				//now we have map of key as char cols concatenated and list of
-				//time value pairs as value we just need to convert it in dataframe
-				//format as follows for each key in map, extract the key and value
-				//and insert them in dataframe
-
-			} else if timeCols == 1 && numberCols >= 1 && charCols >= 1 {
-				customLogger("info",
-					"Inside getDataFrameFromRows Function case2 numberCols", numberCols)
-				*timeAfterQuery = time.Now()
-				// A temporary interface{} slice
-				for i := range rawResult {
-					dest[i] = &rawResult[i]
-					// Put pointers to each string in the interface slice
-				}
-
-				//itearate for each row (tuple) in sql results
-				for rows.Next() {
-					err = rows.Scan(dest...)
-					if err != nil {
-						customLogger("error", "scan row error part2-3", err)
-						return frames, execTime, err
-					}
-					//declare key string and val string
-					key := ""            // concatenated char columns
-					keywithcolname := "" // concatenated char columns
-					strtime := ""
-					//for each element in row create strings
-					//first string will be concatenation of all char columns in row
-					//and will act as key in map.
-					//second string will be time and value pair which will be
-					//inserted in list of pairs in map for the corresponding key.
-					for i, raw := range rawResult {
-						dbType := types[i].DatabaseTypeName()
-						colName := cols[i]
-						if isTimeColumn(dbType, colName) {
-							if raw.Valid {
-								strtime = raw.String
-							} else {
-								strtime = "0"
-							}
-							continue
-						} else if isCharColumn(dbType) {
-							if raw.Valid {
-								key += raw.String
-							}
-							continue
-						}
-					}
-
-					for i, raw := range rawResult {
-						dbType := types[i].DatabaseTypeName()
-						colName := cols[i]
-						if isNumberColumn(dbType) && !isTimeColumn(dbType, colName) {
-							keywithcolname = key + colName
-							var tvp TimeValuePair
-							tvp.TimeStr = strtime
-							if raw.Valid {
-								v, err := strconv.ParseFloat(raw.String, 64)
-								if err != nil {
-									return nil, execTime, fmt.Errorf("invalid numeric value '%s' in column %s: %w", raw.String, colName, err)
-								}
-								tvp.Value = v
-								timeseriesMap[keywithcolname] = append(timeseriesMap[keywithcolname], tvp)
-							} else {
-								tvp.Value = 0
-								timeseriesMap[keywithcolname] = append(timeseriesMap[keywithcolname], tvp)
-
-							}
-							continue
-						}
-					}
-
-				}
-				//customLogger("info", "done map", "done map");
-				//print the map
-				//customLogger("info", "map1value", len(mapCharColmnsTimeseries));
-				//customLogger("info", "done map2", "done map2");
-				//now we have map of key as char cols concatenated and list of
-				//time value pairs as value we just need to convert it in dataframe
-				//format as follows for each key in map, extract the key and value
-				//and insert them in dataframe
-
-			} else {
-				//else we return the error
-				frames := data.Frames{}
-				return frames, execTime, errors.New(getConstants("sqlerror_query_str", ""))
-			}
-			rowsTotal := 0
-			for key, pairs := range timeseriesMap {
-				//customLogger("info", "map key", key);
-				sort.Slice(pairs, func(i, j int) bool {
-					t1, err1 := time.Parse(time.RFC3339, pairs[i].TimeStr)
-					if err1 != nil {
-						sec, nsec, _ := parseTime(pairs[i].TimeStr)
-						t1 = time.Unix(sec, nsec)
-					}
-
-					t2, err2 := time.Parse(time.RFC3339, pairs[j].TimeStr)
-					if err2 != nil {
-						sec, nsec, _ := parseTime(pairs[j].TimeStr)
-						t2 = time.Unix(sec, nsec)
-					}
-
-					return t1.Before(t2)
-				})
-				timeseriesMap[key] = pairs
-
-				curFrame := data.NewFrame("response")
-				curFrame.Fields = append(curFrame.Fields,
-					data.NewField("METRIC_TIME", nil, []time.Time{}),
-				)
-
-				dName := key
-
-				if legendTextVal == "" {
-					//if no legend present, simply create dataframe with name of
-					//key(concat of all char columns)
 					curFrame.Fields = append(curFrame.Fields,
-						data.NewField(dName,
-							nil, []float64{}).SetConfig(
+						newSQLField(dName,
+							tagsMap, sqlNumberKind, opts).SetConfig(
 							&data.FieldConfig{DisplayNameFromDS: dName}),
 					)
-					//customLogger("info", "legends empty, display name:", dName)
 				} else {
-					//replace name in dataframe as whatever is given in legend
-					//customLogger("info", "legend not empty, legend:", legendTextVal)
+					//the legend is executed with the tags of the series and
+					//its metric name, as __name__ or METRIC_NAME
+					finalLegend := legend.execute(legendLabels(tagsMap,
+						metricName, map[string]string{"METRIC_NAME": metricName}))
 					curFrame.Fields = append(curFrame.Fields,
-						data.NewField(legendTextVal,
-							nil, []float64{}).SetConfig(
-							&data.FieldConfig{DisplayNameFromDS: legendTextVal}),
+						newSQLField(finalLegend,
+							tagsMap, sqlNumberKind, opts).SetConfig(
+							&data.FieldConfig{DisplayNameFromDS: finalLegend}),
 					)
 				}
 
-				//finally create time and value fields in dataframe with value
-				//having tags as the map we created this is how the grafana
-				//expects the tags for a dataframe.
-
-				//for each value(time, value) pair in the map, insert a row in
-				//dataframe after parsing it in required format
-				for _, tv := range pairs {
-					raw := tv.TimeStr
-					ts, err := time.Parse(time.RFC3339, raw)
-					if err != nil {
-						parsedSecs, parsedNSecs, errTime := parseTime(raw)
-						if errTime == nil && parsedSecs != -1 &&
-							parsedNSecs != -1 {
-							ts = time.Unix(parsedSecs, parsedNSecs)
-						} else {
-							customLogger("error", "Failed to parse time", errTime)
-							customLogger("error", "Failed to parse time's value", raw)
-							return frames, execTime, errTime
-						}
-					}
-					rowsTotal = rowsTotal + 1
-					curFrame.AppendRow(ts, tv.Value)
+				for _, pair := range pairs {
+					curFrame.AppendRow(sqlFieldValue(pair.Time, opts),
+						sqlFieldValue(pair.Value, opts))
 				}
-				//append the frame for current metric_tag pair in resultant
-				//frame
+
 				framesFinal = append(framesFinal, curFrame)
 			}
 			*rowsProcessed = rowsTotal
-			//return the final frame consisting of all the frames we created
-			return framesFinal, execTime, err
+			customLogger("debug", "rowsTotal:", rowsTotal)
+			//return the final frame consisting of all the frames we created,
+			//one per series
+			sortSeriesFrames(framesFinal)
+			framesFinal = opts.Fill.fillFrames(framesFinal)
+			return setFrameType(framesFinal, data.FrameTypeTimeSeriesMulti),
+				execTime, err
 		}
+		// this is the case where user has asked results in timeseries
+		// format but has not projected the required 4 columns. The time
+		// column, the numeric columns and the character columns, if any,
+		// are returned as a wide time series, see sqlWideFrames.
+		customLogger("debug",
+			"Inside getDataFrameFromRows Func case 2-2 promqlflg", promqlflg)
+		frames, err = sqlWideFrames(rows, legendTextVal, opts, rowsProcessed,
+			timeAfterQuery)
+		if err != nil {
+			return data.Frames{}, execTime, err
+		}
+		return frames, execTime, nil
 	}
 
 	// this is case 3 that we saw above, here promqlflg = true and
@@ -1199,624 +1110,260 @@ func getDataFrameFromRows(rows *sql.Rows, promqlflg bool,
 		promqlflg)
 	frames := data.Frames{}
 
-	//Rawresult array will be used to fetch each row in for loop
-	var rawResult string = ""
-	var dest *string
-
-	rows.Next()
-	dest = &rawResult // Put pointers to each string in the interface slice
-
-	var vals string = ""
-	err := rows.Scan(dest)
+	//the telemetry query API returns a single row holding the JSON document
+	//of the Prometheus query API response
+	var rawResult string
+	if !rows.Next() {
+		*timeAfterQuery = now()
+		return frames, execTime, rows.Err()
+	}
+	err := rows.Scan(&rawResult)
 	if err != nil {
 		customLogger("error", "Error in scan", err)
 		return frames, execTime, err
 	}
 	customLogger("debug",
 		"Inside getDataFrameFromRows PromPart 3, scan success", err)
-	*timeAfterQuery = time.Now()
-
-	type RespStruct struct {
-		Status string `json:"status"`
-		Data   struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Metric map[string]string `json:"metric"`
-				Values [][]interface{}   `json:"values"`
-			} `json:"result"`
-		} `json:"data"`
-	}
-
-	vals = string(rawResult) // string json result dump
+	*timeAfterQuery = now()
 
-	var jsn RespStruct
-	//json.Unmarshal([]byte(tempVals4), &jsn2)
-	errjson := json.Unmarshal([]byte(vals), &jsn)
-	if errjson != nil {
-		customLogger("error", "Error in Json Unmarshal", errjson)
-		return frames, execTime, errjson
+	execTime = "0"
+	frames, rowsTotal, err := promResponseToFrames([]byte(rawResult),
+		legendTextVal, opts)
+	if err != nil {
+		return data.Frames{}, execTime, err
 	}
-	// get the json string to object in "jsn"
-
-	customLogger("info", "vals", vals)
-	customLogger("info", "valsjson", jsn)
+	//return the final dataframe containing all the frames
+	*rowsProcessed = rowsTotal
+	return frames, execTime, nil
+}
 
-	execTime = "0"
-	rowsTotal := 0
-	for _, item := range jsn.Data.Result {
-		customLogger("info", "iteration start", "")
-		//create a dataframe for current tags
-		frame := data.NewFrame("response")
-		//prepare tags to add in current timeseries dataframe
-		tags := make(map[string]string, len(item.Metric))
-		for key, element := range item.Metric {
-			tags[key] = element
-		}
-		// add time and value fields for current dataframe along with tags
-		// created
-		frame.Fields = append(frame.Fields,
-			data.NewField("METRIC_TIME", nil, []time.Time{}),
-		)
-		tagsEmpty := make(map[string]string)
-		if legendTextVal == "" {
-			customLogger("info", "legend empty1, tags value", tags)
-			customLogger("info", "legend empty2, qryInputVal", qryInputVal)
-			customLogger("info", "legend empty3, qryInputVal", tags["__name__"])
-			frame.Fields = append(frame.Fields,
-				data.NewField(tags["__name__"], tags, []float64{}),
-			)
-		} else {
-			// manipulate the legendVal,
-			//if legendVal doesnot contain {{objectname}} then do nothing
-			//else if legendVal is something like {{objectname}} ,
-			//we will replace objectname by current value of objectname
-			legendVal := strings.TrimSpace(legendTextVal)
-			finalLegend := ""
-			if strings.Contains(legendVal, "{{") &&
-				strings.Contains(legendVal, "}}") {
-				startSep := "{{"
-				endSep := "}}"
-				tmp := strings.Split(legendVal, startSep)
-
-				for i, ind := range tmp {
-					if strings.Contains(ind, endSep) {
-						finalLegend = finalLegend +
-							strings.Split(
-								tmp[i-1],
-								endSep)[len(strings.Split(tmp[i-1], endSep))-1]
-						finalLegend = finalLegend +
-							tags[strings.Split(ind, endSep)[0]]
-					}
-				}
-				if !strings.HasSuffix(legendVal, endSep) {
-					finalLegend = finalLegend +
-						strings.Split(
-							legendVal,
-							endSep)[len(strings.Split(legendVal, endSep))-1]
-				}
+// queryConfig carries the datasource level settings that apply to every query
+// run by query().
+type queryConfig struct {
+	DeploymentType string
+	//default timeout of a query, 0 means no timeout
+	QueryTimeout time.Duration
+	//NaN samples of PromQL results are returned as null
+	NaNAsNull bool
+	//NULL values of SQL results are returned as zero
+	ZeroFillNulls bool
+	//min step of the queries with an auto step
+	ScrapeInterval time.Duration
+	//time zone of the data, nil for the time zone of the sessions
+	TimeZone *time.Location
+}
 
-			} else {
-				finalLegend = legendVal
-			}
+// frameOptions controls how the rows of a query are converted to frames.
+type frameOptions struct {
+	//NaN samples of PromQL results are returned as null instead of NaN
+	NaNAsNull bool
+	//NULL values of SQL results are returned as zero, the epoch for times,
+	//instead of null
+	ZeroFillNulls bool
+	//columns the time series of SQL results are built from
+	Columns seriesColumns
+	//fill of the time series of SQL results, nil when they are not filled
+	Fill *sqlFill
+}
 
-			customLogger("debug", "legend not empty, tags", tagsEmpty)
-			customLogger("debug", "legend not empty, finalLegend", finalLegend)
+// getQueryConfig returns the datasource level settings used by query().
+func (jd *OracleDatasource) getQueryConfig() queryConfig {
+	return queryConfig{
+		DeploymentType: jd.DeploymentType,
+		QueryTimeout:   jd.QueryTimeout,
+		NaNAsNull:      jd.NaNAsNull,
+		ZeroFillNulls:  jd.ZeroFillNulls,
+		ScrapeInterval: jd.ScrapeInterval,
+		TimeZone:       jd.TimeZone,
+	}
+}
 
-			frame.Fields = append(frame.Fields,
-				data.NewField(
-					finalLegend,
-					tags,
-					[]float64{}).SetConfig(
-					&data.FieldConfig{DisplayNameFromDS: finalLegend}),
-			)
-		}
-		// iterate for time, value array and append it in current dataframe
-		// by creating a temporary interface called rowVal and parsing it
-		// properly
-		for _, colName := range item.Values {
-			rowVal := make([]interface{}, 2)
-
-			var floatTime float64 = colName[0].(float64)
-			var intTime int64 = int64(floatTime)
-			var curVal string = colName[1].(string)
-
-			rowVal[0] = time.Unix(intTime, 0)
-			curValFinal, err := strconv.ParseFloat(curVal, 64)
-			if err != nil {
-				customLogger("error", "Failed to parse value", err)
-				return frames, execTime, err
-			}
-			rowVal[1] = curValFinal
-			customLogger("info", "rowvalue0", rowVal[0])
-			customLogger("info", "rowvalue1", rowVal[1])
-			//appending current key value pair in current dataframe
-			frame.AppendRow(rowVal...)
-			rowsTotal = rowsTotal + 1
+// getTimeoutSecs parses a timeout in seconds given either as number or as
+// text. It returns false if the value is missing or not a positive number.
+func getTimeoutSecs(timeoutObj interface{}) (time.Duration, bool) {
+	var secs float64
+	switch val := timeoutObj.(type) {
+	case float64:
+		secs = val
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0, false
 		}
-		//append current dataframe in global dataframe to be returned
-		customLogger("info", "appending frame", "")
-		frames = append(frames, frame)
-		customLogger("info", "frame appended", "")
+		secs = parsed
+	default:
+		return 0, false
 	}
-	//return the final dataframe containing all the frames
-	*rowsProcessed = rowsTotal
-	customLogger("info", "dfreturn 2", execTime)
-	customLogger("info", "dfreturn 3", err)
-	customLogger("info", "dfreturn 1", "")
-	return frames, execTime, err
+	if secs <= 0 {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+// queryTimeoutError replaces err by a clear message when it was caused by the
+// query running longer than its timeout, so that the user does not only see
+// the ORA- error of the broken call.
+func queryTimeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if err != nil && timeout > 0 &&
+		errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("query timed out after %s s",
+			strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+	}
+	return err
 }
 
 // This is the query method which runs for each query present in current panel
-// It is called by QueryData function for each query
-func query(query backend.DataQuery, dbConn *sql.DB, deploymentType string) backend.DataResponse {
+// It is called by QueryData function for each query. All database calls are
+// bound to ctx so that they are broken when Grafana cancels the request or
+// the query timeout expires.
+func query(ctx context.Context, query backend.DataQuery, dbConn *sql.DB, cfg queryConfig) backend.DataResponse {
 	response := backend.DataResponse{} //Response object to be returned
-	// Unmarshal the JSON into our QueryModel and create a map of it.
+	deploymentType := cfg.DeploymentType
+	// Unmarshal the JSON into our QueryModel, invalid fields are reported
+	// in the response instead of failing the whole request.
 	var err error
-	var queryInterface interface{}
-	errjson:= json.Unmarshal(query.JSON, &queryInterface)
-	if errjson != nil {
-		customLogger("error", "Json Unmarshal error", errjson)
-		response.Error = errjson
+	qm, err := parseQueryModel(query)
+	if err != nil {
+		customLogger("error", "invalid query", err)
+		response.Error = err
 		return response
 	}
-	queryDataMap := queryInterface.(map[string]interface{})
-	//Now we have all or querydata in map queryDataMap.
-	customLogger("info", "calling dumpstruct from", "query")
-	dumpStruct(queryDataMap, "info")
-	customLogger("debug", "Query object", query)
 	customLogger("debug", "Query timerange From", query.TimeRange.From.Unix())
 	customLogger("debug", "Query timerange To", query.TimeRange.To.Unix())
 
+	//the timeout of the query overrides the one of the datasource
+	timeout := cfg.QueryTimeout
+	if qm.timeout > 0 {
+		timeout = qm.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	customLogger("debug", "Query timeout", timeout.String())
+
 	//check the language type to set promql flag
-	promql := true
-	queryText := ""
-	convertSqlResults := true
-	prefetchsize := 100
-	qryInputVal := ""
-	legendTextVal := ""
+	promql := qm.isPromQL()
+	queryText := qm.expr()
+	format := qm.Format
+	prefetchsize := qm.prefetchCount
+	qryInputVal := queryText
+	legendTextVal := qm.legendFormat()
 	queryTextConverted := ""
 	timeBeforeQuery := time.Now() //This is initialised to time before query
 	timeAfterQuery := time.Now()  //This is set to the time when execution
 	//any query finishes to calculate timers
 	rowsProcessed := 0 //Rows processed for current query
-	stepSize := "10"
-
-	if queryDataMap["queryLang"] == "sql" {
-		promql = false
-		queryText = queryDataMap["exprSql"].(string)
-		qryInputVal, _ = queryDataMap["exprSql"].(string)
-		legendTextVal, _ = queryDataMap["legendFormatSql"].(string)
-		customLogger("debug", "promql flg false value", promql)
-
-		// get prefetch count from frontend and override the default if its not null
-		customLogger("info", "Prefetch count fetched",
-			queryDataMap["prefetchCountText"])
-		customLogger("info", "Prefetch count default", prefetchsize)
-		if prefetchcountobj, ok := queryDataMap["prefetchCountText"]; ok {
-			if val, err := strconv.Atoi(prefetchcountobj.(string)); err == nil {
-				prefetchsize = val
-			}
-		}
-		customLogger("info", "Prefetch count final", prefetchsize)
-
-		//check if convert sql is true to set convertSqlResults flag
-		customLogger("debug", "queryDataMap[convsqlflg] value",
-			queryDataMap["convertSqlResults"])
-
-		if queryDataMap["convertSqlResults"] == false {
-			convertSqlResults = false
-			customLogger("debug", "make flg value true", convertSqlResults)
-		} else {
-			customLogger("debug", "keep flg value false", convertSqlResults)
-		}
-
-		if stepSizeObj, ok := queryDataMap["stepTextSql"]; ok {
-			if _, err := strconv.Atoi(stepSizeObj.(string)); err == nil {
-				stepSize = stepSizeObj.(string)
-			}
-		}
-
-	} else {
-		queryText = queryDataMap["exprProm"].(string)
-		qryInputVal, _ = queryDataMap["exprProm"].(string)
-		legendTextVal, _ = queryDataMap["legendFormatProm"].(string)
-		customLogger("debug", "promql flg false value", promql)
-		if stepSizeObj, ok := queryDataMap["stepTextProm"]; ok {
-			if _, err := strconv.Atoi(stepSizeObj.(string)); err == nil {
-				stepSize = stepSizeObj.(string)
-			}
-		}
-	}
-
-	var refString string
-	refString, _ = queryDataMap["refId"].(string)
-	customLogger("debug", "Query refString value", refString)
-
-	//there can be different types of queries like promql , sql , metric find.
-	// There are following conditions to handle them
-	//This first if condition is for support of labels
-	//when refString is "fetchLabels" we need to return the labels list
-	//so that it can be used for autocompletion
-	if refString == "fetchLabels" {
-		queryText := ""
-		if queryDataMap["timeFrom"] != nil && queryDataMap["timeTo"] != nil {
-			fromTs := 0
-			toTs := 0
-			if fromTsObj, ok := queryDataMap["timeFrom"]; ok {
-				if val, err := strconv.Atoi(fromTsObj.(string)); err == nil {
-					fromTs = val / 1000
-				}
-			}
-			if toTsObj, ok := queryDataMap["timeTo"]; ok {
-				if val, err := strconv.Atoi(toTsObj.(string)); err == nil {
-					toTs = val / 1000
-				}
-			}
-			customLogger("debug", "FromTs to fetch labels case1", fromTs)
-			customLogger("debug", "ToTs to fetch labels case1", toTs)
-			queryText = fmt.Sprintf(getConstants("query_fetchlabels_str", deploymentType), fromTs,
-				toTs)
-		} else {
-			secsToSub := int64(1 * 60 * 60) // hours*60(mins)*60(sec)
-			customLogger("debug", "secs to subtract ", secsToSub)
-			queryText = fmt.Sprintf(getConstants("query_fetchlabels_str", deploymentType),
-				time.Now().Unix()-secsToSub,
-				time.Now().Unix())
-		}
-
-		queryTextConverted = queryText
-		logQueryInfo("Query to fetch labels", "Before", queryText)
-		var rows *sql.Rows
-
-		rows, err = dbConn.Query(queryText)
-		
-		if err != nil {
-			customLogger("error", "My db rows error1", err)
-			response.Error = err
-			return response
-		} else {
-			defer rows.Close()
-			customLogger("info", "labels query executed", "")
-			frame, err := getSingleColumnDF(rows, &rowsProcessed, &timeAfterQuery)
-			if err != nil {
-				return response
-			}
-
-			//log query with timings
-			logQueryStatsInfo("Query to fetch labels", "After", queryText, rowsProcessed, timeBeforeQuery, timeAfterQuery)
-			response.Frames = frame
-			return response
-		}
-	}
+	step := resolveStep(qm, query, cfg.ScrapeInterval)
+	customLogger("debug", "Effective step", step)
+	customLogger("debug", "promql flg value", promql)
+	customLogger("info", "Prefetch count final", prefetchsize)
+	customLogger("debug", "format value", format)
 
 	var rows *sql.Rows
+	//macros of the SQL query, they set the fill of its series
+	macros := macroContext{
+		from:           query.TimeRange.From,
+		to:             query.TimeRange.To,
+		step:           step,
+		scrapeInterval: cfg.ScrapeInterval,
+		location:       cfg.TimeZone,
+	}
 
-	// there can be different types of queries like promql , sql , metric find.
-	// There are following conditions to handle them This first if condition is
-	// for support of query variable when refString is "metricFindQuery" we
-	// need to return the tags for the corresponding metric so that it can be
-	// used in the list for query variables
-	if refString == "metricFindQuery" {
-		// section to return tags for query variables
-		var metricName string
-		metricName, _ = queryDataMap["expr"].(string)
-		queryLang, _ := queryDataMap["queryLang"].(string)
-		customLogger("info", "Querylang for metricfindquery", queryLang)
-		//follwing query fetches the tags for given metric name
-		mn := metricName[:strings.Index(metricName, "&start")]
-		start := metricName[strings.Index(metricName, "start")+6 : strings.Index(metricName, "start")+16]
-		end := metricName[strings.Index(metricName, "end")+4 : strings.Index(metricName, "end")+14]
-
-		queryText = fmt.Sprintf(getConstants("variable_query_str", deploymentType), mn, start, end)
-		queryTextConverted = queryText
-		customLogger("debug", "Fetching Tags for metric", metricName)
-
-		logQueryInfo("Query to Fetch Tags", "Before", queryText)
-
-		rows, err = dbConn.Query(queryText)
-		
-		if err != nil {
-			customLogger("error", "My db rows error2", err)
-			response.Error = err
-			return response
-		}
-		defer rows.Close()
-		// function to convert result of query(single column of tags) to
-		// dataframe
-		frame, err := getSingleColumnDF(rows, &rowsProcessed, &timeAfterQuery)
-		if err != nil {
-			response.Error = err
-			return response
-		}
-
-		//log query with timings
-		logQueryStatsInfo("Query to Fetch Tags", "After", queryText, rowsProcessed, timeBeforeQuery, timeAfterQuery)
-		//append frame in response and return
-		response.Frames = frame
-		//customLogger("debug", "My query returned response", response)
-		return response
-
-	} else if refString == "getKeysForAdHocFilter" {
-		//follwing query fetches the keys for adhoc filter
-		queryText = getConstants("adhock_key_query_str", deploymentType)
-		queryTextConverted = queryText
-		logQueryInfo("Query to Fetch keys for adhocfilter", "Before", queryText)
-
-		rows, err = dbConn.Query(queryText)
-
-		if err != nil {
-			customLogger("error", "My db rows error3", err)
-			response.Error = err
-			return response
-		}
-		defer rows.Close()
-
-		frame, err := getSingleColumnDF(rows, &rowsProcessed, &timeAfterQuery)
-		if err != nil {
-			response.Error = err
-			return response
-		}
-
-		//log query with timings
-		logQueryStatsInfo("Query to Fetch keys for adhocfilter", "After",
-			queryText, rowsProcessed, timeBeforeQuery, timeAfterQuery)
-
-		//append frame in response and return
-		response.Frames = frame
-		customLogger("debug", "My query returned response", response)
-		return response
-
-	} else if refString == "getValueforKeyAdHocFilter" {
-		//follwing query fetches the values corresponding key for adhoc filter
-		queryText = fmt.Sprintf(getConstants("adhock_value_query_str", deploymentType),
-			queryDataMap["rawQueryText"].(string))
-		queryTextConverted = queryText
-		logQueryInfo("Query to Fetch value for key of adhocfilter:", "Before",
-			queryText)
-
-		rows, err = dbConn.Query(queryText)
-
-		if err != nil {
-			customLogger("debug", "My db rows error4", err)
-			response.Error = err
-			return response
-		}
-		defer rows.Close()
-
-		// function to convert result of query(single column of tags) to
-		// dataframe
-		frame, err := getSingleColumnDF(rows, &rowsProcessed, &timeAfterQuery)
-		if err != nil {
-			response.Error = err
-			return response
-		}
-
-		//log query with timings
-		logQueryStatsInfo("Query to Fetch value for key of adhocfilter:",
-			"After", queryText, rowsProcessed, timeBeforeQuery, timeAfterQuery)
-
-		//append frame in response and return
-		response.Frames = frame
-		customLogger("debug", "My query returned response", response)
-		return response
-
-	} else if promql {
+	if promql {
 		//This if condition is when language type specified is promql
 		//first we need to convert the promql in required sql format
 		customLogger("debug", "Language type is Promql, promql flg", promql)
-		customLogger("debug", "queryDataMap value", queryDataMap)
 
 		promqlToSql, err := getPromQLToSQL(query.TimeRange.From,
 			query.TimeRange.To,
-			queryText, stepSize,
+			queryText, step,
 			deploymentType)
 		if err != nil {
 			response.Error = err
 			return response
 		}
 
-		logQueryInfo("Converted Query to fire:", "Before", promqlToSql)
+		logQueryInfo("Converted Query to fire:", "Before", promqlToSql.String())
 
-		rows, err = dbConn.Query(promqlToSql)
+		rows, err = promqlToSql.run(ctx, dbConn)
 		if err != nil {
 			customLogger("error", "My db rows error5", err)
-			response.Error = err
+			response.Error = queryTimeoutError(ctx, timeout, err)
 			return response
 		}
 		defer rows.Close()
-		queryText = promqlToSql
+		queryText = promqlToSql.String()
 	} else {
 		//This if condition is when language type specified is SQL. Here we
 		//donot need to convert the query here as sql can be directly executed
 		customLogger("debug", "Language type is Sql, promql flag", promql)
 		customLogger("debug", "My qry in SQL", queryText)
 
-		step, _ := strconv.ParseInt(stepSize, 10, 64)
-		// change queries to support the macros of grafana's oracle plugin
-		// 1. If query contains $__timefilter(), replace it with greater
-		//    and less than selected time range's timestamp
-		if strings.Contains(queryText, "$__timeFilter") {
-			indx1 := strings.Index(queryText, "$__timeFilter")
-			indx2 := strings.Index(queryText[indx1:], ")")
-			val := queryText[indx1+14 : indx1+indx2]
-			queryPart1 := queryText[:indx1]
-			queryPart2 := queryText[indx1+indx2+1:]
-			fStr := queryPart1 + " " + val +
-				">= to_date('19700101', 'YYYYMMDD') +" +
-				" ( 1 / 24 / 60 / 60 ) * :start_time and " + val +
-				"<= to_date('19700101', 'YYYYMMDD') +" +
-				" ( 1 / 24 / 60 / 60 ) * :end_time " + queryPart2
-			queryText = fStr
-			// The above manipulation will replace '$__timefiler(ts)' in query
-			// with 'ts >= to_date(selected_start_date in grafana) and
-			// ts <= to_date(selected_end_time in grafana)'.
-			customLogger("debug",
-				"Query contains $__timeFilter, changed query in sql", fStr)
-		}
-
-		// 2. If query contains $__unixEpochFilter() macro, replace it with
-		//    greater and less than selected time range's epoch seconds. The
-		//    difference in $__timefilter() and $__unixEpochFilter() is the
-		//    prior one uses date to bound query, while later uses unix epochs
-		if strings.Contains(queryText, "$__unixEpochFilter") {
-			indx1 := strings.Index(queryText, "$__unixEpochFilter")
-			indx2 := strings.Index(queryText[indx1:], ")")
-			val := queryText[indx1+19 : indx1+indx2]
-			queryPart1 := queryText[:indx1]
-			queryPart2 := queryText[indx1+indx2+1:]
-			fStr := queryPart1 + " " + val + ">= :start_time*1000 and " +
-				val + "<= :end_time*1000 " + queryPart2
-			queryText = fStr
-			customLogger("debug",
-				"Query contains $__unixEpochFilter, changed query in sql", fStr)
-		}
-
-		// 3. If query contains $__timeGroup() , change the text such that it
-		//    supports group by. Also, timeGroup may be present in query
-		//    multiple times(in select and in groupby clause) so using a for
-		//    loop to execute this till $__timeGroup is present in the query.
-		for strings.Contains(queryText, "$__timeGroup") {
-			// The timegroup macro will be represented as
-			// $__timeGroup(dateColumn,5m) in query, thus first thing is to
-			// extract the parameters of the macro(columnname and duration).
-			indx1 := strings.Index(queryText, "$__timeGroup")
-			indx2 := strings.Index(queryText[indx1:], ")")
-			val := queryText[indx1+13 : indx1+indx2]
-			queryPart1 := queryText[:indx1]
-			queryPart2 := queryText[indx1+indx2+1:]
-			// here queryPart1 is initial part of query, value is the parameters
-			// of macro and queryPart2 is remaining part of query.
-
-			// Now we need columnname and duration for grouping time. For that
-			// we need to split the val string to obtain first and second
-			// parameter of $__timeGroup.
-			splits := strings.Split(val, ",")
-			split1 := strings.TrimSpace(splits[0])
-			split2 := strings.TrimSpace(splits[1])
-
-			// If $__interval is used as duration then we use the stepSize
-			// as its value.Else convert whatever time is given in mins, secs ,
-			// hours etc to seconds.
-			if split2 == "$__interval" {
-				split2 = strconv.FormatInt(step, 10)
-			} else if strings.HasSuffix(split2, "s") {
-				split2 = split2[:len(split2)-1]
-			} else if strings.HasSuffix(split2, "m") {
-				minval, _ := strconv.ParseInt(split2[:len(split2)-1], 10, 64)
-				secsval := minval * 60
-				split2 = strconv.FormatInt(secsval, 10)
-			} else if strings.HasSuffix(split2, "h") {
-				hrval, _ := strconv.ParseInt(split2[:len(split2)-1], 10, 64)
-				minval := hrval * 60
-				secsval := minval * 60
-				split2 = strconv.FormatInt(secsval, 10)
-			}
-
-			// Finally creating the clause to be used in groupby.
-			// Here we need to replace '$__timeGroup(timecolumn, interval)' by
-			// TO_DATE('19700101', 'YYYYMMDD') + ( 1 / 24 / 60 / 60 / 1000) *
-			// FLOOR((timecolumn - TO_TIMESTAMP('1970-01-01 00:00:00',
-			// 'yyyy-mm-dd hh24:mi:ss') +
-			// TO_DATE ('1970-01-01 00:00:00', 'YYYY-mm-dd HH24:MI:SS')
-			// - TO_DATE ('1970-01-01 00:00:00', 'YYYY-mm-dd HH24:MI:SS')
-			// )*24*60*60*1000/interval/1000)*interval*1000
-			// The above logic of groupby is taken from Grafana's oracle plugin
-			// to make our plugin behave same as theirs for this macro while
-			// migrating any grafana's oracle plugin to our plugin
-			groupStr := "TO_DATE('19700101', 'YYYYMMDD') +" +
-				" ( 1 / 24 / 60 / 60 / 1000) * FLOOR((" +
-				split1 +
-				" - TO_TIMESTAMP('1970-01-01 00:00:00'," +
-				"'yyyy-mm-dd hh24:mi:ss') + " +
-				"TO_DATE ('1970-01-01 00:00:00', " +
-				"'YYYY-mm-dd HH24:MI:SS') " +
-				"- TO_DATE ('1970-01-01 00:00:00'," +
-				" 'YYYY-mm-dd HH24:MI:SS'))*24*60*60*1000/" +
-				split2 +
-				"/1000)*" +
-				split2 +
-				"*1000"
-			fStr := queryPart1 + " " + groupStr + queryPart2
-			queryText = fStr
-			customLogger("debug",
-				"Query contains $__timeGroup, changed query in sql", fStr)
-		}
-
-		// 3. If query contains '$__time(t1)' replace it with 't1 as time'
-		if strings.Contains(queryText, "$__time") {
-			indx1 := strings.Index(queryText, "$__time")
-			indx2 := strings.Index(queryText[indx1:], ")")
-			val := queryText[indx1+8 : indx1+indx2]
-			queryPart1 := queryText[:indx1]
-			queryPart2 := queryText[indx1+indx2+1:]
-			fStr := queryPart1 + " " + val + " as time " + queryPart2
-			customLogger("debug",
-				"Query contains $__time, changed query in sql", fStr)
-			queryText = fStr
+		// references to the dashboard variables are bound, see
+		// sqlVariableBinds, then the macros of grafana's oracle plugin are
+		// expanded, see sqlMacros
+		queryText, err = sqlVariableBinds(queryText, qm.Variables)
+		if err != nil {
+			customLogger("error", "Failed to bind the dashboard variables", err)
+			response.Error = err
+			return response
 		}
-
-		//change query to add timestamp
-		if strings.Contains(queryText, ":start_time") &&
-			strings.Contains(queryText, ":end_time") {
-			//both start and end time found
-			stVal := fmt.Sprintf("%d", query.TimeRange.From.Unix())
-			etVal := fmt.Sprintf("%d", query.TimeRange.To.Unix())
-			queryText2 := strings.Replace(queryText, ":start_time", stVal, -1)
-			queryText = queryText2
-			queryText3 := strings.Replace(queryText, ":end_time", etVal, -1)
-			queryText = queryText3
-			customLogger("debug", "Changed qry in SQL case1, sql_1", queryText)
-		} else if strings.Contains(queryText, ":start_time") {
-			//only start time found
-			stVal := fmt.Sprintf("%d", query.TimeRange.From.Unix())
-			queryText2 := strings.Replace(queryText, ":start_time", stVal, -1)
-			queryText = queryText2
-			customLogger("debug", "Changed qry in SQL case2, sql_2", queryText)
-		} else if strings.Contains(queryText, ":end_time") {
-			//only end time found
-			etVal := fmt.Sprintf("%d", query.TimeRange.To.Unix())
-			queryText3 := strings.Replace(queryText, ":end_time", etVal, -1)
-			queryText = queryText3
-			customLogger("debug", "Changed qry in SQL case3, sql_3", queryText)
+		queryText, err = expandSQLMacros(queryText, &macros)
+		if err != nil {
+			customLogger("error", "Failed to expand the macros", err)
+			response.Error = err
+			return response
 		}
+		customLogger("debug", "Query with macros expanded", queryText)
 
 		queryTextConverted = queryText
 		logQueryInfo("Final sql query before translation is :", "Before", queryText)
 
 		logQueryInfo("Final sql query after translation is :", "Before", queryText)
 		//execute the query and store results in rows
-		rows, err = dbConn.Query(queryText, godror.FetchRowCount(prefetchsize))
+		//the time range, the step and the dashboard variables are passed as
+		//bind variables, so the text of the statement stays the same and
+		//is shared in the library cache
+		args := sqlBindArgs(queryText, sqlQueryBinds(qm,
+			query.TimeRange.From, query.TimeRange.To, step))
+		rows, err = dbConn.QueryContext(ctx, queryText,
+			append(args, godror.FetchRowCount(prefetchsize))...)
 
 		if err != nil {
 			customLogger("error", "My db rows error6", err)
-			response.Error = err
+			response.Error = queryTimeoutError(ctx, timeout, err)
 			return response
 		}
 		defer rows.Close()
 	}
-	customLogger("debug", "My db rows success", rows)
-	defer rows.Close()
 
 	frames, execTime, err := getDataFrameFromRows(
 		rows,
 		promql,
-		convertSqlResults,
+		format,
 		qryInputVal,
 		legendTextVal,
 		queryTextConverted,
+		frameOptions{NaNAsNull: cfg.NaNAsNull, ZeroFillNulls: cfg.ZeroFillNulls,
+			Columns: qm.columns, Fill: macros.fill},
 		&rowsProcessed,
 		&timeAfterQuery)
+	if err == nil {
+		//a broken fetch ends rows.Next() early, make sure it is not
+		//returned as a truncated result
+		err = rows.Err()
+	}
 	if err != nil {
 		customLogger("error", "Errong getting output", err.Error())
-		response.Error = err
+		response.Error = queryTimeoutError(ctx, timeout, err)
 		return response
 	}
-	customLogger("info", "no error", "")
 
 	var rttCommon time.Duration
 	if execTime == "" {
 		customLogger("debug", "Exectime not found, exectime=", execTime)
-		customLogger("info", "no error2", "")
 	} else {
 		customLogger("debug", "Exectime found, exectime=", execTime)
 		timedur := execTime + "s"
@@ -1824,19 +1371,18 @@ func query(query backend.DataQuery, dbConn *sql.DB, deploymentType string) backe
 		rttCommon = timeAfterQuery.Sub(timeBeforeQuery)
 		networkTime := (rttCommon - execTimeDurn).String()
 		customLogger("debug", "Network time, networkTime=", networkTime)
-		customLogger("info", "no error3", "")
 	}
 
 	//log query with timings
-	customLogger("info", "no error5", "")
 	logQueryStatsInfo("Query Final Executed:", "After", queryText, rowsProcessed, timeBeforeQuery, timeAfterQuery)
 
 	if response.Error != nil {
 		return response
 	}
-	customLogger("info", "no error6", "")
+	nameFrames(frames, query.RefID, queryText)
+	setFrameStep(frames, step, promql)
 	response.Frames = frames
-	customLogger("info", "no error 7", "")
+	customLogger("debug", "frames returned for refId "+query.RefID, len(frames))
 	return response
 }
 
@@ -1845,62 +1391,39 @@ func query(query backend.DataQuery, dbConn *sql.DB, deploymentType string) backe
 // datasource configuration page which allows users to verify that
 // a datasource is working as expected.
 func (jd *OracleDatasource) CheckHealth(
-	_ context.Context,
+	ctx context.Context,
 	req *backend.CheckHealthRequest) (
 	*backend.CheckHealthResult, error) {
-	customLogger("debug", "CheckHealth called with request", req)
+	customLogger("debug", "CheckHealth called", "")
 	customLogger("info", "taken data from global object", "")
 
 	customLogger("info", "calling dumpstruct from", "checkhealth")
-	dumpStruct(*jd, "info")
-	Auth := jd.QueryAuth
-	if Auth == "BASIC" {
-		connString := fmt.Sprintf("%s/%s@%s:%s/%s", jd.DbUser, jd.secureCredData.DecryptedSecureJSONData["dbPassword"], jd.DbHostName, jd.DbPortName, jd.DbServiceName)
-		var status = backend.HealthStatusOk
-		var message = "Data source is working"
-
-		//try to open a connection with db
-		db, err := GetSqlDBWithGoDror(connString)
-		if err != nil {
-			//if error opening connection, change the message and status to
-			//error
-			customLogger("error", "My db connect error", err)
-			status = backend.HealthStatusError
-			message = "Error Connecting to Database!!! ERROR: " + err.Error()
-		} else {
-			customLogger("info", "My db connection success", "")
-		}
-		defer db.Close()
-
-		//return the message of healthcheck
-		return &backend.CheckHealthResult{
-			Status:  status,
-			Message: message,
-		}, nil
-
+	dumpStruct(jd, "info")
+	var status = backend.HealthStatusOk
+	var message = "Data source is working"
+
+	//get the session pool of this instance (opening it if needed) and make
+	//sure a session can still be obtained from it
+	db, err := jd.getDBPool(ctx)
+	if err == nil {
+		err = db.PingContext(ctx)
+	}
+	if err != nil {
+		//if error opening connection, change the message and status to
+		//error
+		customLogger("error", "My db connect error", err)
+		status = backend.HealthStatusError
+		message = "Error Connecting to Database!!! ERROR: " +
+			redactError(err).Error()
 	} else {
-		connString := jd.DbUser + "/" + jd.secureCredData.DecryptedSecureJSONData["dbPassword"] + "@" + jd.DbConnectString
-		var status = backend.HealthStatusOk
-		var message = "Data source is working"
-
-		//try to open a connection with db
-		db, err := GetSqlDBWithGoDror(connString)
-		if err != nil {
-			//if error opening connection, change the message and status to
-			//error
-			customLogger("error", "My db connect error", err)
-			status = backend.HealthStatusError
-			message = "Error Connecting to Database!!! ERROR: " + err.Error()
-		} else {
-			customLogger("info", "My db connection success", "")
-		}
-		defer db.Close()
-		//return the message of healthcheck
-		return &backend.CheckHealthResult{
-			Status:  status,
-			Message: message,
-		}, nil
+		customLogger("info", "My db connection success", "")
 	}
+
+	//return the message of healthcheck
+	return &backend.CheckHealthResult{
+		Status:  status,
+		Message: message,
+	}, nil
 }
 
 // SubscribeStream is called when a client wants to connect to a stream. This
@@ -1910,7 +1433,7 @@ func (d *OracleDatasource) SubscribeStream(
 	req *backend.SubscribeStreamRequest) (
 	*backend.SubscribeStreamResponse,
 	error) {
-	customLogger("info", "SubscribeStream called with request", req)
+	customLogger("info", "SubscribeStream called for path", req.Path)
 
 	status := backend.SubscribeStreamStatusPermissionDenied
 	if req.Path == "stream" {
@@ -1928,7 +1451,14 @@ func (d *OracleDatasource) RunStream(
 	ctx context.Context,
 	req *backend.RunStreamRequest,
 	sender *backend.StreamSender) error {
-	customLogger("debug", "RunStream called with request", req)
+	customLogger("debug", "RunStream called for path", req.Path)
+
+	// Streams share the session pool of the datasource instance.
+	db, err := d.getDBPool(ctx)
+	if err != nil {
+		customLogger("error", "RunStream failed to get session pool", err)
+		return err
+	}
 
 	// Create the same data frame as for query data.
 	frame := data.NewFrame("response")
@@ -1949,6 +1479,11 @@ func (d *OracleDatasource) RunStream(
 				"Context done, finish streaming with path", req.Path)
 			return nil
 		case <-time.After(time.Second):
+			// Skip this tick if no session can be obtained from the pool.
+			if err := db.PingContext(ctx); err != nil {
+				customLogger("error", "RunStream session pool ping failed", err)
+				continue
+			}
 			// Send new data periodically.
 			frame.Fields[0].Set(0, time.Now())
 			frame.Fields[1].Set(0, int64(10*(counter%2+1)))
@@ -1957,7 +1492,7 @@ func (d *OracleDatasource) RunStream(
 
 			err := sender.SendFrame(frame, data.IncludeAll)
 			if err != nil {
-				log.DefaultLogger.Error("Error sending frame", "error", err)
+				pluginLogger.Error("Error sending frame", "error", err)
 				continue
 			}
 		}
@@ -1970,7 +1505,7 @@ func (d *OracleDatasource) PublishStream(
 	req *backend.PublishStreamRequest) (
 	*backend.PublishStreamResponse,
 	error) {
-	customLogger("debug", "PublishStream called with request", req)
+	customLogger("debug", "PublishStream called for path", req.Path)
 
 	// Do not allow publishing at all.
 	return &backend.PublishStreamResponse{
@@ -31,14 +31,18 @@ package plugin
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
         "fmt"
         "regexp"
         "errors"
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	godror "github.com/godror/godror"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
@@ -147,6 +151,249 @@ func TestNewOracleDatasource(t *testing.T) {
 	}
 }
 
+func TestNewOracleDatasource_PoolSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		jsonData     string
+		wantMin      int
+		wantMax      int
+		wantIdle     time.Duration
+		wantLifetime time.Duration
+	}{
+		{
+			name:         "defaults",
+			jsonData:     `{"queryAuth":"BASIC"}`,
+			wantMin:      defaultPoolMinSessions,
+			wantMax:      defaultPoolMaxSessions,
+			wantIdle:     defaultPoolIdleTimeout,
+			wantLifetime: defaultPoolMaxLifetime,
+		},
+		{
+			name:         "configured",
+			jsonData:     `{"poolMinSessions":2,"poolMaxSessions":20,"poolIdleTimeout":60,"poolMaxLifetime":600}`,
+			wantMin:      2,
+			wantMax:      20,
+			wantIdle:     time.Minute,
+			wantLifetime: 10 * time.Minute,
+		},
+		{
+			name:         "max raised to min",
+			jsonData:     `{"poolMinSessions":15,"poolMaxSessions":5}`,
+			wantMin:      15,
+			wantMax:      15,
+			wantIdle:     defaultPoolIdleTimeout,
+			wantLifetime: defaultPoolMaxLifetime,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{
+				JSONData: json.RawMessage(tc.jsonData),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ds := instance.(*OracleDatasource)
+			if ds.PoolMinSessions != tc.wantMin || ds.PoolMaxSessions != tc.wantMax {
+				t.Errorf("pool sessions = %d/%d, want %d/%d",
+					ds.PoolMinSessions, ds.PoolMaxSessions, tc.wantMin, tc.wantMax)
+			}
+			if ds.PoolIdleTimeout != tc.wantIdle {
+				t.Errorf("PoolIdleTimeout = %v, want %v", ds.PoolIdleTimeout, tc.wantIdle)
+			}
+			if ds.PoolMaxLifetime != tc.wantLifetime {
+				t.Errorf("PoolMaxLifetime = %v, want %v", ds.PoolMaxLifetime, tc.wantLifetime)
+			}
+		})
+	}
+}
+
+func TestQueryData_ReusesSessionPool(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		mock.ExpectQuery(`promql_range`).
+			WillReturnRows(sqlmock.NewRows([]string{"metric_name"}).AddRow("cpu"))
+	}
+	mock.ExpectClose()
+
+	opened := 0
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
+		opened++
+		return db, nil
+	}
+	defer func() { dbConnector = orig }()
+
+	ds := makeTestDS()
+	for i := 0; i < 2; i++ {
+		if _, err := ds.QueryData(context.Background(), makeTestRequest()); err != nil {
+			t.Fatalf("QueryData call %d: %v", i, err)
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("session pool opened %d times, want 1", opened)
+	}
+
+	ds.Dispose()
+	if ds.dbPool != nil {
+		t.Fatalf("expected session pool to be released on Dispose")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
+
+func TestGetDBPool_ConnectContext(t *testing.T) {
+	var deadline time.Time
+	orig := dbConnector
+	dbConnector = func(ctx context.Context, _ godror.ConnectionParams) (*sql.DB, error) {
+		deadline, _ = ctx.Deadline()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("ORA-12170: TNS:Connect timeout occurred")
+	}
+	defer func() { dbConnector = orig }()
+
+	ds := makeTestDS()
+	ds.ConnectTimeout = 30 * time.Second
+	ds.getDBPool(context.Background())
+	if deadline.IsZero() || time.Until(deadline) > ds.ConnectTimeout {
+		t.Fatalf("logon deadline %v, want within the connect timeout", deadline)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ds.getDBPool(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("getDBPool error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGetDBPool_SingleLogon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	mock.ExpectClose()
+
+	var opened int32
+	started, release := make(chan struct{}), make(chan struct{})
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
+		if atomic.AddInt32(&opened, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return db, nil
+	}
+	defer func() { dbConnector = orig }()
+
+	ds := makeTestDS()
+	const requests = 30
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			got, err := ds.getDBPool(context.Background())
+			if err == nil && got != db {
+				err = errors.New("got another session pool")
+			}
+			errs <- err
+		}()
+	}
+	<-started
+
+	//a request given up does not wait for the logon in progress
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ds.getDBPool(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("getDBPool error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	for i := 0; i < requests; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("getDBPool: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&opened); n != 1 {
+		t.Fatalf("session pool opened %d times, want 1", n)
+	}
+	ds.Dispose()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
+
+func TestGetDBPool_SharedLogonError(t *testing.T) {
+	var opened int32
+	started, release := make(chan struct{}), make(chan struct{})
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
+		if atomic.AddInt32(&opened, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return nil, errors.New("ORA-01017: invalid username/password")
+	}
+	defer func() { dbConnector = orig }()
+
+	ds := makeTestDS()
+	first := make(chan error, 1)
+	go func() {
+		_, err := ds.getDBPool(context.Background())
+		first <- err
+	}()
+	<-started
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := ds.getDBPool(context.Background())
+		waiter <- err
+	}()
+	close(release)
+	for _, ch := range []chan error{first, waiter} {
+		if err := <-ch; err == nil || !strings.Contains(err.Error(), "ORA-01017") {
+			t.Fatalf("getDBPool error = %v, want ORA-01017", err)
+		}
+	}
+	//not cached, the next request logs on again
+	ds.getDBPool(context.Background())
+	if n := atomic.LoadInt32(&opened); n < 2 {
+		t.Fatalf("session pool opened %d times, want a new logon", n)
+	}
+}
+
+func TestGetDBPool_AfterDispose(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	mock.ExpectClose()
+
+	ds := makeTestDS()
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
+		//the settings change while the logon is in progress
+		ds.Dispose()
+		return db, nil
+	}
+	defer func() { dbConnector = orig }()
+
+	if _, err := ds.getDBPool(context.Background()); err == nil {
+		t.Fatalf("expected an error for a disposed datasource")
+	}
+	if ds.dbPool != nil {
+		t.Fatalf("expected no session pool after Dispose")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
+
 // helper to avoid strconv in assertions
 func itoa(v int64) string {
 	return fmt.Sprintf("%d", v)
@@ -158,42 +405,27 @@ func TestGetPromQLToSQL(t *testing.T) {
 	deploymentType := "default"
 
 	tests := []struct {
-		name          string
-		from          time.Time
-		to            time.Time
-		stepStr       string
-		expectedStep  int64
-		expectedFrom  int64
+		name         string
+		from         time.Time
+		to           time.Time
+		step         int64
+		expectedStep int64
+		expectedFrom int64
+		wantErr      bool
 	}{
 		{
-			name:         "datapoints less than 720 - step unchanged",
-			from:         baseFrom,
-			to:           baseFrom.Add(100 * time.Second),
-			stepStr:      "1",
-			expectedStep: 1,
-			expectedFrom: 0,
-		},
-		{
-			name:         "datapoints exactly divisible by 720",
-			from:         baseFrom,
-			to:           baseFrom.Add(7200 * time.Second), // diff = 7200
-			stepStr:      "1",
-			expectedStep: 10, // 7200 / 720
-			expectedFrom: 0,
-		},
-		{
-			name:         "datapoints greater than 720 not divisible",
+			name:         "step used as resolved",
 			from:         baseFrom,
 			to:           baseFrom.Add(7201 * time.Second),
-			stepStr:      "1",
-			expectedStep: 11, // 7201/720 + 1
+			step:         1,
+			expectedStep: 1,
 			expectedFrom: 0,
 		},
 		{
 			name:         "from timestamp aligned to step",
 			from:         time.Unix(100, 0),
 			to:           time.Unix(820, 0),
-			stepStr:      "10",
+			step:         10,
 			expectedStep: 10,
 			expectedFrom: 100,
 		},
@@ -201,35 +433,48 @@ func TestGetPromQLToSQL(t *testing.T) {
 			name:         "from timestamp not aligned to step",
 			from:         time.Unix(105, 0),
 			to:           time.Unix(825, 0),
-			stepStr:      "10",
+			step:         10,
 			expectedStep: 10,
 			expectedFrom: 100, // adjusted down
 		},
+		{
+			name:    "zero step",
+			from:    baseFrom,
+			to:      baseFrom.Add(100 * time.Second),
+			step:    0,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sql, err := getPromQLToSQL(
+			rangeQuery, err := getPromQLToSQL(
 				tt.from,
 				tt.to,
 				promql,
-				tt.stepStr,
+				tt.step,
 				deploymentType,
 			)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
 			// Validate step
-			if !strings.Contains(sql, ","+itoa(tt.expectedStep)+")") {
+			if !strings.Contains(rangeQuery.String(), ":step=\""+itoa(tt.expectedStep)+"\"") {
 				t.Fatalf("expected step %d in query, got %s",
-					tt.expectedStep, sql)
+					tt.expectedStep, rangeQuery)
 			}
 
 			// Validate from timestamp
-			if !strings.Contains(sql, itoa(tt.expectedFrom)) {
+			if !strings.Contains(rangeQuery.String(), ":start_ts=\""+itoa(tt.expectedFrom)+"\"") {
 				t.Fatalf("expected from timestamp %d in query, got %s",
-					tt.expectedFrom, sql)
+					tt.expectedFrom, rangeQuery)
 			}
 		})
 	}
@@ -247,31 +492,19 @@ func TestGetConstants(t *testing.T) {
 			name:           "ADB query_range_str",
 			constName:      "query_range_str",
 			deploymentType: "ADB",
-			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_range('%s',%d,%d,%d) from dual",
+			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_range(:expr,:start_ts,:end_ts,:step) from dual",
 		},
 		{
-			name:           "ADB query_fetchlabels_str",
-			constName:      "query_fetchlabels_str",
+			name:           "ADB query_label_str",
+			constName:      "query_label_str",
 			deploymentType: "ADB",
-			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label('__name__',%d,%d) from dual",
+			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label(:label_name,:start_ts,:end_ts) from dual",
 		},
 		{
-			name:           "ADB adhock_key_query_str",
-			constName:      "adhock_key_query_str",
+			name:           "ADB query_series_str",
+			constName:      "query_series_str",
 			deploymentType: "ADB",
-			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label(' ',0,0) from dual",
-		},
-		{
-			name:           "ADB adhock_value_query_str",
-			constName:      "adhock_value_query_str",
-			deploymentType: "ADB",
-			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_label('%s',0,0) from dual",
-		},
-		{
-			name:           "ADB variable_query_str",
-			constName:      "variable_query_str",
-			deploymentType: "ADB",
-			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_series('%s',%s,%s) from dual",
+			expected:       "select DBMS_CLOUD_TELEMETRY_QUERY.promql_series(:match,:start_ts,:end_ts) from dual",
 		},
 
 		// ---------------- Non-ADB cases ----------------
@@ -279,31 +512,19 @@ func TestGetConstants(t *testing.T) {
 			name:           "Non-ADB query_range_str",
 			constName:      "query_range_str",
 			deploymentType: "ONPREM",
-			expected:       "select DBMS_TELEMETRY_QUERY.promql_range('%s',%d,%d,%d) from dual",
-		},
-		{
-			name:           "Non-ADB query_fetchlabels_str",
-			constName:      "query_fetchlabels_str",
-			deploymentType: "ONPREM",
-			expected:       "select DBMS_TELEMETRY_QUERY.promql_label('__name__',%d,%d) from dual",
-		},
-		{
-			name:           "Non-ADB adhock_key_query_str",
-			constName:      "adhock_key_query_str",
-			deploymentType: "ONPREM",
-			expected:       "select DBMS_TELEMETRY_QUERY.promql_label(' ',0,0) from dual",
+			expected:       "select DBMS_TELEMETRY_QUERY.promql_range(:expr,:start_ts,:end_ts,:step) from dual",
 		},
 		{
-			name:           "Non-ADB adhock_value_query_str",
-			constName:      "adhock_value_query_str",
+			name:           "Non-ADB query_label_str",
+			constName:      "query_label_str",
 			deploymentType: "ONPREM",
-			expected:       "select DBMS_TELEMETRY_QUERY.promql_label('%s',0,0) from dual",
+			expected:       "select DBMS_TELEMETRY_QUERY.promql_label(:label_name,:start_ts,:end_ts) from dual",
 		},
 		{
-			name:           "Non-ADB variable_query_str",
-			constName:      "variable_query_str",
+			name:           "Non-ADB query_series_str",
+			constName:      "query_series_str",
 			deploymentType: "ONPREM",
-			expected:       "select DBMS_TELEMETRY_QUERY.promql_series('%s',%s,%s) from dual",
+			expected:       "select DBMS_TELEMETRY_QUERY.promql_series(:match,:start_ts,:end_ts) from dual",
 		},
 
 		// ---------------- Common cases ----------------
@@ -519,96 +740,6 @@ func TestIsCharColumn(t *testing.T) {
 	}
 }
 
-func TestGetSingleColumnDF_Scan(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("sqlmock.New: %v", err)
-	}
-	t.Cleanup(func() {
-		_ = db.Close()
-	})
-
-	// Mock rows with ONE column
-	mockRows := sqlmock.NewRows([]string{"metric_name"}).
-		AddRow("cpu").
-		AddRow("memory").
-		AddRow("disk")
-
-	// Expect query (query text doesn't matter much here)
-	mock.ExpectQuery("SELECT .*").
-		WillReturnRows(mockRows)
-
-	// Execute query to get *sql.Rows
-	rows, err := db.Query("SELECT metric_name FROM telemetry_metrics_data")
-	if err != nil {
-		t.Fatalf("db.Query: %v", err)
-	}
-	defer rows.Close()
-
-	var processed int
-	var after time.Time
-
-	frames, err := getSingleColumnDF(rows, &processed, &after)
-	if err != nil {
-		t.Fatalf("getSingleColumnDF: %v", err)
-	}
-
-	if processed != 3 {
-		t.Fatalf("rowsProcessed = %d, want 3", processed)
-	}
-
-	if after.IsZero() {
-		t.Fatalf("expected timeAfterQuery to be set")
-	}
-
-	if len(frames) != 1 {
-		t.Fatalf("frames length = %d, want 1", len(frames))
-	}
-
-	field := frames[0].Fields[0]
-
-	if field.Name != "metric_name" {
-		t.Fatalf("field name = %q, want metric_name", field.Name)
-	}
-
-	b, err := json.Marshal(frames[0])
-	if err != nil {
-		t.Fatalf("json.Marshal(frame): %v", err)
-	}
-
-	s := string(b)
-
-	for _, want := range []string{"cpu", "memory", "disk"} {
-		if !strings.Contains(s, want) {
-			t.Fatalf("frame JSON missing value %q\nJSON: %s", want, s)
-		}
-	}
-}
-
-func TestGetSingleColumnDF_ScanError(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("sqlmock init failed: %v", err)
-	}
-	defer db.Close()
-
-	rows := sqlmock.NewRows([]string{"TAG"}).
-		AddRow(nil) // causes scan error for string
-
-	mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
-
-	sqlRows, _ := db.Query("SELECT TAG FROM dual")
-	defer sqlRows.Close()
-
-	var rowsProcessed int
-	var timeAfterQuery time.Time
-
-	_, err = getSingleColumnDF(sqlRows, &rowsProcessed, &timeAfterQuery)
-	if err == nil {
-		t.Fatal("expected scan error, got nil")
-	}
-}
-
 func TestGetDataFrameFromRows_SQLTabular(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -634,7 +765,7 @@ func TestGetDataFrameFromRows_SQLTabular(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, false, "SELECT", "", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTable, "SELECT", "", "query", frameOptions{}, &processed, &after)
 	if err != nil {
 		t.Fatalf("getDataFrameFromRows: %v", err)
 	}
@@ -679,9 +810,9 @@ func TestGetDataFrameFromRows_SQLTabular(t *testing.T) {
 
         // Validate each row
         for i := 0; i < rowCount; i++ {
-             tags := frame.Fields[0].At(i)
-             ts, _ := frame.Fields[2].At(i).(time.Time)
-             val := frame.Fields[1].At(i)
+             tags := *frame.Fields[0].At(i).(*string)
+             ts := *frame.Fields[2].At(i).(*time.Time)
+             val := *frame.Fields[1].At(i).(*string)
              epoch := ts.UTC().Unix()
 
              // fmt.Println("val ",val)
@@ -735,7 +866,7 @@ func TestGetDataFrameFromRows_SQLTimeseriesWithMetrics(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, true, "SELECT", "node", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries, "SELECT", "node", "query", frameOptions{}, &processed, &after)
 	if err != nil {
 		t.Fatalf("getDataFrameFromRows: %v", err)
 	}
@@ -768,8 +899,8 @@ func TestGetDataFrameFromRows_SQLTimeseriesWithMetrics(t *testing.T) {
 
         // Validate each row
         for i := 0; i < rowCount; i++ {
-              ts := frame.Fields[0].At(i).(time.Time)
-              val := frame.Fields[1].At(i).(float64)
+              ts := *frame.Fields[0].At(i).(*time.Time)
+              val := *frame.Fields[1].At(i).(*float64)
 
              if ts.Format(time.RFC3339) != expectedTimes[i] {
                  t.Fatalf("row %d time = %s, want %s",
@@ -812,7 +943,7 @@ func TestGetDataFrameFromRows_SQLNumericParseError(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, true, "SELECT", "", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries, "SELECT", "", "query", frameOptions{}, &processed, &after)
 	if err == nil {
 		t.Fatalf("expected numeric parse error")
 	}
@@ -842,7 +973,7 @@ func TestGetDataFrameFromRows_SQLNumericParseError(t *testing.T) {
 	}
 }
 
-func TestGetDataFrameFromRows_SQLTimeseriesMissingLabelColumns(t *testing.T) {
+func TestGetDataFrameFromRows_SQLTimeseriesWithoutLabelColumns(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("sqlmock.New: %v", err)
@@ -868,25 +999,25 @@ func TestGetDataFrameFromRows_SQLTimeseriesMissingLabelColumns(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, true, "SELECT", "", "query", &processed, &after)
-	if err == nil {
-		t.Fatalf("expected error due to missing character columns")
-	}
-
-	if !strings.Contains(err.Error(), "Invalid SQL query") {
-		t.Fatalf("unexpected error: %v", err)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries, "SELECT", "", "query", frameOptions{}, &processed, &after)
+	if err != nil {
+		t.Fatalf("getDataFrameFromRows: %v", err)
 	}
 
 	if execTime != "" {
 		t.Fatalf("execTime = %q, want empty string", execTime)
 	}
 
-	if processed != 0 {
-		t.Fatalf("rowsProcessed = %d, want 0", processed)
+	if processed != 1 {
+		t.Fatalf("rowsProcessed = %d, want 1", processed)
 	}
 
-	if len(frames) != 0 {
-		t.Fatalf("frames length = %d, want 0", len(frames))
+	if len(frames) != 1 || len(frames[0].Fields) != 2 {
+		t.Fatalf("frames = %v, want one wide frame with time and value", frames)
+	}
+
+	if value, _ := frames[0].Fields[1].ConcreteAt(0); value != 1.0 {
+		t.Fatalf("value = %v, want 1", value)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -920,7 +1051,7 @@ func TestGetDataFrameFromRows_SQLCase1InvalidTime(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, false, "SELECT", "", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTable, "SELECT", "", "query", frameOptions{}, &processed, &after)
 	if err == nil {
 		t.Fatalf("expected error due to invalid time value")
 	}
@@ -978,7 +1109,7 @@ func TestGetDataFrameFromRows_SQLTimeseriesDerivedLabels(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, false, true, "SELECT", "", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries, "SELECT", "", "query", frameOptions{}, &processed, &after)
 	if err != nil {
 		t.Fatalf("getDataFrameFromRows: %v", err)
 	}
@@ -1008,7 +1139,7 @@ func TestGetDataFrameFromRows_SQLTimeseriesDerivedLabels(t *testing.T) {
         // Validate each row
         for i := 0; i < rowCount; i++ {
               ts := frame.Fields[0].At(i).(time.Time)
-              val := frame.Fields[1].At(i).(float64)
+              val := *frame.Fields[1].At(i).(*float64)
 
              if ts.Format(time.RFC3339) != expectedTimes[i] {
                  t.Fatalf("row %d time = %s, want %s",
@@ -1054,7 +1185,7 @@ func TestGetDataFrameFromRows_PromQL(t *testing.T) {
 	var processed int
 	var after time.Time
 
-	frames, execTime, err := getDataFrameFromRows(sqlRows, true, false, "up", "{{instance}}", "query", &processed, &after)
+	frames, execTime, err := getDataFrameFromRows(sqlRows, true, formatTimeSeries, "up", "{{instance}}", "query", frameOptions{}, &processed, &after)
 	if err != nil {
 		t.Fatalf("getDataFrameFromRows: %v", err)
 	}
@@ -1126,10 +1257,10 @@ func TestQueryData_BASICAuth(t *testing.T) {
 		AddRow("cpu").
 		AddRow("memory")
 
-  mock.ExpectQuery(regexp.QuoteMeta("select DBMS_CLOUD_TELEMETRY_QUERY.promql_label ")).WillReturnRows(mockRows)
+  mock.ExpectQuery(regexp.QuoteMeta("select DBMS_CLOUD_TELEMETRY_QUERY.promql_range")).WillReturnRows(mockRows)
 	// ---- override dbConnector ----
 	orig := dbConnector
-	dbConnector = func(_ string) (*sql.DB, error) {
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 		return db, nil
 	}
 	defer func() { dbConnector = orig }()
@@ -1167,7 +1298,7 @@ func TestQueryData_NonBASICAuth(t *testing.T) {
 	)
 
 	orig := dbConnector
-	dbConnector = func(_ string) (*sql.DB, error) { return db, nil }
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
 	defer func() { dbConnector = orig }()
 
 	ds := makeTestDS()
@@ -1191,7 +1322,7 @@ func TestQueryData_DBFailure(t *testing.T) {
 	origConnector := dbConnector
 	defer func() { dbConnector = origConnector }()
 
-	dbConnector = func(string) (*sql.DB, error) {
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 		return nil, errors.New("db down")
 	}
 
@@ -1220,187 +1351,238 @@ func TestQueryData_DBFailure(t *testing.T) {
 	}
 }
 
-func TestQuery_FetchLabels_Time(t *testing.T) {
-	db, mock, err := sqlmock.New()
+var testTimeRange = backend.TimeRange{
+	From: time.Unix(1700000000, 0),
+	To:   time.Unix(1700003600, 0),
+}
+
+// promRangeRows returns the promql_range result of a single series whose
+// instance label is instance.
+func promRangeRows(instance string) *sqlmock.Rows {
+	payload := `{"status":"success","data":{"resultType":"matrix","result":[` +
+		`{"metric":{"__name__":"up","instance":"` + instance + `"},` +
+		`"values":[[1700000000,"1"],[1700000060,"2"]]}]}}`
+	return sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("PROM_RESULT").OfType("CLOB", ""),
+	).AddRow(payload)
+}
+
+// concurrencyConnector opens connections to a sqlmock database that record
+// the maximum number of queries running at once. A query waits until want
+// queries have run at once, so that the maximum does not depend on timing,
+// then stays running a little to overlap any query started beyond want.
+type concurrencyConnector struct {
+	driver driver.Driver
+	dsn    string
+	want   int
+	// onQuery, if set, is called when a query starts
+	onQuery func()
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (c *concurrencyConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
 	if err != nil {
-		t.Fatalf("sqlmock.New: %v", err)
+		return nil, err
 	}
-	defer db.Close()
+	return &concurrencyConn{Conn: conn, connector: c}, nil
+}
 
-	mockRows := sqlmock.NewRows([]string{"metric_name"}).
-		AddRow("cpu").
-		AddRow("memory")
+func (c *concurrencyConnector) Driver() driver.Driver { return c.driver }
 
-  expectedSQL := `select DBMS_CLOUD_TELEMETRY_QUERY.promql_label('__name__',1700000000,1700003600) from dual`
+func (c *concurrencyConnector) maxConcurrent() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxRunning
+}
 
-  mock.ExpectQuery(regexp.QuoteMeta(expectedSQL)).
-	         WillReturnRows(mockRows)
+type concurrencyConn struct {
+	driver.Conn
+	connector *concurrencyConnector
+}
 
-	queryJSON := json.RawMessage(`{"refId":"fetchLabels","queryLang":"promql","exprProm":"up","timeFrom":"1700000000000","timeTo":"1700003600000"}`)
-       fmt.Println("queryjson ", queryJSON)
-	dataQuery := backend.DataQuery{
-		RefID: "A",
-		JSON:  queryJSON,
-		TimeRange: backend.TimeRange{
-			From: time.Unix(1700000000, 0),
-			To:   time.Unix(1700003600, 0),
-		},
-	}
+func (c *concurrencyConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return c.Conn.(driver.NamedValueChecker).CheckNamedValue(nv)
+}
 
-	resp := query(dataQuery, db, "ADB")
-	if resp.Error != nil {
-		t.Fatalf("unexpected response error: %v", resp.Error)
+func (c *concurrencyConn) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+	cc := c.connector
+	cc.mu.Lock()
+	cc.running++
+	if cc.running > cc.maxRunning {
+		cc.maxRunning = cc.running
+	}
+	cc.mu.Unlock()
+	defer func() {
+		cc.mu.Lock()
+		cc.running--
+		cc.mu.Unlock()
+	}()
+	if cc.onQuery != nil {
+		cc.onQuery()
 	}
-	if len(resp.Frames) != 1 {
-		t.Fatalf("expected 1 frame, got %d", len(resp.Frames))
+	for deadline := time.Now().Add(5 * time.Second); cc.maxConcurrent() < cc.want &&
+		time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
 	}
+	// stay running a little so that a query started beyond the limit overlaps
+	time.Sleep(10 * time.Millisecond)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
 
-	frameJSON, err := json.Marshal(resp.Frames[0])
+// newConcurrencyDB returns a database whose queries are answered by sqlmock
+// and counted by the returned connector.
+func newConcurrencyDB(t *testing.T, want int) (*sql.DB, sqlmock.Sqlmock, *concurrencyConnector) {
+	t.Helper()
+	dsn := "concurrency_" + t.Name()
+	mockDB, mock, err := sqlmock.NewWithDSN(dsn)
 	if err != nil {
-		t.Fatalf("json.Marshal(frame): %v", err)
-	}
-	frameStr := string(frameJSON)
-	for _, want := range []string{"cpu", "memory"} {
-		if !strings.Contains(frameStr, want) {
-			t.Fatalf("frame JSON missing value %q\nJSON: %s", want, frameStr)
-		}
-	}
+		t.Fatalf("sqlmock.NewWithDSN: %v", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+	mock.MatchExpectationsInOrder(false)
+	connector := &concurrencyConnector{driver: mockDB.Driver(), dsn: dsn, want: want}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return db, mock, connector
+}
 
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Fatalf("sqlmock expectations: %v", err)
+func TestQueryData_Parallel(t *testing.T) {
+	tests := []struct {
+		name     string
+		parallel int
+	}{
+		{name: "serial", parallel: 1},
+		{name: "bounded", parallel: 2},
+		{name: "all at once", parallel: 4},
 	}
-}
 
-func TestQuery_FetchLabels_NoTime(t *testing.T) {
-    db, mock, _ := sqlmock.New()
-    defer db.Close()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, connector := newConcurrencyDB(t, tc.parallel)
+
+			req := &backend.QueryDataRequest{}
+			for _, refID := range []string{"A", "B", "C", "D"} {
+				mock.ExpectQuery(`promql_range`).WillReturnRows(promRangeRows(refID))
+				req.Queries = append(req.Queries, backend.DataQuery{
+					RefID:     refID,
+					JSON:      json.RawMessage(`{"queryLang":"promql","exprProm":"up"}`),
+					TimeRange: testTimeRange,
+				})
+			}
 
-    rows := sqlmock.NewRows([]string{"label"}).
-        AddRow("host")
+			orig := dbConnector
+			dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
+			defer func() { dbConnector = orig }()
 
-     mock.ExpectQuery(`select\s+DBMS_TELEMETRY_QUERY\.promql_label`,).
-             WillReturnRows(rows)
+			ds := makeTestDS()
+			ds.MaxParallelQueries = tc.parallel
 
-    qMap := map[string]interface{}{
-        "refId":     "fetchLabels",
-        "queryLang": "promql",        // REQUIRED
-        "exprProm":  "up",             // REQUIRED
-    }
+			resp, err := ds.QueryData(context.Background(), req)
+			if err != nil {
+				t.Fatalf("QueryData: %v", err)
+			}
+			if got := connector.maxConcurrent(); got != tc.parallel {
+				t.Fatalf("%d queries ran at once, want %d", got, tc.parallel)
+			}
+			for _, refID := range []string{"A", "B", "C", "D"} {
+				r, ok := resp.Responses[refID]
+				if !ok || r.Error != nil || len(r.Frames) != 1 {
+					t.Fatalf("response %s = %+v, want one frame", refID, r)
+				}
+			}
+		})
+	}
+}
 
-    jsonBytes, _ := json.Marshal(qMap)
+func TestQueryData_CancelledWhileWaiting(t *testing.T) {
+	db, mock, connector := newConcurrencyDB(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the request is cancelled while its first query runs, the other
+	// queries are waiting for the only slot
+	connector.onQuery = cancel
+
+	req := &backend.QueryDataRequest{}
+	for _, refID := range []string{"A", "B", "C"} {
+		mock.ExpectQuery(`promql_range`).WillReturnRows(promRangeRows(refID))
+		req.Queries = append(req.Queries, backend.DataQuery{
+			RefID:     refID,
+			JSON:      json.RawMessage(`{"queryLang":"promql","exprProm":"up"}`),
+			TimeRange: testTimeRange,
+		})
+	}
 
-    q := backend.DataQuery{
-        JSON: jsonBytes,
-    }
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
+	defer func() { dbConnector = orig }()
 
-    response := query(q, db, "test")
+	ds := makeTestDS()
+	ds.MaxParallelQueries = 1
+	// open the pool before the request is cancelled
+	if _, err := ds.getDBPool(context.Background()); err != nil {
+		t.Fatalf("getDBPool: %v", err)
+	}
 
-    if response.Error != nil {
-        t.Fatalf("unexpected error")
-    }
+	resp, err := ds.QueryData(ctx, req)
+	if err != nil {
+		t.Fatalf("QueryData: %v", err)
+	}
+	if len(resp.Responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(resp.Responses))
+	}
+	if _, ok := resp.Responses["A"]; !ok {
+		t.Fatalf("missing response of the running query")
+	}
+	for _, refID := range []string{"B", "C"} {
+		r := resp.Responses[refID]
+		want := "query " + refID + " not run: context canceled"
+		if r.Error == nil || r.Error.Error() != want || !errors.Is(r.Error, context.Canceled) {
+			t.Fatalf("response %s error = %v, want %q", refID, r.Error, want)
+		}
+	}
 }
 
-func TestQuery_MetricFindQuery(t *testing.T) {
+func TestQueryData_FailedQueryIsolated(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("sqlmock.New: %v", err)
 	}
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"metric_name"}).
-		AddRow("region")
-
-	mock.ExpectQuery(`(?i)select\s+DBMS_TELEMETRY_QUERY\.`).
-		WillReturnRows(rows)
-
-	qMap := map[string]interface{}{
-		"refId":      "metricFindQuery",
-		"exprProm":   "cpu_usage",
-                "expr":       "cpu_usage&start=1768542262&end=1768542462",
-		"queryLang":  "promql",
-	}
+	mock.ExpectQuery(`promql_range`).
+		WillReturnRows(promRangeRows("cpu"))
 
-	jsonBytes, _ := json.Marshal(qMap)
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
+	defer func() { dbConnector = orig }()
 
-	q := backend.DataQuery{
-		RefID: "metricFindQuery",
-		JSON:  jsonBytes,
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", JSON: json.RawMessage(`{"queryLang":"promql","exprProm":"up"}`), TimeRange: testTimeRange},
+			{RefID: "B", JSON: json.RawMessage(`"not an object"`)},
+			{RefID: "C", JSON: json.RawMessage(`{not json`)},
+		},
 	}
 
-	resp := query(q, db, "test")
-
-	if resp.Error != nil {
-		t.Fatalf("unexpected error: %v", resp.Error)
+	resp, err := makeTestDS().QueryData(context.Background(), req)
+	if err != nil {
+		t.Fatalf("QueryData: %v", err)
 	}
-
-	if len(resp.Frames) == 0 {
-		t.Fatalf("expected frames in response")
+	if r := resp.Responses["A"]; r.Error != nil || len(r.Frames) != 1 {
+		t.Fatalf("response A = %+v, want one frame", r)
 	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Fatalf("sql expectations not met: %v", err)
+	for _, refID := range []string{"B", "C"} {
+		if resp.Responses[refID].Error == nil {
+			t.Fatalf("response %s: expected error", refID)
+		}
 	}
 }
 
-func TestQuery_getKeys(t *testing.T) {
-    db, mock, _ := sqlmock.New()
-    defer db.Close()
-
-    rows := sqlmock.NewRows([]string{"label"}).
-        AddRow("host")
-
-     mock.ExpectQuery(`select\s+DBMS_TELEMETRY_QUERY\.promql_label`,).
-             WillReturnRows(rows)
-
-    qMap := map[string]interface{}{
-	    "refId":"getKeysForAdHocFilter",
-	    "exprProm":   "host",
-      "expr":       "host",
-	    "queryLang":  "promql",
-    }
-    jsonBytes, _ := json.Marshal(qMap)
-
-    q := backend.DataQuery{
-        JSON: jsonBytes,
-    }
-
-    resp := query(q, db, "test")
-
-    if resp.Error != nil {
-        t.Fatalf("unexpected error")
-    }
-}
-
-func TestQuery_getValue(t *testing.T) {
-    db, mock, _ := sqlmock.New()
-    defer db.Close()
-
-    rows := sqlmock.NewRows([]string{"label"}).
-        AddRow("host")
-
-     mock.ExpectQuery(`select\s+DBMS_TELEMETRY_QUERY\.promql_label`,).
-             WillReturnRows(rows)
-
-    qMap := map[string]interface{} {
-			"refId":      "getValueforKeyAdHocFilter",
-			"exprProm":   "host",
-			"expr":       "host",
-			"queryLang":  "promql",
-			"rawQueryText": "cpu",
-    }
-    jsonBytes, _ := json.Marshal(qMap)
-
-    q := backend.DataQuery{
-        JSON: jsonBytes,
-    }
-
-    resp := query(q, db, "test")
-
-    if resp.Error != nil {
-        t.Fatalf("unexpected error")
-    }
-}
-
 func TestQuery_PromQL(t *testing.T) {
     db, mock, err := sqlmock.New()
     if err != nil {
@@ -1433,26 +1615,144 @@ func TestQuery_PromQL(t *testing.T) {
       },
     }
 
-    resp := query(q, db, "test")
+    resp := query(context.Background(), q, db, queryConfig{DeploymentType: "test"})
 
     if resp.Error != nil {
         t.Fatalf("unexpected error")
     }
 }
 
+func TestQuery_Timeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       queryConfig
+		qTimeout  interface{}
+		wantError string
+	}{
+		{
+			name:      "datasource timeout",
+			cfg:       queryConfig{DeploymentType: "test", QueryTimeout: 50 * time.Millisecond},
+			wantError: "query timed out after 0.05 s",
+		},
+		{
+			name:      "query timeout overrides datasource",
+			cfg:       queryConfig{DeploymentType: "test", QueryTimeout: time.Hour},
+			qTimeout:  "0.05",
+			wantError: "query timed out after 0.05 s",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(`select\s+DBMS_TELEMETRY_QUERY\.promql`).
+				WillDelayFor(time.Second).
+				WillReturnRows(sqlmock.NewRows([]string{"PROM_RESULT"}).AddRow("{}"))
+
+			qMap := map[string]interface{}{
+				"refId":     "A",
+				"exprProm":  "up",
+				"queryLang": "promql",
+			}
+			if tc.qTimeout != nil {
+				qMap["queryTimeout"] = tc.qTimeout
+			}
+			jsonBytes, _ := json.Marshal(qMap)
+
+			q := backend.DataQuery{
+				JSON: jsonBytes,
+				TimeRange: backend.TimeRange{
+					From: time.Unix(1700000000, 0),
+					To:   time.Unix(1700003600, 0),
+				},
+			}
+
+			resp := query(context.Background(), q, db, tc.cfg)
+			if resp.Error == nil || resp.Error.Error() != tc.wantError {
+				t.Fatalf("error = %v, want %q", resp.Error, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestQuery_ContextCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`select\s+DBMS_TELEMETRY_QUERY\.promql`).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"PROM_RESULT"}).AddRow("{}"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	q := backend.DataQuery{
+		JSON: json.RawMessage(`{"refId":"A","queryLang":"promql","exprProm":"up"}`),
+		TimeRange: backend.TimeRange{
+			From: time.Unix(1700000000, 0),
+			To:   time.Unix(1700003600, 0),
+		},
+	}
+
+	start := time.Now()
+	resp := query(ctx, q, db, queryConfig{DeploymentType: "test"})
+	if resp.Error == nil {
+		t.Fatalf("expected error for cancelled request")
+	}
+	if strings.Contains(resp.Error.Error(), "timed out") {
+		t.Fatalf("cancelled request reported as timeout: %v", resp.Error)
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatalf("query was not broken on cancel")
+	}
+}
+
+func TestGetTimeoutSecs(t *testing.T) {
+	tests := []struct {
+		in     interface{}
+		want   time.Duration
+		wantOk bool
+	}{
+		{in: nil, wantOk: false},
+		{in: "", wantOk: false},
+		{in: "abc", wantOk: false},
+		{in: "0", wantOk: false},
+		{in: float64(-5), wantOk: false},
+		{in: "30", want: 30 * time.Second, wantOk: true},
+		{in: " 1.5 ", want: 1500 * time.Millisecond, wantOk: true},
+		{in: float64(10), want: 10 * time.Second, wantOk: true},
+	}
+
+	for _, tc := range tests {
+		got, ok := getTimeoutSecs(tc.in)
+		if ok != tc.wantOk || got != tc.want {
+			t.Errorf("getTimeoutSecs(%#v) = %v, %v, want %v, %v",
+				tc.in, got, ok, tc.want, tc.wantOk)
+		}
+	}
+}
+
 func TestCheckHealth_BasicAuth(t *testing.T) {
 	orig := dbConnector
 	defer func() { dbConnector = orig }()
 
 	tests := []struct {
 		name        string
-		connector   func(string) (*sql.DB, error)
+		connector   func(context.Context, godror.ConnectionParams) (*sql.DB, error)
 		expectMsg   string
 		expectState backend.HealthStatus
 	}{
 		{
 			name: "basic-success",
-			connector: func(string) (*sql.DB, error) {
+			connector: func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 				db, _, err := sqlmock.New()
 				return db, err
 			},
@@ -1461,7 +1761,7 @@ func TestCheckHealth_BasicAuth(t *testing.T) {
 		},
 		{
 			name: "basic-failure",
-			connector: func(string) (*sql.DB, error) {
+			connector: func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 				return nil, errors.New("boom")
 			},
 			expectMsg:   "Error Connecting to Database!!! ERROR: boom",
@@ -1508,13 +1808,13 @@ func TestCheckHealth_NonBasicAuth(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		connector   func(string) (*sql.DB, error)
+		connector   func(context.Context, godror.ConnectionParams) (*sql.DB, error)
 		expectMsg   string
 		expectState backend.HealthStatus
 	}{
 		{
 			name: "non-basic-success",
-			connector: func(string) (*sql.DB, error) {
+			connector: func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 				db, _, err := sqlmock.New()
 				return db, err
 			},
@@ -1523,7 +1823,7 @@ func TestCheckHealth_NonBasicAuth(t *testing.T) {
 		},
 		{
 			name: "non-basic-failure",
-			connector: func(string) (*sql.DB, error) {
+			connector: func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 				return nil, errors.New("boom")
 			},
 			expectMsg:   "Error Connecting to Database!!! ERROR: boom",
@@ -1628,7 +1928,17 @@ func (t *testPacketSender) Send(_ *backend.StreamPacket) error {
 }
 
 func TestRunStream_ContextCancel(t *testing.T) {
-	ds := &OracleDatasource{}
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
+	defer func() { dbConnector = orig }()
+
+	ds := makeTestDS()
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -1672,7 +1982,7 @@ func TestQueryData_DBError(t *testing.T) {
 	orig := dbConnector
 	defer func() { dbConnector = orig }()
 
-	dbConnector = func(string) (*sql.DB, error) {
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) {
 		return nil, fmt.Errorf("mock db error")
 	}
 
@@ -1727,7 +2037,7 @@ func TestQuery_SQLBranch(t *testing.T) {
 		AddRow("{\"host\":\"server3\"}", "0.3", "1766486100")
 
 	mock.ExpectQuery("SELECT").WillReturnRows(rows)
-	resp := query(q, db, "test")
+	resp := query(context.Background(), q, db, queryConfig{DeploymentType: "test"})
 
 	// Observable assertion
 	if resp.Error != nil {
@@ -1741,3 +2051,71 @@ func TestQuery_SQLBranch(t *testing.T) {
 */
 
 
+
+// benchmarkRows is the size of the result sets of the conversion benchmarks.
+const benchmarkRows = 1000000
+
+// BenchmarkGetDataFrameFromRows_1MRows measures the conversion of 1M rows
+// scanned in their native types, as returned by godror, in table and in time
+// series format.
+func BenchmarkGetDataFrameFromRows_1MRows(b *testing.B) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hosts := []string{"host-a", "host-b", "host-c", "host-d"}
+
+	//the values are returned in the native types of the driver, NUMBER(12,2)
+	//columns are scanned into a float64 without going through text
+	values := make([][]driver.Value, benchmarkRows)
+	for r := range values {
+		values[r] = []driver.Value{
+			start.Add(time.Duration(r/len(hosts)) * time.Second),
+			hosts[r%len(hosts)], float64(r) + 0.25}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		format string
+	}{
+		{name: "table", format: formatTable},
+		{name: "time_series", format: formatTimeSeries},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					b.Fatalf("sqlmock.New: %v", err)
+				}
+				rows := sqlmock.NewRowsWithColumnDefinition(
+					sqlmock.NewColumn("TIME").OfType("TIMESTAMP", time.Time{}),
+					sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+					sqlmock.NewColumn("VALUE").OfType("NUMBER", float64(0)).
+						WithPrecisionAndScale(12, 2),
+				).AddRows(values...)
+				mock.ExpectQuery("SELECT").WillReturnRows(rows)
+				sqlRows, err := db.Query("SELECT")
+				if err != nil {
+					b.Fatalf("db.Query: %v", err)
+				}
+				var processed int
+				var after time.Time
+				b.StartTimer()
+
+				_, _, err = getDataFrameFromRows(sqlRows, false,
+					tc.format, "SELECT", "", "query", frameOptions{},
+					&processed, &after)
+
+				b.StopTimer()
+				if err != nil {
+					b.Fatalf("getDataFrameFromRows: %v", err)
+				}
+				if processed != benchmarkRows {
+					b.Fatalf("rowsProcessed = %d, want %d", processed, benchmarkRows)
+				}
+				sqlRows.Close()
+				db.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(benchmarkRows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
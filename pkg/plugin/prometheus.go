@@ -0,0 +1,309 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// result types of the Prometheus query API
+const (
+	promResultMatrix = "matrix"
+	promResultVector = "vector"
+	promResultScalar = "scalar"
+	promResultString = "string"
+)
+
+// promFrameTypes are the data plane types of the frames of each result type.
+// Instant vectors and scalars keep the time of their sample, so they are time
+// series of one row rather than numeric frames, which have no time field.
+var promFrameTypes = map[string]data.FrameType{
+	promResultMatrix: data.FrameTypeTimeSeriesMulti,
+	promResultVector: data.FrameTypeTimeSeriesMulti,
+	promResultScalar: data.FrameTypeTimeSeriesWide,
+	promResultString: data.FrameTypeTable,
+}
+
+// promResponse is the JSON document returned by promql_range, it follows the
+// response of the Prometheus HTTP query API.
+type promResponse struct {
+	Status    string   `json:"status"`
+	Data      promData `json:"data"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Infos     []string `json:"infos"`
+}
+
+type promData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+// promSeries is a series of a matrix (Values) or vector (Value) result.
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values []promSample      `json:"values"`
+	Value  *promSample       `json:"value"`
+}
+
+// promSample is a [<unix time>, "<value>"] pair.
+type promSample struct {
+	Time  float64
+	Value string
+}
+
+func (s *promSample) UnmarshalJSON(b []byte) error {
+	var pair []interface{}
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("invalid sample %s, expected [time, value]", b)
+	}
+	t, ok := pair[0].(float64)
+	if !ok {
+		return fmt.Errorf("invalid sample time %v", pair[0])
+	}
+	v, ok := pair[1].(string)
+	if !ok {
+		return fmt.Errorf("invalid sample value %v", pair[1])
+	}
+	s.Time, s.Value = t, v
+	return nil
+}
+
+// sampleTime returns the time of the sample. Prometheus timestamps have a
+// millisecond precision, the time is rounded to the millisecond so that the
+// float representation does not leak into the result.
+func (s promSample) sampleTime() time.Time {
+	return time.UnixMilli(int64(math.Round(s.Time * 1000))).UTC()
+}
+
+// sampleValue parses the value of the sample, "NaN", "+Inf" and "-Inf"
+// included. NaN, which also encodes the staleness markers, is returned as nil
+// when nanAsNull is set.
+func (s promSample) sampleValue(nanAsNull bool) (*float64, error) {
+	value, err := strconv.ParseFloat(s.Value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sample value %q", s.Value)
+	}
+	if nanAsNull && math.IsNaN(value) {
+		return nil, nil
+	}
+	return &value, nil
+}
+
+// newPromValueField returns the field holding the values of a series, it
+// is nullable when NaN samples are returned as null.
+func newPromValueField(name string, labels data.Labels, opts frameOptions) *data.Field {
+	if opts.NaNAsNull {
+		return data.NewField(name, labels, []*float64{})
+	}
+	return data.NewField(name, labels, []float64{})
+}
+
+// appendPromSample appends the sample to the time and value fields of frame.
+func appendPromSample(frame *data.Frame, sample promSample,
+	opts frameOptions) error {
+	value, err := sample.sampleValue(opts.NaNAsNull)
+	if err != nil {
+		customLogger("error", "Failed to parse value", err)
+		return err
+	}
+	if opts.NaNAsNull {
+		frame.AppendRow(sample.sampleTime(), value)
+	} else {
+		frame.AppendRow(sample.sampleTime(), *value)
+	}
+	return nil
+}
+
+// promResponseToFrames converts the response of the telemetry query API to
+// data frames, one per series. An error status of the API is returned as
+// error, its warnings and infos as notices of the frames. It also returns the
+// number of samples converted.
+func promResponseToFrames(body []byte, legendTextVal string,
+	opts frameOptions) (
+	data.Frames, int, error) {
+	var resp promResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		customLogger("error", "Error in Json Unmarshal", err)
+		return nil, 0, err
+	}
+	if resp.Status == "error" {
+		msg := resp.Error
+		if msg == "" {
+			msg = "query failed"
+		}
+		if resp.ErrorType != "" {
+			msg = resp.ErrorType + ": " + msg
+		}
+		return nil, 0, errors.New(msg)
+	}
+
+	var frames data.Frames
+	samples := 0
+	var err error
+	switch resp.Data.ResultType {
+	case promResultMatrix, promResultVector:
+		frames, samples, err = promSeriesToFrames(resp.Data, legendTextVal, opts)
+	case promResultScalar, promResultString:
+		frames, samples, err = promValueToFrames(resp.Data, legendTextVal, opts)
+	case "":
+		// no data, e.g. a response carrying only warnings
+	default:
+		err = fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if frameType, ok := promFrameTypes[resp.Data.ResultType]; ok {
+		frames = setFrameType(frames, frameType)
+	}
+
+	notices := promNotices(resp)
+	if len(notices) > 0 {
+		if len(frames) == 0 {
+			// keep the notices visible when the query returns nothing
+			frames = append(frames, data.NewFrame("response"))
+		}
+		for _, frame := range frames {
+			frame.AppendNotices(notices...)
+		}
+	}
+	return frames, samples, nil
+}
+
+// promNotices returns the warnings and infos of the response as notices.
+func promNotices(resp promResponse) []data.Notice {
+	var notices []data.Notice
+	for _, warning := range resp.Warnings {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     warning,
+		})
+	}
+	for _, info := range resp.Infos {
+		notices = append(notices, data.Notice{
+			Severity: data.NoticeSeverityInfo,
+			Text:     info,
+		})
+	}
+	return notices
+}
+
+// promSeriesToFrames converts a matrix or vector result, a frame is created
+// per series with a time and a value field. The series of a vector have a
+// single sample.
+func promSeriesToFrames(result promData, legendTextVal string,
+	opts frameOptions) (
+	data.Frames, int, error) {
+	var series []promSeries
+	if err := json.Unmarshal(result.Result, &series); err != nil {
+		return nil, 0, fmt.Errorf("invalid %s result: %w", result.ResultType, err)
+	}
+	frames := data.Frames{}
+	samples := 0
+	legend := newLegend(legendTextVal)
+	for _, item := range series {
+		//prepare tags to add in current timeseries dataframe
+		tags := make(map[string]string, len(item.Metric))
+		for key, element := range item.Metric {
+			tags[key] = element
+		}
+		values := item.Values
+		if result.ResultType == promResultVector && item.Value != nil {
+			values = []promSample{*item.Value}
+		}
+
+		frame := data.NewFrame("response")
+		frame.Fields = append(frame.Fields,
+			data.NewField("METRIC_TIME", nil, []time.Time{}),
+		)
+		if legendTextVal == "" {
+			frame.Fields = append(frame.Fields,
+				newPromValueField(tags["__name__"], tags, opts),
+			)
+		} else {
+			finalLegend := legend.execute(tags)
+			frame.Fields = append(frame.Fields,
+				newPromValueField(finalLegend, tags, opts).SetConfig(
+					&data.FieldConfig{DisplayNameFromDS: finalLegend}),
+			)
+		}
+		for _, sample := range values {
+			if err := appendPromSample(frame, sample, opts); err != nil {
+				return nil, 0, err
+			}
+			samples++
+		}
+		frames = append(frames, frame)
+	}
+	return frames, samples, nil
+}
+
+// promValueToFrames converts a scalar or string result, a single sample
+// without labels.
+func promValueToFrames(result promData, legendTextVal string,
+	opts frameOptions) (
+	data.Frames, int, error) {
+	var sample promSample
+	if err := json.Unmarshal(result.Result, &sample); err != nil {
+		return nil, 0, fmt.Errorf("invalid %s result: %w", result.ResultType, err)
+	}
+	name := result.ResultType
+	var config *data.FieldConfig
+	if legendTextVal != "" {
+		name = newLegend(legendTextVal).execute(nil)
+		config = &data.FieldConfig{DisplayNameFromDS: name}
+	}
+
+	frame := data.NewFrame("response")
+	frame.Fields = append(frame.Fields,
+		data.NewField("METRIC_TIME", nil, []time.Time{}),
+	)
+	if result.ResultType == promResultString {
+		frame.Fields = append(frame.Fields,
+			data.NewField(name, nil, []string{}).SetConfig(config))
+		frame.AppendRow(sample.sampleTime(), sample.Value)
+		return data.Frames{frame}, 1, nil
+	}
+	frame.Fields = append(frame.Fields,
+		newPromValueField(name, nil, opts).SetConfig(config))
+	if err := appendPromSample(frame, sample, opts); err != nil {
+		return nil, 0, err
+	}
+	return data.Frames{frame}, 1, nil
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestPromResponseToFrames(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		legend      string
+		opts        frameOptions
+		wantErr     string
+		wantFrames  int
+		wantSamples int
+		check       func(t *testing.T, frames data.Frames)
+	}{
+		{
+			name: "matrix",
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up","job":"a"},"values":[[1700000000,"1"],[1700000060,"0"]]},
+				{"metric":{"__name__":"up","job":"b"},"values":[[1700000000,"1"]]}]}}`,
+			legend:      "{{job}}",
+			wantFrames:  2,
+			wantSamples: 3,
+			check: func(t *testing.T, frames data.Frames) {
+				if got := frames[1].Fields[1].Config.DisplayNameFromDS; got != "b" {
+					t.Fatalf("legend = %q, want b", got)
+				}
+			},
+		},
+		{
+			name: "vector",
+			body: `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"up","job":"a"},"value":[1700000000,"1"]},
+				{"metric":{"__name__":"up","job":"b"},"value":[1700000000,"0"]}]}}`,
+			wantFrames:  2,
+			wantSamples: 2,
+			check: func(t *testing.T, frames data.Frames) {
+				field := frames[1].Fields[1]
+				if field.Name != "up" || field.Labels["job"] != "b" || field.At(0).(float64) != 0 {
+					t.Fatalf("unexpected vector field %s %v %v", field.Name, field.Labels, field.At(0))
+				}
+				if !frames[1].Fields[0].At(0).(time.Time).Equal(time.Unix(1700000000, 0)) {
+					t.Fatalf("unexpected time %v", frames[1].Fields[0].At(0))
+				}
+			},
+		},
+		{
+			name:        "scalar",
+			body:        `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"42.5"]}}`,
+			wantFrames:  1,
+			wantSamples: 1,
+			check: func(t *testing.T, frames data.Frames) {
+				field := frames[0].Fields[1]
+				if field.Name != "scalar" || field.At(0).(float64) != 42.5 {
+					t.Fatalf("unexpected scalar field %s %v", field.Name, field.At(0))
+				}
+			},
+		},
+		{
+			name:        "string",
+			body:        `{"status":"success","data":{"resultType":"string","result":[1700000000,"hello"]}}`,
+			legend:      "greeting",
+			wantFrames:  1,
+			wantSamples: 1,
+			check: func(t *testing.T, frames data.Frames) {
+				field := frames[0].Fields[1]
+				if field.Name != "greeting" || field.At(0).(string) != "hello" {
+					t.Fatalf("unexpected string field %s %v", field.Name, field.At(0))
+				}
+			},
+		},
+		{
+			name: "warnings",
+			body: `{"status":"success","warnings":["results truncated"],"infos":["metric might not be a counter"],
+				"data":{"resultType":"vector","result":[{"metric":{"__name__":"up"},"value":[1700000000,"1"]}]}}`,
+			wantFrames:  1,
+			wantSamples: 1,
+			check: func(t *testing.T, frames data.Frames) {
+				notices := frames[0].Meta.Notices
+				if len(notices) != 2 ||
+					notices[0].Severity != data.NoticeSeverityWarning ||
+					notices[0].Text != "results truncated" ||
+					notices[1].Severity != data.NoticeSeverityInfo {
+					t.Fatalf("unexpected notices %+v", notices)
+				}
+			},
+		},
+		{
+			name:       "warnings without data",
+			body:       `{"status":"success","warnings":["no samples in range"],"data":{"resultType":"matrix","result":[]}}`,
+			wantFrames: 1,
+			check: func(t *testing.T, frames data.Frames) {
+				if len(frames[0].Fields) != 0 || len(frames[0].Meta.Notices) != 1 {
+					t.Fatalf("unexpected frame %+v", frames[0])
+				}
+			},
+		},
+		{
+			name: "sub-second timestamps",
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up"},"values":[[1700000000.001,"1"],[1700000000.25,"2"],[1700000000.999,"3"]]}]}}`,
+			wantFrames:  1,
+			wantSamples: 3,
+			check: func(t *testing.T, frames data.Frames) {
+				for i, ms := range []int64{1700000000001, 1700000000250, 1700000000999} {
+					if got := frames[0].Fields[0].At(i).(time.Time); !got.Equal(time.UnixMilli(ms)) {
+						t.Fatalf("row %d time = %v, want %v", i, got, time.UnixMilli(ms))
+					}
+				}
+			},
+		},
+		{
+			name: "special values",
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up"},"values":[[1700000000,"NaN"],[1700000015,"+Inf"],[1700000030,"-Inf"],[1700000045,"1"]]}]}}`,
+			wantFrames:  1,
+			wantSamples: 4,
+			check: func(t *testing.T, frames data.Frames) {
+				field := frames[0].Fields[1]
+				if !math.IsNaN(field.At(0).(float64)) || !math.IsInf(field.At(1).(float64), 1) ||
+					!math.IsInf(field.At(2).(float64), -1) || field.At(3).(float64) != 1 {
+					t.Fatalf("unexpected values %v %v %v %v", field.At(0), field.At(1),
+						field.At(2), field.At(3))
+				}
+			},
+		},
+		{
+			name: "NaN as null",
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"up"},"values":[[1700000000,"NaN"],[1700000015,"+Inf"],[1700000030,"2"]]}]}}`,
+			opts:        frameOptions{NaNAsNull: true},
+			wantFrames:  1,
+			wantSamples: 3,
+			check: func(t *testing.T, frames data.Frames) {
+				field := frames[0].Fields[1]
+				if field.At(0).(*float64) != nil || !math.IsInf(*field.At(1).(*float64), 1) ||
+					*field.At(2).(*float64) != 2 {
+					t.Fatalf("unexpected values %v %v %v", field.At(0), field.At(1), field.At(2))
+				}
+			},
+		},
+		{
+			name:        "scalar NaN as null",
+			body:        `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"NaN"]}}`,
+			opts:        frameOptions{NaNAsNull: true},
+			wantFrames:  1,
+			wantSamples: 1,
+			check: func(t *testing.T, frames data.Frames) {
+				if frames[0].Fields[1].At(0).(*float64) != nil {
+					t.Fatalf("expected null scalar, got %v", frames[0].Fields[1].At(0))
+				}
+			},
+		},
+		{
+			name:    "error status",
+			body:    `{"status":"error","errorType":"bad_data","error":"parse error at char 5"}`,
+			wantErr: "bad_data: parse error at char 5",
+		},
+		{
+			name:    "error status without message",
+			body:    `{"status":"error"}`,
+			wantErr: "query failed",
+		},
+		{
+			name:    "unsupported result type",
+			body:    `{"status":"success","data":{"resultType":"histogram","result":[]}}`,
+			wantErr: `unsupported result type "histogram"`,
+		},
+		{
+			name:    "invalid sample",
+			body:    `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000]}]}}`,
+			wantErr: "invalid vector result: invalid sample [1700000000], expected [time, value]",
+		},
+		{
+			name:    "invalid value",
+			body:    `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"abc"]}}`,
+			wantErr: `invalid sample value "abc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, samples, err := promResponseToFrames([]byte(tt.body), tt.legend, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("promResponseToFrames: %v", err)
+			}
+			if len(frames) != tt.wantFrames || samples != tt.wantSamples {
+				t.Fatalf("got %d frames and %d samples, want %d and %d",
+					len(frames), samples, tt.wantFrames, tt.wantSamples)
+			}
+			if tt.check != nil {
+				tt.check(t, frames)
+			}
+		})
+	}
+}
+
+func TestQuery_PromQLErrorStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`promql_range`).WillReturnRows(
+		sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("PROM_RESULT").OfType("CLOB", ""),
+		).AddRow(`{"status":"error","errorType":"timeout","error":"query timed out in expression evaluation"}`))
+
+	resp := query(context.Background(), backend.DataQuery{
+		JSON:      json.RawMessage(`{"exprProm":"sum(rate(x[5m]))"}`),
+		TimeRange: testTimeRange,
+	}, db, queryConfig{DeploymentType: "ONPREM"})
+	want := "timeout: query timed out in expression evaluation"
+	if resp.Error == nil || resp.Error.Error() != want {
+		t.Fatalf("error = %v, want %q", resp.Error, want)
+	}
+	if len(resp.Frames) != 0 {
+		t.Fatalf("expected no frames on error, got %d", len(resp.Frames))
+	}
+}
+
+func TestNewOracleDatasource_NaNValues(t *testing.T) {
+	for setting, want := range map[string]bool{"": false, "nan": false, "null": true, " NULL ": true} {
+		raw, _ := json.Marshal(map[string]interface{}{"nanValues": setting})
+		instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{JSONData: raw})
+		if err != nil {
+			t.Fatalf("NewOracleDatasource: %v", err)
+		}
+		ds := instance.(*OracleDatasource)
+		if ds.NaNAsNull != want || ds.getQueryConfig().NaNAsNull != want {
+			t.Errorf("nanValues %q: NaNAsNull = %v, want %v", setting, ds.NaNAsNull, want)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// queryModelVersion is the version of the query model written by the current
+// query editor. Queries saved without a version are migrated from the older
+// shapes by migrate().
+const queryModelVersion = 1
+
+// query languages
+const (
+	queryLangPromQL = "promql"
+	queryLangSQL    = "sql"
+)
+
+// result formats of a query
+const (
+	formatTimeSeries = "time_series"
+	formatTable      = "table"
+	formatLogs       = "logs"
+	// time series when the columns of a SQL result allow it, else table
+	formatAuto = "auto"
+)
+
+// defaults applied to the fields left empty by the query editor
+const (
+	// auto step of a query sent without interval and max data points
+	defaultStepSecs      = 10
+	defaultPrefetchCount = 100
+)
+
+// QueryModel is the query sent by the frontend for each DataQuery. Text
+// fields hold what the user typed in the query editor, the values used to run
+// the query are resolved by validate().
+type QueryModel struct {
+	Version   int    `json:"version"`
+	RefID     string `json:"refId"`
+	QueryLang string `json:"queryLang"`
+	Format    string `json:"format"`
+	// fields promql
+	ExprProm         string     `json:"exprProm"`
+	LegendFormatProm string     `json:"legendFormatProm"`
+	StepTextProm     flexString `json:"stepTextProm"`
+	// fields sql
+	ExprSql           string     `json:"exprSql"`
+	LegendFormatSql   string     `json:"legendFormatSql"`
+	StepTextSql       flexString `json:"stepTextSql"`
+	PrefetchCountText flexString `json:"prefetchCountText"`
+	ConvertSqlResults *flexBool  `json:"convertSqlResults"`
+	// columns of the time series built from a SQL result, found by the
+	// type and name of the columns when not set
+	TimeColumn       string   `json:"timeColumn"`
+	TimeColumnUnit   string   `json:"timeColumnUnit"`
+	ValueColumns     flexList `json:"valueColumns"`
+	MetricColumn     string   `json:"metricColumn"`
+	LabelColumns     flexList `json:"labelColumns"`
+	LabelsJSONColumn string   `json:"labelsJsonColumn"`
+	// lower bound of the step, and the resolution factor of an auto step,
+	// 2 for 1/2 of the points
+	MinStep    flexString `json:"minStep"`
+	Resolution flexString `json:"resolution"`
+	// timeout in seconds, overrides the one of the datasource when set
+	QueryTimeout flexString `json:"queryTimeout"`
+	// current values of the dashboard variables by name, bound to the bind
+	// variables of SQL queries, see sqlQueryBinds. A multi-value variable is
+	// a single comma separated string, IN (:name) does not match its values.
+	Variables map[string]flexValue `json:"variables"`
+	// query text of the older query shapes
+	Expr string `json:"expr"`
+
+	// values resolved by validate(), a step of 0 is an auto step
+	step          int64
+	minStep       int64
+	resolution    int64
+	prefetchCount int
+	timeout       time.Duration
+	columns       seriesColumns
+}
+
+// legacyQueryModel holds the fields of the query shapes saved before the
+// query model was versioned.
+type legacyQueryModel struct {
+	LegendFormat string     `json:"legendFormat"`
+	StepText     flexString `json:"stepText"`
+}
+
+// flexString is a field the query editor saves as text, older dashboards and
+// queries built through the API may hold a number instead.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = ""
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = flexString(strings.TrimSpace(str))
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err == nil {
+		*s = flexString(num.String())
+		return nil
+	}
+	return &json.UnmarshalTypeError{Value: string(b), Type: reflect.TypeOf("")}
+}
+
+// flexBool is a switch of the query editor, older dashboards may hold it as
+// "true" or "false".
+type flexBool bool
+
+func (v *flexBool) UnmarshalJSON(b []byte) error {
+	var val bool
+	if err := json.Unmarshal(b, &val); err == nil {
+		*v = flexBool(val)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		if val, err := strconv.ParseBool(strings.TrimSpace(str)); err == nil {
+			*v = flexBool(val)
+			return nil
+		}
+	}
+	return &json.UnmarshalTypeError{Value: string(b), Type: reflect.TypeOf(true)}
+}
+
+// flexList is a list of names, saved by the query editor as a list or as
+// comma separated text.
+type flexList []string
+
+func (l *flexList) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		var text flexString
+		if err := text.UnmarshalJSON(b); err != nil {
+			return &json.UnmarshalTypeError{Value: string(b),
+				Type: reflect.TypeOf([]string{})}
+		}
+		list = strings.Split(string(text), ",")
+	}
+	*l = nil
+	for _, name := range list {
+		if name = strings.TrimSpace(name); name != "" {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
+
+// flexValue holds the values of a dashboard variable, sent as a list for the
+// variables with many values and as a single value otherwise.
+type flexValue []string
+
+func (v *flexValue) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*v = flexValue{str}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*v = flexValue(list)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err == nil {
+		*v = flexValue{num.String()}
+		return nil
+	}
+	return &json.UnmarshalTypeError{Value: string(b), Type: reflect.TypeOf("")}
+}
+
+// fieldError reports an invalid value of a field of the query model.
+type fieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e *fieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// queryModelError holds all the invalid fields of a query, so that they are
+// reported together instead of one per run.
+type queryModelError []*fieldError
+
+func (e queryModelError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "invalid query: " + strings.Join(msgs, "; ")
+}
+
+// parseQueryModel decodes, migrates and validates the query of a DataQuery.
+func parseQueryModel(query backend.DataQuery) (*QueryModel, error) {
+	qm := &QueryModel{}
+	legacy := &legacyQueryModel{}
+	if len(query.JSON) > 0 {
+		if err := json.Unmarshal(query.JSON, qm); err != nil {
+			return nil, jsonFieldError(query.JSON, err)
+		}
+		if err := json.Unmarshal(query.JSON, legacy); err != nil {
+			return nil, jsonFieldError(query.JSON, err)
+		}
+	}
+	if qm.RefID == "" {
+		qm.RefID = query.RefID
+	}
+	if err := qm.migrate(legacy); err != nil {
+		return nil, err
+	}
+	if err := qm.validate(); err != nil {
+		return nil, err
+	}
+	return qm, nil
+}
+
+// jsonFieldError turns a decoding error of b into an error naming the
+// fields that could not be decoded.
+func jsonFieldError(b []byte, err error) error {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(b, &fields) != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs queryModelError
+	for _, name := range names {
+		field, _ := json.Marshal(map[string]json.RawMessage{name: fields[name]})
+		if json.Unmarshal(field, &QueryModel{}) != nil ||
+			json.Unmarshal(field, &legacyQueryModel{}) != nil {
+			errs = append(errs, &fieldError{Field: name,
+				Msg: fmt.Sprintf("unexpected value %s", fields[name])})
+		}
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	return errs
+}
+
+// migrate brings a query saved by an older version of the plugin to the
+// current shape.
+func (qm *QueryModel) migrate(legacy *legacyQueryModel) error {
+	if qm.Version > queryModelVersion {
+		return queryModelError{{
+			Field: "version",
+			Msg: fmt.Sprintf("query version %d is newer than the supported version %d",
+				qm.Version, queryModelVersion),
+		}}
+	}
+	qm.QueryLang = strings.ToLower(strings.TrimSpace(qm.QueryLang))
+	if qm.Version == 0 {
+		// before the editor had one set of fields per language the query
+		// was saved in expr, legendFormat and stepText
+		if qm.QueryLang == queryLangSQL {
+			if qm.ExprSql == "" {
+				qm.ExprSql = qm.Expr
+			}
+			if qm.LegendFormatSql == "" {
+				qm.LegendFormatSql = legacy.LegendFormat
+			}
+			if qm.StepTextSql == "" {
+				qm.StepTextSql = legacy.StepText
+			}
+		} else {
+			if qm.ExprProm == "" {
+				qm.ExprProm = qm.Expr
+			}
+			if qm.LegendFormatProm == "" {
+				qm.LegendFormatProm = legacy.LegendFormat
+			}
+			if qm.StepTextProm == "" {
+				qm.StepTextProm = legacy.StepText
+			}
+		}
+		// the format used to be chosen with the convertSqlResults switch
+		if qm.Format == "" && qm.ConvertSqlResults != nil &&
+			!bool(*qm.ConvertSqlResults) {
+			qm.Format = formatTable
+		}
+	}
+	qm.Version = queryModelVersion
+	return nil
+}
+
+// isPromQL returns true if the query is written in PromQL.
+func (qm *QueryModel) isPromQL() bool {
+	return qm.QueryLang != queryLangSQL
+}
+
+// validate checks the fields of the query, applies the defaults and resolves
+// the values used to run it. All invalid fields are reported.
+func (qm *QueryModel) validate() error {
+	var errs queryModelError
+	addErr := func(field, format string, args ...interface{}) {
+		errs = append(errs, &fieldError{Field: field,
+			Msg: fmt.Sprintf(format, args...)})
+	}
+
+	switch qm.QueryLang {
+	case "":
+		qm.QueryLang = queryLangPromQL
+	case queryLangPromQL, queryLangSQL:
+	default:
+		addErr("queryLang", "unsupported query language %q, expected %q or %q",
+			qm.QueryLang, queryLangPromQL, queryLangSQL)
+	}
+
+	qm.Format = strings.ToLower(strings.TrimSpace(qm.Format))
+	switch qm.Format {
+	case "":
+		qm.Format = formatTimeSeries
+		if qm.ConvertSqlResults != nil && !bool(*qm.ConvertSqlResults) {
+			qm.Format = formatTable
+		}
+	case formatTimeSeries, formatTable, formatLogs, formatAuto:
+	default:
+		addErr("format", "unsupported format %q, expected %q, %q, %q or %q",
+			qm.Format, formatTimeSeries, formatTable, formatLogs, formatAuto)
+	}
+
+	stepField, stepText := "stepTextProm", qm.StepTextProm
+	if qm.QueryLang == queryLangSQL {
+		stepField, stepText = "stepTextSql", qm.StepTextSql
+	}
+	qm.step = 0
+	if stepText != "" && !strings.EqualFold(string(stepText), stepAuto) {
+		step, err := parseStepText(string(stepText))
+		if err != nil {
+			addErr(stepField, "step must be a positive number of seconds, got %q",
+				stepText)
+		} else {
+			qm.step = step
+		}
+	}
+
+	qm.minStep = 0
+	if qm.MinStep != "" {
+		step, err := parseStepText(string(qm.MinStep))
+		if err != nil {
+			addErr("minStep", "min step must be a positive number of seconds, got %q",
+				qm.MinStep)
+		} else {
+			qm.minStep = step
+		}
+	}
+
+	qm.resolution = 1
+	if qm.Resolution != "" {
+		//the query editor shows the factor as 1/2
+		text := strings.TrimPrefix(string(qm.Resolution), "1/")
+		factor, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil || factor <= 0 {
+			addErr("resolution", "resolution must be 1/1, 1/2, ... 1/10, got %q",
+				qm.Resolution)
+		} else {
+			qm.resolution = factor
+		}
+	}
+
+	qm.prefetchCount = defaultPrefetchCount
+	if qm.PrefetchCountText != "" {
+		count, err := strconv.Atoi(string(qm.PrefetchCountText))
+		if err != nil || count <= 0 {
+			addErr("prefetchCountText",
+				"prefetch count must be a positive number, got %q",
+				qm.PrefetchCountText)
+		} else {
+			qm.prefetchCount = count
+		}
+	}
+
+	if qm.QueryTimeout != "" {
+		secs, err := strconv.ParseFloat(string(qm.QueryTimeout), 64)
+		if err != nil || secs <= 0 {
+			addErr("queryTimeout",
+				"timeout must be a positive number of seconds, got %q",
+				qm.QueryTimeout)
+		} else {
+			qm.timeout, _ = getTimeoutSecs(secs)
+		}
+	}
+
+	qm.TimeColumnUnit = strings.ToLower(strings.TrimSpace(qm.TimeColumnUnit))
+	switch qm.TimeColumnUnit {
+	case "", timeUnitSeconds, timeUnitMillis, timeUnitNanos, timeUnitDate,
+		timeUnitTimestamp:
+	default:
+		addErr("timeColumnUnit",
+			"unsupported time column unit %q, expected %q, %q, %q, %q or %q",
+			qm.TimeColumnUnit, timeUnitSeconds, timeUnitMillis, timeUnitNanos,
+			timeUnitDate, timeUnitTimestamp)
+	}
+	qm.columns = seriesColumns{
+		Time:       strings.TrimSpace(qm.TimeColumn),
+		TimeUnit:   qm.TimeColumnUnit,
+		Values:     qm.ValueColumns,
+		Metric:     strings.TrimSpace(qm.MetricColumn),
+		Labels:     qm.LabelColumns,
+		LabelsJSON: strings.TrimSpace(qm.LabelsJSONColumn),
+	}
+
+	if strings.TrimSpace(qm.expr()) == "" {
+		field := "exprProm"
+		if qm.QueryLang == queryLangSQL {
+			field = "exprSql"
+		}
+		addErr(field, "query is empty")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// expr returns the query text of the query language.
+func (qm *QueryModel) expr() string {
+	if qm.QueryLang == queryLangSQL {
+		return qm.ExprSql
+	}
+	return qm.ExprProm
+}
+
+// legendFormat returns the legend format of the query language.
+func (qm *QueryModel) legendFormat() string {
+	if qm.QueryLang == queryLangSQL {
+		return qm.LegendFormatSql
+	}
+	return qm.LegendFormatProm
+}
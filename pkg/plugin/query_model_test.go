@@ -0,0 +1,220 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestParseQueryModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		check    func(t *testing.T, qm *QueryModel)
+		wantErrs []string
+	}{
+		{
+			name: "defaults",
+			json: `{"refId":"A","exprProm":"up"}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.QueryLang != queryLangPromQL || qm.Format != formatTimeSeries ||
+					qm.step != 0 || qm.resolution != 1 || qm.prefetchCount != defaultPrefetchCount ||
+					qm.timeout != 0 || qm.Version != queryModelVersion {
+					t.Fatalf("unexpected defaults: %+v", qm)
+				}
+			},
+		},
+		{
+			name: "sql fields",
+			json: `{"refId":"A","queryLang":"sql","exprSql":"select 1 from dual",
+				"stepTextSql":"30","prefetchCountText":"500","queryTimeout":"2.5",
+				"convertSqlResults":false,"legendFormatSql":"{{host}}"}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.expr() != "select 1 from dual" || qm.legendFormat() != "{{host}}" ||
+					qm.step != 30 || qm.prefetchCount != 500 ||
+					qm.timeout != 2500*time.Millisecond || qm.Format != formatTable {
+					t.Fatalf("unexpected model: %+v", qm)
+				}
+			},
+		},
+		{
+			name: "numbers instead of text",
+			json: `{"refId":"A","exprProm":"up","stepTextProm":60,"queryTimeout":5}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.step != 60 || qm.timeout != 5*time.Second {
+					t.Fatalf("unexpected model: %+v", qm)
+				}
+			},
+		},
+		{
+			name: "legacy promql shape",
+			json: `{"refId":"A","expr":"rate(x[5m])","legendFormat":"{{job}}",
+				"stepText":"15"}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.ExprProm != "rate(x[5m])" || qm.LegendFormatProm != "{{job}}" ||
+					qm.step != 15 || qm.Version != queryModelVersion {
+					t.Fatalf("unexpected migration: %+v", qm)
+				}
+			},
+		},
+		{
+			name: "explicit format",
+			json: `{"refId":"A","queryLang":"sql","exprSql":"select 1 from dual",
+				"format":" Logs ","convertSqlResults":false}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.Format != formatLogs {
+					t.Fatalf("format = %q, want %q", qm.Format, formatLogs)
+				}
+			},
+		},
+		{
+			name: "series columns",
+			json: `{"refId":"A","queryLang":"sql","exprSql":"select 1 from dual",
+				"timeColumn":" SAMPLE_TS ","timeColumnUnit":"MS",
+				"valueColumns":"VAL, ,RX","labelColumns":["HOST"],
+				"labelsJsonColumn":"ATTRS"}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				c := qm.columns
+				if c.Time != "SAMPLE_TS" || c.TimeUnit != timeUnitMillis ||
+					strings.Join(c.Values, ",") != "VAL,RX" ||
+					strings.Join(c.Labels, ",") != "HOST" || c.LabelsJSON != "ATTRS" {
+					t.Fatalf("unexpected columns: %+v", c)
+				}
+			},
+		},
+		{
+			name: "dashboard variables",
+			json: `{"refId":"A","queryLang":"sql","exprSql":"select 1 from dual",
+				"variables":{"host":" a ","dc":["eu","us"],"n":5}}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				want := map[string]flexValue{"host": {" a "}, "dc": {"eu", "us"}, "n": {"5"}}
+				if !reflect.DeepEqual(qm.Variables, want) {
+					t.Fatalf("variables = %v, want %v", qm.Variables, want)
+				}
+			},
+		},
+		{
+			name: "legacy sql shape",
+			json: `{"refId":"A","queryLang":"SQL","expr":"select 1 from dual",
+				"convertSqlResults":"false"}`,
+			check: func(t *testing.T, qm *QueryModel) {
+				if qm.QueryLang != queryLangSQL || qm.ExprSql != "select 1 from dual" ||
+					qm.Format != formatTable {
+					t.Fatalf("unexpected migration: %+v", qm)
+				}
+			},
+		},
+
+		{
+			name:     "missing expression",
+			json:     `{"refId":"A","queryLang":"sql"}`,
+			wantErrs: []string{"exprSql: query is empty"},
+		},
+		{
+			name: "invalid fields reported together",
+			json: `{"refId":"A","exprProm":"up","stepTextProm":"abc",
+				"prefetchCountText":"-1","queryTimeout":"soon","format":"graph",
+				"timeColumnUnit":"days"}`,
+			wantErrs: []string{
+				`format: unsupported format "graph"`,
+				`stepTextProm: step must be a positive number of seconds, got "abc"`,
+				`prefetchCountText: prefetch count must be a positive number, got "-1"`,
+				`queryTimeout: timeout must be a positive number of seconds, got "soon"`,
+				`timeColumnUnit: unsupported time column unit "days"`,
+			},
+		},
+		{
+			name:     "zero timeout",
+			json:     `{"refId":"A","exprProm":"up","queryTimeout":"0"}`,
+			wantErrs: []string{`queryTimeout: timeout must be a positive number of seconds, got "0"`},
+		},
+		{
+			name:     "unsupported language",
+			json:     `{"refId":"A","queryLang":"kql","exprProm":"up"}`,
+			wantErrs: []string{`queryLang: unsupported query language "kql"`},
+		},
+		{
+			name:     "wrong json type",
+			json:     `{"refId":"A","exprProm":"up","stepTextProm":{"v":1}}`,
+			wantErrs: []string{"stepTextProm: unexpected value"},
+		},
+		{
+			name:     "wrong variable type",
+			json:     `{"refId":"A","queryLang":"sql","exprSql":"select 1 from dual","variables":{"host":{"v":1}}}`,
+			wantErrs: []string{"variables: unexpected value"},
+		},
+		{
+			name:     "newer version",
+			json:     `{"refId":"A","version":99,"exprProm":"up"}`,
+			wantErrs: []string{"version: query version 99 is newer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qm, err := parseQueryModel(backend.DataQuery{RefID: "A", JSON: []byte(tt.json)})
+			if len(tt.wantErrs) > 0 {
+				if err == nil {
+					t.Fatalf("expected errors %v, got none", tt.wantErrs)
+				}
+				for _, want := range tt.wantErrs {
+					if !strings.Contains(err.Error(), want) {
+						t.Fatalf("error %q does not contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseQueryModel: %v", err)
+			}
+			tt.check(t, qm)
+		})
+	}
+}
+
+// A query saved with missing or mistyped fields must come back as an error
+// response, not take down the plugin.
+func TestQuery_InvalidModelDoesNotPanic(t *testing.T) {
+	for _, js := range []string{
+		`null`,
+		`[]`,
+		`{"queryLang":"sql"}`,
+		`{"queryLang":"promql","exprProm":{}}`,
+		`{"exprProm":"up","stepTextProm":"1m"}`,
+	} {
+		resp := query(context.Background(), backend.DataQuery{RefID: "A", JSON: []byte(js)},
+			nil, queryConfig{})
+		if resp.Error == nil {
+			t.Fatalf("query(%s): expected an error response", js)
+		}
+	}
+}
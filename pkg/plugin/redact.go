@@ -0,0 +1,288 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const redactedText = "[REDACTED]"
+
+// keys whose values are never logged, matched case insensitively on the
+// JSON name of a field or map key
+var sensitiveKeyRegex = regexp.MustCompile(
+	`(?i)(password|passwd|secret|token|privatekey|walletzip|securejson)`)
+
+// password=value and "password": "value" pairs in free text
+var secretPairRegex = regexp.MustCompile(
+	`(?i)("?[\w.]*(?:password|passwd|secret|token)"?\s*[=:]\s*)("[^"]*"|[^\s,;&}]+)`)
+
+// credentials in a connect string of the form user/password@service
+var connectCredentialRegex = regexp.MustCompile(
+	`([\w$#.\-]+)/("[^"]*"|[^\s/@"]+)@`)
+
+// minMaskedSecretLength is the length below which a secret is not masked
+// wherever it appears in a message, a short password such as "oracle" would
+// mangle unrelated text. Short secrets are still masked in the password pairs
+// and connect strings of secretPairRegex and connectCredentialRegex.
+const minMaskedSecretLength = 8
+
+// secretRegistry holds the decrypted secure settings of the live datasource
+// instances, so that they can be masked wherever they end up in a message,
+// e.g. echoed back in a driver error. Values are reference counted as two
+// datasources may share a password. Secrets shorter than
+// minMaskedSecretLength are not held.
+type secretRegistry struct {
+	mu      sync.RWMutex
+	secrets map[string]int
+}
+
+var secrets = &secretRegistry{secrets: map[string]int{}}
+
+func (r *secretRegistry) add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		if len(value) >= minMaskedSecretLength {
+			r.secrets[value]++
+		}
+	}
+}
+
+func (r *secretRegistry) remove(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		if len(value) < minMaskedSecretLength {
+			continue
+		}
+		if r.secrets[value] <= 1 {
+			delete(r.secrets, value)
+		} else {
+			r.secrets[value]--
+		}
+	}
+}
+
+// list returns the registered secrets, longest first so that a secret
+// containing another one is masked as a whole.
+func (r *secretRegistry) list() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make([]string, 0, len(r.secrets))
+	for value := range r.secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	return values
+}
+
+// redactString masks the registered secrets, password pairs and connect
+// string credentials in s.
+func redactString(s string) string {
+	for _, secret := range secrets.list() {
+		s = strings.ReplaceAll(s, secret, redactedText)
+	}
+	s = secretPairRegex.ReplaceAllString(s, "${1}"+redactedText)
+	s = connectCredentialRegex.ReplaceAllString(s, "${1}/"+redactedText+"@")
+	return s
+}
+
+// redactError returns err with its message redacted. err itself is returned
+// when there is nothing to mask, so that errors.Is/As keep working.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := redactString(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// redactValue returns a copy of v safe to log. Strings and errors are
+// redacted as text, maps and slices of plain values are rendered through
+// their JSON form with the values of sensitive keys replaced. Structs,
+// pointers and anything else are not logged, only their type is, so that
+// requests, settings and frames never end up in the log.
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return redactString(val)
+	case error:
+		return redactString(val.Error())
+	case time.Time, time.Duration, reflect.Kind:
+		return v
+	case json.RawMessage:
+		return redactJSON(val)
+	case []byte:
+		return redactJSON(val)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return v
+	case reflect.Map, reflect.Slice, reflect.Array:
+		if !isPlainKind(rv.Type().Elem().Kind()) {
+			return omittedValue(v)
+		}
+	default:
+		return omittedValue(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return redactString(fmt.Sprintf("%+v", v))
+	}
+	return redactJSON(b)
+}
+
+// isPlainKind reports whether values of kind k are logged by redactValue
+// as part of a map or a slice.
+func isPlainKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Struct, reflect.Ptr, reflect.Func, reflect.Chan,
+		reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+func omittedValue(v interface{}) string {
+	return fmt.Sprintf("<%T omitted>", v)
+}
+
+// redactJSON parses b as JSON and returns it with the values of sensitive
+// keys replaced, falling back to text redaction when b is not JSON.
+func redactJSON(b []byte) interface{} {
+	var parsed interface{}
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		return redactString(string(b))
+	}
+	return redactTree(parsed)
+}
+
+func redactTree(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if sensitiveKeyRegex.MatchString(key) {
+				val[key] = redactedText
+			} else {
+				val[key] = redactTree(item)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactTree(item)
+		}
+		return val
+	case string:
+		return redactString(val)
+	}
+	return v
+}
+
+// redactingLogger redacts the message and every value before handing them
+// to the Grafana logger. Unless it was derived with With, it looks up
+// log.DefaultLogger on each call so that it follows the logger installed by
+// the SDK.
+type redactingLogger struct {
+	base log.Logger
+}
+
+var pluginLogger log.Logger = redactingLogger{}
+
+func (r redactingLogger) logger() log.Logger {
+	if r.base != nil {
+		return r.base
+	}
+	return log.DefaultLogger
+}
+
+func redactArgs(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		redacted[i] = redactValue(arg)
+	}
+	return redacted
+}
+
+// enabled reports whether logger writes messages of the given level, so
+// that the redaction work is skipped for messages which are dropped anyway.
+func enabled(logger log.Logger, level log.Level) bool {
+	return level >= logger.Level()
+}
+
+func (r redactingLogger) Debug(msg string, args ...interface{}) {
+	if logger := r.logger(); enabled(logger, log.Debug) {
+		logger.Debug(redactString(msg), redactArgs(args)...)
+	}
+}
+
+func (r redactingLogger) Info(msg string, args ...interface{}) {
+	if logger := r.logger(); enabled(logger, log.Info) {
+		logger.Info(redactString(msg), redactArgs(args)...)
+	}
+}
+
+func (r redactingLogger) Warn(msg string, args ...interface{}) {
+	if logger := r.logger(); enabled(logger, log.Warn) {
+		logger.Warn(redactString(msg), redactArgs(args)...)
+	}
+}
+
+func (r redactingLogger) Error(msg string, args ...interface{}) {
+	if logger := r.logger(); enabled(logger, log.Error) {
+		logger.Error(redactString(msg), redactArgs(args)...)
+	}
+}
+
+func (r redactingLogger) With(args ...interface{}) log.Logger {
+	return redactingLogger{base: r.logger().With(redactArgs(args)...)}
+}
+
+func (r redactingLogger) Level() log.Level {
+	return r.logger().Level()
+}
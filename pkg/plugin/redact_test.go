@@ -0,0 +1,255 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	godror "github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// captureLogger records every message and value logged through it.
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+	level log.Level
+}
+
+func (l *captureLogger) record(msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf("%s %+v", msg, args))
+}
+
+func (l *captureLogger) Debug(msg string, args ...interface{}) { l.record(msg, args...) }
+func (l *captureLogger) Info(msg string, args ...interface{})  { l.record(msg, args...) }
+func (l *captureLogger) Warn(msg string, args ...interface{})  { l.record(msg, args...) }
+func (l *captureLogger) Error(msg string, args ...interface{}) { l.record(msg, args...) }
+func (l *captureLogger) With(args ...interface{}) log.Logger   { return l }
+func (l *captureLogger) Level() log.Level                      { return l.level }
+
+func (l *captureLogger) output() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func captureLogs(t *testing.T) *captureLogger {
+	capture := &captureLogger{}
+	orig := log.DefaultLogger
+	log.DefaultLogger = capture
+	t.Cleanup(func() { log.DefaultLogger = orig })
+	return capture
+}
+
+func TestRedactString(t *testing.T) {
+	secrets.add("Sup3r$ecret", "oracle")
+	defer secrets.remove("Sup3r$ecret", "oracle")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "registered secret",
+			in:   "ORA-01017: invalid credential Sup3r$ecret",
+			want: "ORA-01017: invalid credential [REDACTED]",
+		},
+		{
+			name: "connect string credentials",
+			in:   "cannot connect scott/tiger@dbhost:1521/orclpdb1",
+			want: "cannot connect scott/[REDACTED]@dbhost:1521/orclpdb1",
+		},
+		{
+			name: "password pair",
+			in:   `user=scott password="p w" connectString=db`,
+			want: `user=scott password=[REDACTED] connectString=db`,
+		},
+		{
+			name: "json password",
+			in:   `{"dbPassword": "abc","dbUser":"scott"}`,
+			want: `{"dbPassword": [REDACTED],"dbUser":"scott"}`,
+		},
+		{
+			name: "short secret outside of credentials",
+			in:   "ORA-00942: table or view does not exist, user oracle",
+			want: "ORA-00942: table or view does not exist, user oracle",
+		},
+		{
+			name: "short secret in credentials",
+			in:   "logon scott/oracle@db password=oracle",
+			want: "logon scott/[REDACTED]@db password=[REDACTED]",
+		},
+		{
+			name: "nothing to redact",
+			in:   "select sysdate from dual",
+			want: "select sysdate from dual",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactString(tt.in); got != tt.want {
+				t.Fatalf("redactString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecureValues(t *testing.T) {
+	got := secureValues(backend.DataSourceInstanceSettings{
+		DecryptedSecureJSONData: map[string]string{
+			"dbPassword": "Sup3r$ecret",
+			"walletZip":  "UEsDBBQAAAAIAA==",
+		},
+	})
+	if len(got) != 1 || got[0] != "Sup3r$ecret" {
+		t.Fatalf("secureValues = %v, want the password only", got)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	if redactError(nil) != nil {
+		t.Fatalf("redactError(nil) != nil")
+	}
+	plain := errors.New("ORA-12154: could not resolve the connect identifier")
+	if got := redactError(plain); got != plain {
+		t.Fatalf("error without secrets should be returned as is, got %v", got)
+	}
+	got := redactError(errors.New("logon as scott/tiger@db failed"))
+	if strings.Contains(got.Error(), "tiger") {
+		t.Fatalf("password not redacted: %v", got)
+	}
+}
+
+func TestRedactValue(t *testing.T) {
+	settings := backend.DataSourceInstanceSettings{
+		Name:                    "oracle",
+		DecryptedSecureJSONData: map[string]string{"dbPassword": "tiger"},
+	}
+	got := fmt.Sprintf("%v", redactValue(settings))
+	if got != "<backend.DataSourceInstanceSettings omitted>" {
+		t.Fatalf("structs should not be logged: %s", got)
+	}
+	if got := fmt.Sprintf("%v", redactValue(&settings)); strings.Contains(got, "tiger") {
+		t.Fatalf("struct pointers should not be logged: %s", got)
+	}
+	got = fmt.Sprintf("%v", redactValue(map[string]string{
+		"dbUser": "scott", "dbPassword": "tiger"}))
+	if strings.Contains(got, "tiger") || !strings.Contains(got, "scott") {
+		t.Fatalf("map not redacted: %s", got)
+	}
+	if got := redactValue(42); got != 42 {
+		t.Fatalf("redactValue(42) = %v", got)
+	}
+}
+
+func TestLogging_SkipsDisabledLevels(t *testing.T) {
+	capture := captureLogs(t)
+	capture.level = log.Info
+
+	pluginLogger.Debug("dropped", "value", "debug")
+	pluginLogger.Info("kept", "value", "info")
+	pluginLogger.Error("kept", "value", "error")
+	if got := len(capture.lines); got != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", got, capture.output())
+	}
+	if strings.Contains(capture.output(), "dropped") {
+		t.Fatalf("debug message logged at info level:\n%s", capture.output())
+	}
+}
+
+func TestLogging_DoesNotLeakSecrets(t *testing.T) {
+	capture := captureLogs(t)
+	const password = `Xy9#very/secret`
+
+	orig := dbConnector
+	dbConnector = func(_ context.Context, params godror.ConnectionParams) (*sql.DB, error) {
+		// the driver may echo the credentials back in its error
+		return nil, fmt.Errorf("ORA-12154: cannot connect %s/%s@%s",
+			params.Username, params.Password.Secret(), params.ConnectString)
+	}
+	defer func() { dbConnector = orig }()
+
+	raw, _ := json.Marshal(map[string]interface{}{
+		"queryAuth":       "TNS",
+		"dbUser":          "scott",
+		"dbConnectString": "dbhost:1521/orclpdb1",
+	})
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: raw,
+		DecryptedSecureJSONData: map[string]string{
+			"dbPassword": password,
+		},
+	}
+	instance, err := NewOracleDatasource(settings)
+	if err != nil {
+		t.Fatalf("NewOracleDatasource: %v", err)
+	}
+	ds := instance.(*OracleDatasource)
+	defer ds.Dispose()
+
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &settings}
+	_, err = ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: pluginCtx,
+		Queries:       []backend.DataQuery{{RefID: "A"}},
+	})
+	if err == nil {
+		t.Fatalf("QueryData: expected a connection error")
+	}
+	if strings.Contains(err.Error(), password) {
+		t.Fatalf("QueryData error leaks the password: %v", err)
+	}
+
+	result, err := ds.CheckHealth(context.Background(),
+		&backend.CheckHealthRequest{PluginContext: pluginCtx})
+	if err != nil {
+		t.Fatalf("CheckHealth: %v", err)
+	}
+	if strings.Contains(result.Message, password) {
+		t.Fatalf("health message leaks the password: %s", result.Message)
+	}
+
+	out := capture.output()
+	if out == "" {
+		t.Fatalf("expected log output")
+	}
+	for _, part := range strings.Split(password, "/") {
+		if strings.Contains(out, part) {
+			t.Fatalf("log output leaks the password:\n%s", out)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// time range of the metric name lookup when the frontend does not send one
+const defaultLookupRange = time.Hour
+
+// emptyLookupResult is returned when the telemetry query API has no rows
+const emptyLookupResult = `{"status":"success","data":[]}`
+
+// CallResource serves the metadata lookups of the frontend (metric names,
+// label names and values, series of a selector) as REST resources. They
+// return the JSON document of the telemetry query API, which follows the
+// Prometheus HTTP API:
+//
+//	GET /metrics?start=&end=             metric names
+//	GET /labels?start=&end=              label names
+//	GET /label/{name}/values?start=&end= values of a label
+//	GET /series?match[]=&start=&end=     series matching a selector
+//
+// start and end are epoch seconds or RFC 3339 timestamps.
+func (jd *OracleDatasource) CallResource(ctx context.Context,
+	req *backend.CallResourceRequest,
+	sender backend.CallResourceResponseSender) error {
+	customLogger("debug", "CallResource called with path", req.Path)
+	return httpadapter.New(jd.newResourceMux()).CallResource(ctx, req, sender)
+}
+
+// newResourceMux returns the routes of the resources served by CallResource.
+func (jd *OracleDatasource) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", jd.handleMetrics)
+	mux.HandleFunc("GET /labels", jd.handleLabels)
+	mux.HandleFunc("GET /label/{name}/values", jd.handleLabelValues)
+	mux.HandleFunc("GET /series", jd.handleSeries)
+	return mux
+}
+
+func (jd *OracleDatasource) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	to := now()
+	start, end, err := lookupTimeRange(r, to.Add(-defaultLookupRange), to)
+	if err != nil {
+		writeLookupError(w, http.StatusBadRequest, err)
+		return
+	}
+	jd.runLookup(w, r, newPromQLLabelQuery(jd.DeploymentType, "__name__",
+		start, end))
+}
+
+func (jd *OracleDatasource) handleLabels(w http.ResponseWriter, r *http.Request) {
+	// without a range the telemetry query API looks at all the samples
+	start, end, err := lookupTimeRange(r, time.Time{}, time.Time{})
+	if err != nil {
+		writeLookupError(w, http.StatusBadRequest, err)
+		return
+	}
+	jd.runLookup(w, r, newPromQLLabelQuery(jd.DeploymentType, " ", start, end))
+}
+
+func (jd *OracleDatasource) handleLabelValues(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		writeLookupError(w, http.StatusBadRequest,
+			errors.New("label name is required"))
+		return
+	}
+	start, end, err := lookupTimeRange(r, time.Time{}, time.Time{})
+	if err != nil {
+		writeLookupError(w, http.StatusBadRequest, err)
+		return
+	}
+	jd.runLookup(w, r, newPromQLLabelQuery(jd.DeploymentType, name, start, end))
+}
+
+func (jd *OracleDatasource) handleSeries(w http.ResponseWriter, r *http.Request) {
+	match := r.URL.Query().Get("match[]")
+	if match == "" {
+		writeLookupError(w, http.StatusBadRequest,
+			errors.New("match[] parameter is required"))
+		return
+	}
+	to := now()
+	start, end, err := lookupTimeRange(r, to.Add(-defaultLookupRange), to)
+	if err != nil {
+		writeLookupError(w, http.StatusBadRequest, err)
+		return
+	}
+	jd.runLookup(w, r, newPromQLSeriesQuery(jd.DeploymentType, match,
+		start, end))
+}
+
+// lookupTimeRange returns the start and end parameters of r in epoch seconds,
+// defStart and defEnd are used for the missing ones. A zero default is passed
+// as 0.
+func lookupTimeRange(r *http.Request, defStart time.Time, defEnd time.Time) (
+	int64, int64, error) {
+	start, err := parseLookupTime(r.URL.Query().Get("start"), defStart)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseLookupTime(r.URL.Query().Get("end"), defEnd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end: %w", err)
+	}
+	if start > end {
+		return 0, 0, errors.New("end timestamp must not be before start time")
+	}
+	return start, end, nil
+}
+
+// parseLookupTime parses a time given in epoch seconds or as RFC 3339.
+func parseLookupTime(value string, def time.Time) (int64, error) {
+	if value == "" {
+		if def.IsZero() {
+			return 0, nil
+		}
+		return def.Unix(), nil
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil &&
+		!math.IsNaN(secs) && !math.IsInf(secs, 0) {
+		return int64(secs), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q to a valid timestamp", value)
+	}
+	return t.Unix(), nil
+}
+
+// runLookup runs the telemetry query API call and writes the JSON document it
+// returns.
+func (jd *OracleDatasource) runLookup(w http.ResponseWriter, r *http.Request,
+	lookup telemetryQuery) {
+	queryText := lookup.String()
+	ctx := r.Context()
+	dbConn, err := jd.getDBPool(ctx)
+	if err != nil {
+		writeLookupError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if jd.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, jd.QueryTimeout)
+		defer cancel()
+	}
+
+	timeBeforeQuery := time.Now()
+	logQueryInfo("Lookup "+r.URL.Path, "Before", queryText)
+	rows, err := lookup.run(ctx, dbConn)
+	if err != nil {
+		customLogger("error", "lookup query error", err)
+		writeLookupError(w, http.StatusInternalServerError,
+			queryTimeoutError(ctx, jd.QueryTimeout, err))
+		return
+	}
+	defer rows.Close()
+
+	rowsProcessed := 0
+	result := ""
+	for rows.Next() {
+		rowsProcessed++
+		if rowsProcessed > 1 {
+			continue
+		}
+		if err = rows.Scan(&result); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	timeAfterQuery := now()
+	if err != nil {
+		customLogger("error", "lookup fetch error", err)
+		writeLookupError(w, http.StatusInternalServerError,
+			queryTimeoutError(ctx, jd.QueryTimeout, err))
+		return
+	}
+	logQueryStatsInfo("Lookup "+r.URL.Path, "After", queryText, rowsProcessed,
+		timeBeforeQuery, timeAfterQuery)
+
+	if result == "" {
+		result = emptyLookupResult
+	}
+	if !json.Valid([]byte(result)) {
+		writeLookupError(w, http.StatusInternalServerError,
+			errors.New("telemetry query API returned an invalid JSON document"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(result)); err != nil {
+		customLogger("error", "error writing lookup response", err)
+	}
+}
+
+// writeLookupError writes err as an error of the Prometheus HTTP API.
+func writeLookupError(w http.ResponseWriter, status int, err error) {
+	errorType := "execution"
+	if status == http.StatusBadRequest {
+		errorType = "bad_data"
+	}
+	body, _ := json.Marshal(map[string]string{
+		"status":    "error",
+		"errorType": errorType,
+		"error":     redactError(err).Error(),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		customLogger("error", "error writing lookup response", err)
+	}
+}
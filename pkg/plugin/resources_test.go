@@ -0,0 +1,303 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	godror "github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// resourceSender records the response sent by CallResource.
+type resourceSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *resourceSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
+
+// callResource sends a GET of path to the datasource backed by db.
+func callResource(t *testing.T, ds *OracleDatasource, db *sql.DB, path string,
+	rawQuery string) *backend.CallResourceResponse {
+	t.Helper()
+	orig := dbConnector
+	dbConnector = func(context.Context, godror.ConnectionParams) (*sql.DB, error) { return db, nil }
+	defer func() { dbConnector = orig }()
+
+	url := path
+	if rawQuery != "" {
+		url += "?" + rawQuery
+	}
+	sender := &resourceSender{}
+	err := ds.CallResource(context.Background(), &backend.CallResourceRequest{
+		Method: http.MethodGet,
+		Path:   path,
+		URL:    url,
+	}, sender)
+	if err != nil {
+		t.Fatalf("CallResource(%s): %v", url, err)
+	}
+	if sender.resp == nil {
+		t.Fatalf("CallResource(%s): no response sent", url)
+	}
+	return sender.resp
+}
+
+func TestCallResource_Lookups(t *testing.T) {
+	origNow := now
+	now = func() time.Time { return time.Unix(1700003600, 0) }
+	defer func() { now = origNow }()
+
+	labelSQL := regexp.QuoteMeta(getConstants("query_label_str", "ONPREM"))
+	seriesSQL := regexp.QuoteMeta(getConstants("query_series_str", "ONPREM"))
+
+	tests := []struct {
+		name     string
+		path     string
+		rawQuery string
+		sql      string
+		args     []driver.Value
+	}{
+		{
+			name:     "metric names",
+			path:     "metrics",
+			rawQuery: "start=1700000000&end=1700003600",
+			sql:      labelSQL,
+			args: []driver.Value{sql.Named("label_name", "__name__"),
+				sql.Named("start_ts", int64(1700000000)),
+				sql.Named("end_ts", int64(1700003600))},
+		},
+		{
+			name: "metric names default to the last hour",
+			path: "metrics",
+			sql:  labelSQL,
+			args: []driver.Value{sql.Named("label_name", "__name__"),
+				sql.Named("start_ts", int64(1700000000)),
+				sql.Named("end_ts", int64(1700003600))},
+		},
+		{
+			name: "label names",
+			path: "labels",
+			sql:  labelSQL,
+			args: []driver.Value{sql.Named("label_name", " "),
+				sql.Named("start_ts", int64(0)),
+				sql.Named("end_ts", int64(0))},
+		},
+		{
+			name:     "label values with quotes",
+			path:     "label/job' or '1'='1/values",
+			rawQuery: "start=2023-11-14T22:13:20Z&end=1700003600.5",
+			sql:      labelSQL,
+			args: []driver.Value{sql.Named("label_name", `job' or '1'='1`),
+				sql.Named("start_ts", int64(1700000000)),
+				sql.Named("end_ts", int64(1700003600))},
+		},
+		{
+			name:     "series",
+			path:     "series",
+			rawQuery: "match%5B%5D=cpu_usage%7Bjob%3D%22node%22%7D&start=1768542262&end=1768542462",
+			sql:      seriesSQL,
+			args: []driver.Value{sql.Named("match", `cpu_usage{job="node"}`),
+				sql.Named("start_ts", int64(1768542262)),
+				sql.Named("end_ts", int64(1768542462))},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+
+			result := `{"status":"success","data":["cpu","memory"]}`
+			mock.ExpectQuery(tc.sql).WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"RESULT"}).AddRow(result))
+
+			ds := makeTestDS()
+			ds.DeploymentType = "ONPREM"
+			resp := callResource(t, ds, db, tc.path, tc.rawQuery)
+			if resp.Status != http.StatusOK {
+				t.Fatalf("status = %d, body %s", resp.Status, resp.Body)
+			}
+			if string(resp.Body) != result {
+				t.Fatalf("body = %s, want %s", resp.Body, result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("sqlmock expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCallResource_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		rawQuery   string
+		queryErr   error
+		rows       *sqlmock.Rows
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "unknown resource",
+			path:       "query",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "series without match",
+			path:       "series",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "match[] parameter is required",
+		},
+		{
+			name:       "invalid time",
+			path:       "metrics",
+			rawQuery:   "start=yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantError:  `invalid start: cannot parse "yesterday" to a valid timestamp`,
+		},
+		{
+			name:       "end before start",
+			path:       "labels",
+			rawQuery:   "start=200&end=100",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "end timestamp must not be before start time",
+		},
+		{
+			name:       "database error",
+			path:       "labels",
+			queryErr:   errors.New("ORA-00904: invalid identifier"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "ORA-00904: invalid identifier",
+		},
+		{
+			name:       "invalid document",
+			path:       "labels",
+			rows:       sqlmock.NewRows([]string{"RESULT"}).AddRow("not json"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "telemetry query API returned an invalid JSON document",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+			if tc.queryErr != nil {
+				mock.ExpectQuery(`promql_label`).WillReturnError(tc.queryErr)
+			} else if tc.rows != nil {
+				mock.ExpectQuery(`promql_label`).WillReturnRows(tc.rows)
+			}
+
+			resp := callResource(t, makeTestDS(), db, tc.path, tc.rawQuery)
+			if resp.Status != tc.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", resp.Status,
+					tc.wantStatus, resp.Body)
+			}
+			if tc.wantError == "" {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(resp.Body, &body); err != nil {
+				t.Fatalf("error body %s: %v", resp.Body, err)
+			}
+			if body["status"] != "error" || body["error"] != tc.wantError {
+				t.Fatalf("body = %s, want error %q", resp.Body, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestCallResource_EmptyResult(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(`promql_label`).
+		WillReturnRows(sqlmock.NewRows([]string{"RESULT"}))
+
+	resp := callResource(t, makeTestDS(), db, "labels", "")
+	if resp.Status != http.StatusOK || string(resp.Body) != emptyLookupResult {
+		t.Fatalf("response = %d %s", resp.Status, resp.Body)
+	}
+}
+
+// A panel query whose refId matches one of the former lookup pseudo-queries
+// is run as the query it is.
+func TestQuery_LookupRefIDNotHijacked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	for _, refID := range []string{"fetchLabels", "metricFindQuery",
+		"getKeysForAdHocFilter", "getValueforKeyAdHocFilter"} {
+		mock.ExpectQuery(`promql_range`).WillReturnRows(promRangeRows(refID))
+		resp := query(context.Background(), backend.DataQuery{
+			RefID:     refID,
+			JSON:      json.RawMessage(`{"refId":"` + refID + `","exprProm":"up"}`),
+			TimeRange: testTimeRange,
+		}, db, queryConfig{DeploymentType: "ONPREM"})
+		if resp.Error != nil {
+			t.Fatalf("query %s: %v", refID, resp.Error)
+		}
+		found := false
+		for _, frame := range resp.Frames {
+			for _, field := range frame.Fields {
+				found = found || strings.Contains(field.Labels.String(), refID) ||
+					strings.Contains(field.Name, refID)
+			}
+		}
+		if !found {
+			t.Fatalf("query %s: frames do not hold the series", refID)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
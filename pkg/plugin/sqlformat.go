@@ -0,0 +1,271 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// metricColumns are the columns of a SQL result returned in the shape of a
+// PromQL result, one series per METRIC_NAME and METRIC_TAGS.
+var metricColumns = []string{"METRIC_TIME_EPOCH", "METRIC_VALUE",
+	"METRIC_NAME", "METRIC_TAGS"}
+
+// sqlColumnRoles sorts the columns of a SQL result by the part they can play
+// in a time series or in log lines.
+type sqlColumnRoles struct {
+	metric []string // metricColumns found
+	time   []string
+	value  []string
+	label  []string
+	text   []string // text, LOB and binary columns
+	line   []string // character, text and LOB columns, see isLogs
+}
+
+func newSQLColumnRoles(cols []string, types []*sql.ColumnType) sqlColumnRoles {
+	var roles sqlColumnRoles
+	for i, coltype := range types {
+		dataType := coltype.DatabaseTypeName()
+		for _, name := range metricColumns {
+			if cols[i] == name {
+				roles.metric = append(roles.metric, name)
+			}
+		}
+		if isTimeColumn(dataType, cols[i]) {
+			roles.time = append(roles.time, cols[i])
+		} else if isNumberColumn(dataType) {
+			roles.value = append(roles.value, cols[i])
+		} else if isCharColumn(dataType) {
+			roles.label = append(roles.label, cols[i])
+		} else {
+			roles.text = append(roles.text, cols[i])
+		}
+		if oracleTypeKind(dataType).isLogLineKind() && !timeColumnNames[cols[i]] {
+			roles.line = append(roles.line, cols[i])
+		}
+	}
+	return roles
+}
+
+// hasMetricColumns reports whether all the metricColumns were found.
+func (r sqlColumnRoles) hasMetricColumns() bool {
+	return len(r.metric) == len(metricColumns)
+}
+
+// isTimeSeries reports whether the columns can be returned as time series,
+// either as metricColumns or as one time column, numeric values and
+// optional character columns identifying the series.
+func (r sqlColumnRoles) isTimeSeries() bool {
+	return r.hasMetricColumns() || (len(r.time) == 1 && len(r.value) >= 1)
+}
+
+// isLogs reports whether the columns can be returned as log lines, one time
+// column and a character, text or LOB column holding the line. BOOLEAN and
+// binary columns cannot hold it.
+func (r sqlColumnRoles) isLogs() bool {
+	return len(r.time) == 1 && len(r.line) >= 1
+}
+
+// String lists the columns found by role, for the errors of the formats
+// that cannot be built.
+func (r sqlColumnRoles) String() string {
+	list := func(cols []string) string {
+		if len(cols) == 0 {
+			return "none"
+		}
+		return strings.Join(cols, ", ")
+	}
+	return fmt.Sprintf("time columns: %s; numeric columns: %s; "+
+		"character columns: %s; other columns: %s",
+		list(r.time), list(r.value), list(r.label), list(r.text))
+}
+
+// sqlResultFormat resolves the format a SQL result is returned in. The auto
+// format returns time series when the columns allow it and a table
+// otherwise, time_series and logs fail with the columns found when the
+// result cannot be returned in that shape. The time series columns set in the
+// query editor replace the rules of isTimeSeries.
+func sqlResultFormat(rows *sql.Rows, format string, columns seriesColumns) (
+	string, error) {
+	if format == formatTable {
+		return format, nil
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", err
+	}
+	roles := newSQLColumnRoles(cols, types)
+	customLogger("debug", "sql result columns", roles.String())
+	if columns.isSet() && (format == formatAuto || format == formatTimeSeries) {
+		if _, err := columns.resolve(cols, types); err != nil {
+			if format == formatAuto {
+				return formatTable, nil
+			}
+			return "", err
+		}
+		return formatTimeSeries, nil
+	}
+	switch format {
+	case formatAuto:
+		if roles.isTimeSeries() {
+			return formatTimeSeries, nil
+		}
+		return formatTable, nil
+	case formatTimeSeries:
+		if !roles.isTimeSeries() {
+			return "", errors.New(getConstants("sqlerror_query_str", "") +
+				"\nThe query returned " + roles.String())
+		}
+	case formatLogs:
+		if !roles.isLogs() {
+			return "", errors.New(`Invalid SQL query.
+                    To show log lines, the query must return:
+                    • exactly one time column
+                    • at least one character column (the first one is the line)` +
+				"\nThe query returned " + roles.String())
+		}
+	}
+	return format, nil
+}
+
+// frameTypeLogLines is the data plane type of log frames, which the SDK in
+// use does not declare yet, and logLinesTypeVersion its version.
+const frameTypeLogLines data.FrameType = "log-lines"
+
+var logLinesTypeVersion = data.FrameTypeVersion{0, 0}
+
+// severityColumns are the names of the character columns, in upper case,
+// returned as the severity of log lines.
+var severityColumns = map[string]bool{"LEVEL": true, "SEVERITY": true}
+
+// logsFrames turns the table of a SQL result into a log lines frame: the
+// first time column is the timestamp, the first other character column the
+// body, a LEVEL or SEVERITY column the severity and the non NULL values of
+// the other columns the labels of the line. Rows without time are left out.
+func logsFrames(frames data.Frames) data.Frames {
+	logs := make(data.Frames, 0, len(frames))
+	for _, frame := range frames {
+		timeIdx, bodyIdx, severityIdx := -1, -1, -1
+		for i, field := range frame.Fields {
+			switch field.Type().NonNullableType() {
+			case data.FieldTypeTime:
+				if timeIdx < 0 {
+					timeIdx = i
+				}
+			case data.FieldTypeString:
+				if severityColumns[strings.ToUpper(field.Name)] && severityIdx < 0 {
+					severityIdx = i
+				} else if bodyIdx < 0 {
+					bodyIdx = i
+				}
+			}
+		}
+		if timeIdx < 0 || bodyIdx < 0 {
+			logs = append(logs, setFrameType(data.Frames{frame}, data.FrameTypeTable)...)
+			continue
+		}
+
+		timestamps := []time.Time{}
+		bodies := []string{}
+		severities := []string{}
+		labels := []json.RawMessage{}
+		for row := 0; row < frame.Rows(); row++ {
+			ts, ok := frame.Fields[timeIdx].ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			timestamps = append(timestamps, ts.(time.Time))
+			body, _ := frame.Fields[bodyIdx].ConcreteAt(row)
+			bodies = append(bodies, fmt.Sprint(valueOrEmpty(body)))
+			if severityIdx >= 0 {
+				severity, _ := frame.Fields[severityIdx].ConcreteAt(row)
+				severities = append(severities, fmt.Sprint(valueOrEmpty(severity)))
+			}
+			rowLabels := map[string]string{}
+			for i, field := range frame.Fields {
+				if i == timeIdx || i == bodyIdx || i == severityIdx {
+					continue
+				}
+				if value, ok := field.ConcreteAt(row); ok {
+					rowLabels[field.Name] = labelValue(value)
+				}
+			}
+			raw, _ := json.Marshal(rowLabels)
+			labels = append(labels, raw)
+		}
+
+		fields := []*data.Field{
+			data.NewField("timestamp", nil, timestamps),
+			data.NewField("body", nil, bodies),
+		}
+		if severityIdx >= 0 {
+			fields = append(fields, data.NewField("severity", nil, severities))
+		}
+		fields = append(fields, data.NewField("labels", nil, labels))
+		logFrame := data.NewFrame(frame.Name, fields...)
+		logFrame.Meta = &data.FrameMeta{}
+		if frame.Meta != nil {
+			*logFrame.Meta = *frame.Meta
+		}
+		logFrame.Meta.Type = frameTypeLogLines
+		logFrame.Meta.TypeVersion = logLinesTypeVersion
+		logFrame.Meta.PreferredVisualization = data.VisTypeLogs
+		logs = append(logs, logFrame)
+	}
+	return logs
+}
+
+// valueOrEmpty returns value, or the empty string for NULL.
+func valueOrEmpty(value interface{}) interface{} {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// labelValue formats the value of a column as the label of a log line.
+func labelValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
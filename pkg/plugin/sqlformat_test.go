@@ -0,0 +1,217 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestGetDataFrameFromRows_Formats(t *testing.T) {
+	seriesRows := func() *sqlmock.Rows {
+		return sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).AddRow("1700000000", "a", "1")
+	}
+	logRows := func() *sqlmock.Rows {
+		return sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("ID").OfType("NUMBER", ""),
+			sqlmock.NewColumn("MESSAGE").OfType("CLOB", ""),
+			sqlmock.NewColumn("LOGGED_AT").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("LEVEL").OfType("VARCHAR2", ""),
+		).AddRow("1", "started", time.Unix(1700000000, 0), "info")
+	}
+
+	t.Run("auto time series", func(t *testing.T) {
+		frames := sqlFrames(t, seriesRows(), formatAuto, frameOptions{})
+		if frames[0].Meta.Type != data.FrameTypeTimeSeriesWide {
+			t.Fatalf("type = %s, want time series", frames[0].Meta.Type)
+		}
+	})
+
+	t.Run("time series without labels", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("CPU").OfType("NUMBER", ""),
+			sqlmock.NewColumn("MEM").OfType("NUMBER", ""),
+		).
+			AddRow("1700000060", "2", "20").
+			AddRow("1700000000", "1", "10")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{})
+		if len(frames) != 1 || frames[0].Meta.Type != data.FrameTypeTimeSeriesWide {
+			t.Fatalf("expected one wide frame, got %v", frames)
+		}
+		if got := fieldText(frames[0].Fields[1]) + "," + fieldText(frames[0].Fields[2]); got != "1 2,10 20" {
+			t.Fatalf("values = %s, want 1 2,10 20 in time order", got)
+		}
+	})
+
+	t.Run("time series labels", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).
+			AddRow("1700000000", "a", "1").
+			AddRow("1700000000", "b", "2").
+			AddRow("1700000060", "a", "3")
+		frames := sqlFrames(t, rows, formatTimeSeries, frameOptions{})
+		if len(frames) != 1 || frames[0].Fields[0].Len() != 2 {
+			t.Fatalf("expected one wide frame with 2 times, got %v", frames)
+		}
+		want := map[string]string{"HOST=a": "1 3", "HOST=b": "2 null"}
+		fields := seriesByLabels(frames)
+		if len(fields) != len(want) {
+			t.Fatalf("series = %v, want %v", fields, want)
+		}
+		for labels, field := range fields {
+			if got := fieldText(field); got != want[labels] {
+				t.Fatalf("values of %s = %s, want %s", labels, got, want[labels])
+			}
+		}
+	})
+
+	t.Run("auto table", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).AddRow("a", "1")
+		frames := sqlFrames(t, rows, formatAuto, frameOptions{})
+		if len(frames) != 1 || frames[0].Meta.Type != data.FrameTypeTable ||
+			len(frames[0].Fields) != 2 {
+			t.Fatalf("expected one table frame, got %v", frames)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		frames := sqlFrames(t, seriesRows(), formatTable, frameOptions{})
+		if frames[0].Meta.Type != data.FrameTypeTimeSeriesLong ||
+			len(frames[0].Fields) != 3 {
+			t.Fatalf("expected the table as a long time series, got %v", frames)
+		}
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		frame := sqlFrames(t, logRows(), formatLogs, frameOptions{})[0]
+		if frame.Meta.Type != frameTypeLogLines ||
+			frame.Meta.PreferredVisualization != data.VisTypeLogs {
+			t.Fatalf("meta = %+v, want log lines", frame.Meta)
+		}
+		var names []string
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		if got := strings.Join(names, ","); got != "timestamp,body,severity,labels" {
+			t.Fatalf("fields = %s, want timestamp,body,severity,labels", got)
+		}
+		row := fmt.Sprintf("%d %s %s %s", frame.Fields[0].At(0).(time.Time).Unix(),
+			frame.Fields[1].At(0), frame.Fields[2].At(0),
+			string(frame.Fields[3].At(0).(json.RawMessage)))
+		if want := `1700000000 started info {"ID":"1"}`; row != want {
+			t.Fatalf("row = %s, want %s", row, want)
+		}
+	})
+}
+
+func TestGetDataFrameFromRows_FormatErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		rows   *sqlmock.Rows
+		want   []string
+	}{
+		{
+			name:   "time series without numbers",
+			format: formatTimeSeries,
+			rows: sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+				sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+				sqlmock.NewColumn("DOC").OfType("CLOB", ""),
+			).AddRow("1700000000", "a", "x"),
+			want: []string{"Invalid SQL query", "time columns: TIME",
+				"numeric columns: none", "character columns: HOST",
+				"other columns: DOC"},
+		},
+		{
+			name:   "logs without time",
+			format: formatLogs,
+			rows: sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("MESSAGE").OfType("VARCHAR2", ""),
+			).AddRow("started"),
+			want: []string{"To show log lines", "time columns: none",
+				"character columns: MESSAGE"},
+		},
+		{
+			name:   "logs without line",
+			format: formatLogs,
+			rows: sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+				sqlmock.NewColumn("OK").OfType("BOOLEAN", ""),
+				sqlmock.NewColumn("PAYLOAD").OfType("BLOB", ""),
+			).AddRow("1700000000", true, []byte{1}),
+			want: []string{"To show log lines", "character columns: OK",
+				"other columns: PAYLOAD"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+			mock.ExpectQuery("SELECT").WillReturnRows(tc.rows)
+			sqlRows, err := db.Query("SELECT")
+			if err != nil {
+				t.Fatalf("db.Query: %v", err)
+			}
+			defer sqlRows.Close()
+
+			var processed int
+			var after time.Time
+			_, _, err = getDataFrameFromRows(sqlRows, false, tc.format, "SELECT",
+				"", "query", frameOptions{}, &processed, &after)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
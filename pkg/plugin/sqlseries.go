@@ -0,0 +1,488 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// units of the time column of a SQL time series, epoch numbers in seconds,
+// milliseconds or nanoseconds, or DATE and TIMESTAMP values
+const (
+	timeUnitSeconds   = "s"
+	timeUnitMillis    = "ms"
+	timeUnitNanos     = "ns"
+	timeUnitDate      = "date"
+	timeUnitTimestamp = "timestamp"
+)
+
+// seriesColumns names the columns the time series of a SQL result are built
+// from, as set in the query editor. The columns not set are found by type and
+// name, see resolve.
+type seriesColumns struct {
+	Time     string
+	TimeUnit string
+	Values   []string
+	// the value of the metric column names the series
+	Metric string
+	Labels []string
+	// column holding the labels of the series as a JSON object
+	LabelsJSON string
+}
+
+// isSet reports whether any of the columns is set, otherwise the time series
+// are built by the conversions of getDataFrameFromRows.
+func (c seriesColumns) isSet() bool {
+	return c.Time != "" || c.TimeUnit != "" || len(c.Values) > 0 ||
+		c.Metric != "" || len(c.Labels) > 0 || c.LabelsJSON != ""
+}
+
+// seriesLayout holds the indexes of the columns of a SQL result the time
+// series are built from, -1 when there is no such column.
+type seriesLayout struct {
+	cols       []string
+	kinds      []sqlValueKind
+	time       int
+	timeUnit   string
+	values     []int
+	metric     int
+	labels     []int
+	labelsJSON int
+}
+
+// findColumn returns the index of the column name, compared without case
+// when no column has the exact name since Oracle returns unquoted
+// identifiers in upper case.
+func findColumn(cols []string, name string) int {
+	for i, col := range cols {
+		if col == name {
+			return i
+		}
+	}
+	for i, col := range cols {
+		if strings.EqualFold(col, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolve finds the columns in the columns of a result. When not set the time
+// column is the only time column, the metric and JSON labels columns are
+// METRIC_NAME and METRIC_TAGS when returned, the values are the numeric
+// columns and the labels the character columns left.
+func (c seriesColumns) resolve(cols []string, types []*sql.ColumnType) (
+	*seriesLayout, error) {
+	roles := newSQLColumnRoles(cols, types)
+	layout := &seriesLayout{
+		cols:       cols,
+		kinds:      make([]sqlValueKind, len(cols)),
+		time:       -1,
+		timeUnit:   c.TimeUnit,
+		metric:     -1,
+		labelsJSON: -1,
+	}
+	for i, coltype := range types {
+		layout.kinds[i] = oracleTypeKind(coltype.DatabaseTypeName())
+	}
+	notFound := func(setting, name string) error {
+		return fmt.Errorf("%s column %s not found, the query returned %s",
+			setting, name, roles)
+	}
+	used := make([]bool, len(cols))
+	find := func(setting, name string) (int, error) {
+		idx := findColumn(cols, name)
+		if idx < 0 {
+			return -1, notFound(setting, name)
+		}
+		used[idx] = true
+		return idx, nil
+	}
+
+	var err error
+	if c.Time != "" {
+		if layout.time, err = find("time", c.Time); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, coltype := range types {
+			if isTimeColumn(coltype.DatabaseTypeName(), cols[i]) {
+				if layout.time >= 0 {
+					return nil, fmt.Errorf("more than one time column, set "+
+						"the time column, the query returned %s", roles)
+				}
+				layout.time = i
+			}
+		}
+		if layout.time < 0 {
+			return nil, fmt.Errorf("no time column, set the time column, "+
+				"the query returned %s", roles)
+		}
+		used[layout.time] = true
+	}
+	if layout.kinds[layout.time].isSeriesValueKind() && layout.timeUnit == "" {
+		layout.timeUnit = timeUnitSeconds
+	}
+
+	metric, labelsJSON := c.Metric, c.LabelsJSON
+	if metric == "" && findColumn(cols, "METRIC_NAME") >= 0 {
+		metric = "METRIC_NAME"
+	}
+	if labelsJSON == "" && len(c.Labels) == 0 &&
+		findColumn(cols, "METRIC_TAGS") >= 0 {
+		labelsJSON = "METRIC_TAGS"
+	}
+	if metric != "" {
+		if layout.metric, err = find("metric", metric); err != nil {
+			return nil, err
+		}
+	}
+	if labelsJSON != "" {
+		if layout.labelsJSON, err = find("JSON labels", labelsJSON); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range c.Values {
+		idx, err := find("value", name)
+		if err != nil {
+			return nil, err
+		}
+		layout.values = append(layout.values, idx)
+	}
+	for _, name := range c.Labels {
+		idx, err := find("label", name)
+		if err != nil {
+			return nil, err
+		}
+		layout.labels = append(layout.labels, idx)
+	}
+	for i, kind := range layout.kinds {
+		if used[i] {
+			continue
+		}
+		if len(c.Values) == 0 && kind.isSeriesValueKind() {
+			layout.values = append(layout.values, i)
+		} else if len(c.Labels) == 0 && kind.isSeriesLabelKind() {
+			layout.labels = append(layout.labels, i)
+		}
+	}
+	if len(layout.values) == 0 {
+		return nil, fmt.Errorf("no value column, set the value columns, "+
+			"the query returned %s", roles)
+	}
+	return layout, nil
+}
+
+// rowTime returns the time of a row read in the unit of the time column.
+func (l *seriesLayout) rowTime(cell *sqlCell, opts frameOptions) (
+	*time.Time, error) {
+	var scale time.Duration
+	switch l.timeUnit {
+	case timeUnitMillis:
+		scale = time.Millisecond
+	case timeUnitNanos:
+		scale = time.Nanosecond
+	default:
+		return cell.time(opts)
+	}
+	if cell.native {
+		return cell.epochTime(scale, opts), nil
+	}
+	raw := cell.nullString()
+	if !raw.Valid {
+		return sqlTime(raw, opts)
+	}
+	var ts time.Time
+	if n, err := strconv.ParseInt(raw.String, 10, 64); err == nil {
+		ts = time.Unix(0, n*int64(scale))
+	} else if f, err := strconv.ParseFloat(raw.String, 64); err == nil {
+		ts = time.Unix(0, int64(math.Round(f*float64(scale))))
+	} else {
+		return nil, fmt.Errorf("invalid epoch time '%s' in column %s",
+			raw.String, l.cols[l.time])
+	}
+	return &ts, nil
+}
+
+// sqlSeriesFrames returns the rows of a SQL result as one frame per series,
+// the series are built from the columns set in the query editor. A series is
+// the values of one value column for one metric name and set of labels.
+func sqlSeriesFrames(rows *sql.Rows, columns seriesColumns,
+	legendTextVal string, opts frameOptions, rowsProcessed *int,
+	timeAfterQuery *time.Time) (data.Frames, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	layout, err := columns.resolve(cols, types)
+	if err != nil {
+		return nil, err
+	}
+	*timeAfterQuery = now()
+
+	//time or value is nil for NULL unless NULL values are zero filled
+	type series struct {
+		name   string
+		metric string
+		column string // value column
+		labels data.Labels
+		times  []*time.Time
+		values []*float64
+	}
+	seriesMap := make(map[string]*series)
+	cells, dest := newSQLCells(layout.kinds, types)
+	rowsTotal := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			customLogger("error", "scan row error", err)
+			return nil, err
+		}
+		rowsTotal++
+		ts, err := layout.rowTime(&cells[layout.time], opts)
+		if err != nil {
+			return nil, err
+		}
+
+		//NULL label values are left out of the labels
+		labels := data.Labels{}
+		if layout.labelsJSON >= 0 {
+			tags, err := parseMetricTags(cells[layout.labelsJSON].nullString().String)
+			if err != nil {
+				customLogger("debug", "failed to unmarshal labels json", err)
+			}
+			for k, v := range tags {
+				labels[k] = v
+			}
+		}
+		for _, i := range layout.labels {
+			if cells[i].src != nil {
+				labels[cols[i]] = cells[i].nullString().String
+			}
+		}
+		metricName := ""
+		if layout.metric >= 0 {
+			metricName = cells[layout.metric].nullString().String
+		}
+
+		for _, i := range layout.values {
+			value, err := cells[i].float(opts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid numeric value '%s' in column %s: %w",
+					cells[i].nullString().String, cols[i], err)
+			}
+			name := metricName
+			if name == "" {
+				name = cols[i]
+			} else if len(layout.values) > 1 {
+				name += " " + cols[i]
+			}
+			key := name + seriesKeySep + labels.String()
+			s, ok := seriesMap[key]
+			if !ok {
+				s = &series{name: name, metric: metricName, column: cols[i],
+					labels: labels}
+				seriesMap[key] = s
+			}
+			s.times = append(s.times, ts)
+			s.values = append(s.values, value)
+		}
+	}
+	*rowsProcessed = rowsTotal
+
+	frames := data.Frames{}
+	legend := newLegend(legendTextVal)
+	for _, s := range seriesMap {
+		idx := make([]int, len(s.times))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(i, j int) bool {
+			return sqlTimeBefore(s.times[idx[i]], s.times[idx[j]])
+		})
+
+		dName := s.name
+		if len(s.labels) > 0 {
+			dName += "{" + s.labels.String() + "}"
+		}
+		if legendTextVal != "" {
+			name, columns := s.column, map[string]string{}
+			if layout.metric >= 0 {
+				name = s.metric
+				columns[cols[layout.metric]] = s.metric
+			}
+			dName = legend.execute(legendLabels(s.labels, name, columns))
+		}
+		frame := data.NewFrame("response",
+			newSQLField(cols[layout.time], nil, sqlTimeKind, opts),
+			newSQLField(s.name, s.labels, sqlNumberKind, opts).SetConfig(
+				&data.FieldConfig{DisplayNameFromDS: dName}),
+		)
+		for _, i := range idx {
+			frame.AppendRow(sqlFieldValue(s.times[i], opts),
+				sqlFieldValue(s.values[i], opts))
+		}
+		frames = append(frames, frame)
+	}
+	sortSeriesFrames(frames)
+	return setFrameType(frames, data.FrameTypeTimeSeriesMulti), nil
+}
+
+// sqlWideFrames returns a SQL result of one time column, numeric columns and
+// optional character columns as a single wide time series frame. The rows
+// are first collected in a long frame, sorted by time, which is converted
+// with data.LongToWide when character columns identify the series: each
+// numeric column then gives a field per combination of their values, as
+// labels. Rows without time cannot be placed on the time axis and are left
+// out, other columns are ignored.
+func sqlWideFrames(rows *sql.Rows, legendTextVal string, opts frameOptions,
+	rowsProcessed *int, timeAfterQuery *time.Time) (data.Frames, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	*timeAfterQuery = now()
+
+	timeIdx := -1
+	var values, labels []int
+	kinds := make([]sqlValueKind, len(types))
+	for i, coltype := range types {
+		kinds[i] = sqlColumnKind(coltype)
+		switch {
+		case isTimeColumn(coltype.DatabaseTypeName(), cols[i]):
+			kinds[i] = sqlTimeKind
+			if timeIdx < 0 {
+				timeIdx = i
+			}
+		case kinds[i].isSeriesValueKind():
+			values = append(values, i)
+		case kinds[i].isSeriesLabelKind():
+			labels = append(labels, i)
+		}
+	}
+	if timeIdx < 0 || len(values) == 0 {
+		return nil, fmt.Errorf("%s\nThe query returned %s",
+			getConstants("sqlerror_query_str", ""),
+			newSQLColumnRoles(cols, types))
+	}
+
+	type longRow struct {
+		time   *time.Time
+		values []interface{}
+	}
+	var longRows []longRow
+	cells, dest := newSQLCells(kinds, types)
+	rowsTotal := 0
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			customLogger("error", "scan row error", err)
+			return nil, err
+		}
+		rowsTotal++
+		ts, err := cells[timeIdx].time(opts)
+		if err != nil {
+			return nil, err
+		}
+		if ts == nil {
+			continue
+		}
+		row := longRow{time: ts, values: []interface{}{*ts}}
+		for _, i := range values {
+			value, err := cells[i].float(opts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid numeric value '%s' in column %s: %w",
+					cells[i].nullString().String, cols[i], err)
+			}
+			row.values = append(row.values, sqlFieldValue(value, opts))
+		}
+		for _, i := range labels {
+			//NULL label values are empty, see below
+			value := cells[i].nullString().String
+			row.values = append(row.values, value)
+		}
+		longRows = append(longRows, row)
+	}
+	*rowsProcessed = rowsTotal
+	sort.SliceStable(longRows, func(i, j int) bool {
+		return longRows[i].time.Before(*longRows[j].time)
+	})
+
+	frame := data.NewFrame("response",
+		data.NewField(cols[timeIdx], nil, []time.Time{}))
+	for _, i := range values {
+		frame.Fields = append(frame.Fields,
+			newSQLField(cols[i], nil, sqlNumberKind, opts))
+	}
+	for _, i := range labels {
+		frame.Fields = append(frame.Fields,
+			data.NewField(cols[i], nil, []string{}))
+	}
+	for _, row := range longRows {
+		frame.AppendRow(row.values...)
+	}
+	if len(labels) > 0 {
+		if frame, err = data.LongToWide(frame, nil); err != nil {
+			return nil, err
+		}
+		//NULL labels are left out of the series labels
+		for _, field := range frame.Fields[1:] {
+			for name, value := range field.Labels {
+				if value == "" {
+					delete(field.Labels, name)
+				}
+			}
+		}
+	}
+
+	//without legend Grafana names the series after the field and its labels,
+	//like the series of PromQL queries
+	if legendTextVal != "" {
+		legend := newLegend(legendTextVal)
+		for _, field := range frame.Fields[1:] {
+			dName := legend.execute(legendLabels(field.Labels, field.Name, nil))
+			field.SetConfig(&data.FieldConfig{DisplayNameFromDS: dName})
+		}
+	}
+	frames := opts.Fill.fillFrames(data.Frames{frame})
+	return setFrameType(frames, data.FrameTypeTimeSeriesWide), nil
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// sqlSeriesRows converts rows of a mocked SQL query in the time series format
+// with the columns set.
+func sqlSeriesRows(t *testing.T, rows *sqlmock.Rows, columns seriesColumns,
+	legend string) (data.Frames, error) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	sqlRows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("db.Query: %v", err)
+	}
+	defer sqlRows.Close()
+
+	var processed int
+	var after time.Time
+	frames, _, err := getDataFrameFromRows(sqlRows, false, formatTimeSeries,
+		"SELECT", legend, "query", frameOptions{Columns: columns}, &processed,
+		&after)
+	return frames, err
+}
+
+func TestGetDataFrameFromRows_SeriesColumns(t *testing.T) {
+	t.Run("epoch milliseconds and JSON labels", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("SAMPLE_TS").OfType("NUMBER", ""),
+			sqlmock.NewColumn("VAL").OfType("NUMBER", ""),
+			sqlmock.NewColumn("ATTRS").OfType("CLOB", ""),
+		).
+			AddRow("1700000001500", "2", `{"host":"b"}`).
+			AddRow("1700000000500", "1", `{"host":"b"}`).
+			AddRow("1700000000500", "3", `{"host":"a"}`)
+		frames, err := sqlSeriesRows(t, rows, seriesColumns{Time: "SAMPLE_TS",
+			TimeUnit: timeUnitMillis, Values: []string{"VAL"},
+			LabelsJSON: "ATTRS"}, "")
+		if err != nil {
+			t.Fatalf("getDataFrameFromRows: %v", err)
+		}
+		if len(frames) != 2 {
+			t.Fatalf("got %d frames, want 2", len(frames))
+		}
+		b := frames[1]
+		if b.Fields[0].Name != "SAMPLE_TS" || b.Fields[1].Name != "VAL" ||
+			b.Fields[1].Labels["host"] != "b" || b.Rows() != 2 {
+			t.Fatalf("unexpected series: %v %v", b.Fields[1].Name,
+				b.Fields[1].Labels)
+		}
+		first, _ := b.Fields[0].ConcreteAt(0)
+		if !first.(time.Time).Equal(time.UnixMilli(1700000000500)) {
+			t.Fatalf("time = %v, want the earliest sample first", first)
+		}
+		if got := b.Fields[1].Config.DisplayNameFromDS; got != "VAL{host=b}" {
+			t.Fatalf("display name = %q", got)
+		}
+	})
+
+	t.Run("epoch NUMBER columns", func(t *testing.T) {
+		for _, tc := range []struct {
+			unit string
+			ts   godror.Number
+			want time.Time
+		}{
+			{timeUnitSeconds, "1700000123", time.Unix(1700000123, 0)},
+			{"", "1700000123.5", time.Unix(1700000123, 500000000)},
+			{timeUnitMillis, "1700000123456", time.UnixMilli(1700000123456)},
+			{timeUnitNanos, "1700000123000000000", time.Unix(1700000123, 0)},
+		} {
+			rows := sqlmock.NewRowsWithColumnDefinition(
+				sqlmock.NewColumn("SAMPLE_TS").OfType("NUMBER", godror.Number("")),
+				sqlmock.NewColumn("VAL").OfType("NUMBER", godror.Number("")),
+			).
+				AddRow(tc.ts, godror.Number("1"))
+			frames, err := sqlSeriesRows(t, rows, seriesColumns{Time: "SAMPLE_TS",
+				TimeUnit: tc.unit}, "")
+			if err != nil {
+				t.Fatalf("unit %q: getDataFrameFromRows: %v", tc.unit, err)
+			}
+			got := frames[0].Fields[0].At(0).(*time.Time)
+			if !got.Equal(tc.want) {
+				t.Fatalf("unit %q: time = %v, want %v", tc.unit, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("metric, label and value columns", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("ts").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("NAME").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("DC").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("RX").OfType("NUMBER", ""),
+			sqlmock.NewColumn("TX").OfType("NUMBER", ""),
+		).
+			AddRow(time.Unix(1700000000, 0), "net", "a", "x", "1", "2")
+		frames, err := sqlSeriesRows(t, rows, seriesColumns{Time: "TS",
+			Metric: "name", Labels: []string{"HOST"},
+			Values: []string{"RX", "TX"}}, "{{NAME}} {{HOST}}")
+		if err != nil {
+			t.Fatalf("getDataFrameFromRows: %v", err)
+		}
+		var names []string
+		for _, frame := range frames {
+			field := frame.Fields[1]
+			if _, ok := field.Labels["DC"]; ok || field.Labels["HOST"] != "a" {
+				t.Fatalf("labels = %v, want only the label columns", field.Labels)
+			}
+			names = append(names, field.Name+"="+field.Config.DisplayNameFromDS)
+		}
+		if got := strings.Join(names, ","); got != "net RX=net a,net TX=net a" {
+			t.Fatalf("series = %s", got)
+		}
+	})
+
+	t.Run("defaults from the column types", func(t *testing.T) {
+		rows := sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("COLLECTED").OfType("DATE", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("CPU").OfType("NUMBER", ""),
+		).
+			AddRow(time.Unix(1700000000, 0), nil, "1")
+		frames, err := sqlSeriesRows(t, rows,
+			seriesColumns{TimeUnit: timeUnitDate}, "")
+		if err != nil {
+			t.Fatalf("getDataFrameFromRows: %v", err)
+		}
+		if len(frames) != 1 || frames[0].Fields[1].Name != "CPU" ||
+			len(frames[0].Fields[1].Labels) != 0 {
+			t.Fatalf("unexpected frames: %v", frames)
+		}
+	})
+}
+
+func TestGetDataFrameFromRows_SeriesColumnErrors(t *testing.T) {
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("SAMPLE_TS").OfType("NUMBER", ""),
+			sqlmock.NewColumn("VAL").OfType("NUMBER", ""),
+		).AddRow("1700000000", "1")
+	}
+	tests := []struct {
+		name    string
+		columns seriesColumns
+		want    string
+	}{
+		{
+			name:    "missing column",
+			columns: seriesColumns{Time: "SAMPLE_TS", LabelsJSON: "ATTRS"},
+			want:    "JSON labels column ATTRS not found, the query returned time columns: none; numeric columns: SAMPLE_TS, VAL",
+		},
+		{
+			name:    "no time column",
+			columns: seriesColumns{Values: []string{"VAL"}},
+			want:    "no time column",
+		},
+		{
+			name:    "no value column",
+			columns: seriesColumns{Time: "SAMPLE_TS", Labels: []string{"VAL"}},
+			want:    "no value column",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sqlSeriesRows(t, rows(), tc.columns, "")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("auto format falls back to table", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock.New: %v", err)
+		}
+		defer db.Close()
+		mock.ExpectQuery("SELECT").WillReturnRows(rows())
+		sqlRows, err := db.Query("SELECT")
+		if err != nil {
+			t.Fatalf("db.Query: %v", err)
+		}
+		defer sqlRows.Close()
+		var processed int
+		var after time.Time
+		frames, _, err := getDataFrameFromRows(sqlRows, false, formatAuto,
+			"SELECT", "", "query",
+			frameOptions{Columns: seriesColumns{Time: "MISSING"}},
+			&processed, &after)
+		if err != nil || frames[0].Meta.Type != data.FrameTypeTable {
+			t.Fatalf("expected a table, got %v, %v", frames, err)
+		}
+	})
+}
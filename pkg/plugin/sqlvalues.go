@@ -0,0 +1,497 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// nullValuesZero is the nullValues setting returning NULL values of SQL
+// results as zero, the epoch for times, like releases before nullable fields
+const nullValuesZero = "zero"
+
+// sqlValueKind is the kind of field a column of a SQL result is converted to.
+type sqlValueKind int
+
+const (
+	//time.Time
+	sqlTimeKind sqlValueKind = iota
+	//float64
+	sqlNumberKind
+	//int64, NUMBER without decimals and integer types
+	sqlIntKind
+	//bool
+	sqlBoolKind
+	//float64 seconds of an interval
+	sqlDurationKind
+	//string of the character types, used as labels of time series
+	sqlStringKind
+	//string of the other types, ROWID, JSON, VECTOR...
+	sqlTextKind
+	//string of a LOB, truncated to maxLOBTextSize
+	sqlLOBKind
+	//hexadecimal string of binary values, truncated to maxLOBTextSize
+	sqlBinaryKind
+)
+
+// newSQLField returns the field holding the values of a column of a SQL
+// result. The field is nullable unless NULL values are zero filled.
+func newSQLField(name string, labels data.Labels, kind sqlValueKind,
+	opts frameOptions) *data.Field {
+	switch kind {
+	case sqlTimeKind:
+		if opts.ZeroFillNulls {
+			return data.NewField(name, labels, []time.Time{})
+		}
+		return data.NewField(name, labels, []*time.Time{})
+	case sqlNumberKind, sqlDurationKind:
+		if opts.ZeroFillNulls {
+			return data.NewField(name, labels, []float64{})
+		}
+		return data.NewField(name, labels, []*float64{})
+	case sqlIntKind:
+		if opts.ZeroFillNulls {
+			return data.NewField(name, labels, []int64{})
+		}
+		return data.NewField(name, labels, []*int64{})
+	case sqlBoolKind:
+		if opts.ZeroFillNulls {
+			return data.NewField(name, labels, []bool{})
+		}
+		return data.NewField(name, labels, []*bool{})
+	default:
+		if opts.ZeroFillNulls {
+			return data.NewField(name, labels, []string{})
+		}
+		return data.NewField(name, labels, []*string{})
+	}
+}
+
+// parseSQLTime parses a time scanned as text, either RFC3339 or seconds since
+// the epoch with an optional fractional part.
+func parseSQLTime(raw string) (time.Time, error) {
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err == nil {
+		return ts, nil
+	}
+	secs, nSecs, err := parseTime(raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if secs == -1 || nSecs == -1 {
+		return time.Time{}, errors.New("invalid time value " + raw)
+	}
+	return time.Unix(secs, nSecs), nil
+}
+
+// sqlTime converts a scanned time, NULL is returned as nil or as the epoch
+// when NULL values are zero filled.
+func sqlTime(raw sql.NullString, opts frameOptions) (*time.Time, error) {
+	if !raw.Valid {
+		if opts.ZeroFillNulls {
+			epoch := time.Unix(0, 0)
+			return &epoch, nil
+		}
+		return nil, nil
+	}
+	ts, err := parseSQLTime(raw.String)
+	if err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
+
+// sqlNumber converts a scanned number, NULL is returned as nil or as 0 when
+// NULL values are zero filled.
+func sqlNumber(raw sql.NullString, opts frameOptions) (*float64, error) {
+	if !raw.Valid {
+		if opts.ZeroFillNulls {
+			zero := float64(0)
+			return &zero, nil
+		}
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw.String, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// sqlSeriesValue converts a scanned value of a time series. Intervals are
+// returned in seconds, the other kinds like sqlNumber.
+func sqlSeriesValue(raw sql.NullString, kind sqlValueKind,
+	opts frameOptions) (*float64, error) {
+	if kind != sqlDurationKind || !raw.Valid {
+		return sqlNumber(raw, opts)
+	}
+	secs, err := parseOracleInterval(raw.String)
+	if err != nil {
+		return nil, err
+	}
+	return &secs, nil
+}
+
+// sqlString converts a scanned text, NULL is returned as nil or as the empty
+// string when NULL values are zero filled.
+func sqlString(raw sql.NullString, opts frameOptions) *string {
+	if !raw.Valid && !opts.ZeroFillNulls {
+		return nil
+	}
+	value := raw.String
+	return &value
+}
+
+// sqlValue converts a scanned value to a pointer to the element type of the
+// fields of kind. NULL is returned as a nil pointer, or as the zero value
+// when NULL values are zero filled.
+func sqlValue(raw sql.NullString, kind sqlValueKind,
+	opts frameOptions) (interface{}, error) {
+	switch kind {
+	case sqlTimeKind:
+		return sqlTime(raw, opts)
+	case sqlNumberKind, sqlDurationKind:
+		return sqlSeriesValue(raw, kind, opts)
+	case sqlIntKind:
+		if !raw.Valid && !opts.ZeroFillNulls {
+			return (*int64)(nil), nil
+		}
+		value := int64(0)
+		if raw.Valid {
+			var err error
+			value, err = strconv.ParseInt(raw.String, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &value, nil
+	case sqlBoolKind:
+		if !raw.Valid && !opts.ZeroFillNulls {
+			return (*bool)(nil), nil
+		}
+		value := false
+		if raw.Valid {
+			var err error
+			value, err = strconv.ParseBool(raw.String)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &value, nil
+	case sqlLOBKind:
+		value := sqlString(raw, opts)
+		if value != nil {
+			*value = capLOBText(*value)
+		}
+		return value, nil
+	case sqlBinaryKind:
+		value := sqlString(raw, opts)
+		if value != nil {
+			*value = capLOBText(hex.EncodeToString([]byte(*value)))
+		}
+		return value, nil
+	default:
+		return sqlString(raw, opts), nil
+	}
+}
+
+// sqlFieldValue returns a value converted by sqlValue in the element type of
+// the fields created by newSQLField.
+func sqlFieldValue(value interface{}, opts frameOptions) interface{} {
+	if !opts.ZeroFillNulls {
+		return value
+	}
+	switch v := value.(type) {
+	case *time.Time:
+		return *v
+	case *float64:
+		return *v
+	case *int64:
+		return *v
+	case *bool:
+		return *v
+	case *string:
+		return *v
+	}
+	return value
+}
+
+// widenIntField returns the values of an integer field in a number field,
+// for the integer columns holding a value outside of the int64 range.
+func widenIntField(field *data.Field, opts frameOptions) *data.Field {
+	wide := newSQLField(field.Name, field.Labels, sqlNumberKind, opts)
+	wide.Config = field.Config
+	for i := 0; i < field.Len(); i++ {
+		switch v := field.At(i).(type) {
+		case int64:
+			wide.Append(float64(v))
+		case *int64:
+			if v == nil {
+				wide.Append((*float64)(nil))
+				continue
+			}
+			value := float64(*v)
+			wide.Append(&value)
+		}
+	}
+	return wide
+}
+
+// sqlCell scans a value of a SQL result. Numbers are scanned into an int64
+// or a float64 when the scan type of their column is numeric, see scanDest.
+// The other values are kept in the native type returned by the driver,
+// time.Time, float64, int64, bool, time.Duration or godror.Number, so that
+// they are converted to the field type of kind without going through text.
+// Other values, text included, are converted like sql.NullString.
+type sqlCell struct {
+	kind sqlValueKind
+	src  interface{}
+	//set when the value was scanned into i64 or f64 instead of src
+	native bool
+	i64    sql.NullInt64
+	f64    sql.NullFloat64
+}
+
+var numberScanType = reflect.TypeOf(godror.Number(""))
+
+// isNumericScanType reports whether the driver returns the values of a
+// column of scan type t as a number or as a godror.Number.
+func isNumericScanType(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t == numberScanType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// scanDest returns the destination to pass to Rows.Scan for a column of
+// type col. Integer columns the driver returns as int64 are scanned into an
+// int64, number columns into a float64, the others into the cell itself.
+func (c *sqlCell) scanDest(col *sql.ColumnType) interface{} {
+	c.native = false
+	if col == nil || !isNumericScanType(col.ScanType()) {
+		return c
+	}
+	switch c.kind {
+	case sqlIntKind:
+		if col.ScanType() == numberScanType {
+			//converted from text, like the other values of the cell
+			return c
+		}
+		c.native = true
+		return &c.i64
+	case sqlNumberKind:
+		c.native = true
+		return &c.f64
+	}
+	return c
+}
+
+// newSQLCells returns the cells a row of columns of kinds is scanned into,
+// and the destination to pass to Rows.Scan. types are the column types of
+// the result, nil when the kinds do not come from them.
+func newSQLCells(kinds []sqlValueKind, types []*sql.ColumnType) (
+	[]sqlCell, []interface{}) {
+	cells := make([]sqlCell, len(kinds))
+	dest := make([]interface{}, len(kinds))
+	for i := range cells {
+		cells[i].kind = kinds[i]
+		var col *sql.ColumnType
+		if i < len(types) {
+			col = types[i]
+		}
+		dest[i] = cells[i].scanDest(col)
+	}
+	return cells, dest
+}
+
+// Scan implements sql.Scanner.
+func (c *sqlCell) Scan(src interface{}) error {
+	//the driver may reuse the buffer of a []byte once Scan returns
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+	c.src = src
+	return nil
+}
+
+// nullString returns the value as text, the fallback of every conversion.
+func (c *sqlCell) nullString() sql.NullString {
+	if c.native && c.kind == sqlIntKind {
+		return sql.NullString{String: strconv.FormatInt(c.i64.Int64, 10),
+			Valid: c.i64.Valid}
+	}
+	if c.native {
+		return sql.NullString{String: strconv.FormatFloat(c.f64.Float64, 'g', -1, 64),
+			Valid: c.f64.Valid}
+	}
+	switch v := c.src.(type) {
+	case nil:
+		return sql.NullString{}
+	case string:
+		return sql.NullString{String: v, Valid: true}
+	case time.Time:
+		return sql.NullString{String: v.Format(time.RFC3339Nano), Valid: true}
+	case time.Duration:
+		//nanoseconds, as parsed by parseOracleInterval
+		return sql.NullString{String: strconv.FormatInt(int64(v), 10), Valid: true}
+	case float64:
+		return sql.NullString{String: strconv.FormatFloat(v, 'g', -1, 64), Valid: true}
+	case float32:
+		return sql.NullString{String: strconv.FormatFloat(float64(v), 'g', -1, 32), Valid: true}
+	case int64:
+		return sql.NullString{String: strconv.FormatInt(v, 10), Valid: true}
+	case bool:
+		return sql.NullString{String: strconv.FormatBool(v), Valid: true}
+	case fmt.Stringer:
+		return sql.NullString{String: v.String(), Valid: true}
+	}
+	return sql.NullString{String: fmt.Sprint(c.src), Valid: true}
+}
+
+// time returns the value as time like sqlTime. Numbers are seconds since the
+// epoch.
+func (c *sqlCell) time(opts frameOptions) (*time.Time, error) {
+	if c.native {
+		return c.epochTime(time.Second, opts), nil
+	}
+	switch v := c.src.(type) {
+	case time.Time:
+		return &v, nil
+	case int64:
+		ts := time.Unix(v, 0)
+		return &ts, nil
+	case float64:
+		secs, frac := math.Modf(v)
+		ts := time.Unix(int64(secs), int64(math.Round(frac*1e9)))
+		return &ts, nil
+	}
+	return sqlTime(c.nullString(), opts)
+}
+
+// epochTime returns a number scanned into i64 or f64 as the time of that
+// many units since the epoch, NULL like sqlTime.
+func (c *sqlCell) epochTime(unit time.Duration, opts frameOptions) *time.Time {
+	var ts time.Time
+	switch {
+	case c.kind == sqlIntKind && c.i64.Valid:
+		ts = time.Unix(0, c.i64.Int64*int64(unit))
+	case c.kind != sqlIntKind && c.f64.Valid:
+		//whole units apart so that the fraction keeps its precision
+		whole, frac := math.Modf(c.f64.Float64)
+		ts = time.Unix(0, int64(whole)*int64(unit)+
+			int64(math.Round(frac*float64(unit))))
+	default:
+		null, _ := sqlTime(sql.NullString{}, opts)
+		return null
+	}
+	return &ts
+}
+
+// float returns the value as float64 like sqlSeriesValue.
+func (c *sqlCell) float(opts frameOptions) (*float64, error) {
+	if c.native {
+		valid, value := c.f64.Valid, c.f64.Float64
+		if c.kind == sqlIntKind {
+			valid, value = c.i64.Valid, float64(c.i64.Int64)
+		}
+		if !valid {
+			return sqlNumber(sql.NullString{}, opts)
+		}
+		return &value, nil
+	}
+	var value float64
+	switch v := c.src.(type) {
+	case float64:
+		value = v
+	case float32:
+		//through the shortest text of the float32 so that 0.1 stays 0.1
+		value, _ = strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	case int64:
+		value = float64(v)
+	case time.Duration:
+		value = v.Seconds()
+	case godror.Number:
+		return sqlSeriesValue(sql.NullString{String: string(v), Valid: true}, c.kind, opts)
+	default:
+		return sqlSeriesValue(c.nullString(), c.kind, opts)
+	}
+	return &value, nil
+}
+
+// value returns the value like sqlValue.
+func (c *sqlCell) value(opts frameOptions) (interface{}, error) {
+	switch c.kind {
+	case sqlTimeKind:
+		return c.time(opts)
+	case sqlNumberKind, sqlDurationKind:
+		return c.float(opts)
+	case sqlIntKind:
+		if c.native {
+			if !c.i64.Valid {
+				return sqlValue(sql.NullString{}, c.kind, opts)
+			}
+			value := c.i64.Int64
+			return &value, nil
+		}
+		if v, ok := c.src.(int64); ok {
+			return &v, nil
+		}
+	case sqlBoolKind:
+		if v, ok := c.src.(bool); ok {
+			return &v, nil
+		}
+	}
+	return sqlValue(c.nullString(), c.kind, opts)
+}
+
+// sqlTimeBefore orders the samples of a series by time, samples without time
+// come last.
+func sqlTimeBefore(t1, t2 *time.Time) bool {
+	if t1 == nil || t2 == nil {
+		return t1 != nil
+	}
+	return t1.Before(*t2)
+}
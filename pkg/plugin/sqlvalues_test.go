@@ -0,0 +1,297 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// sqlFrames converts rows of a mocked SQL query with getDataFrameFromRows.
+func sqlFrames(t *testing.T, rows *sqlmock.Rows, format string,
+	opts frameOptions) data.Frames {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	sqlRows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("db.Query: %v", err)
+	}
+	defer sqlRows.Close()
+
+	var processed int
+	var after time.Time
+	frames, _, err := getDataFrameFromRows(sqlRows, false, format,
+		"SELECT", "", "query", opts, &processed, &after)
+	if err != nil {
+		t.Fatalf("getDataFrameFromRows: %v", err)
+	}
+	return frames
+}
+
+// isNullAt reports whether the value at idx of a nullable field is null.
+func isNullAt(field *data.Field, idx int) bool {
+	_, ok := field.ConcreteAt(idx)
+	return field.Nullable() && !ok
+}
+
+func TestGetDataFrameFromRows_NullValues(t *testing.T) {
+	tableRows := func() *sqlmock.Rows {
+		return sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+		).
+			AddRow("1700000000", "1.5", "a").
+			AddRow(nil, nil, nil)
+	}
+	metricRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+			AddRow("1700000060", nil, "up", "").
+			AddRow("1700000000", "1", "up", "")
+	}
+	derivedRows := func() *sqlmock.Rows {
+		return sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("TIME").OfType("TIMESTAMP", ""),
+			sqlmock.NewColumn("HOST").OfType("VARCHAR2", ""),
+			sqlmock.NewColumn("VALUE").OfType("NUMBER", ""),
+		).
+			AddRow("1700000060", "a", nil).
+			AddRow("1700000000", "a", "1")
+	}
+
+	t.Run("table nullable", func(t *testing.T) {
+		frame := sqlFrames(t, tableRows(), formatTable, frameOptions{})[0]
+		if frame.Fields[0].Type() != data.FieldTypeNullableTime ||
+			frame.Fields[1].Type() != data.FieldTypeNullableFloat64 ||
+			frame.Fields[2].Type() != data.FieldTypeNullableString {
+			t.Fatalf("field types = %v %v %v", frame.Fields[0].Type(),
+				frame.Fields[1].Type(), frame.Fields[2].Type())
+		}
+		for i, field := range frame.Fields {
+			if !isNullAt(field, 1) {
+				t.Errorf("field %d: NULL not returned as null", i)
+			}
+		}
+		if *frame.Fields[1].At(0).(*float64) != 1.5 {
+			t.Fatalf("value = %v, want 1.5", *frame.Fields[1].At(0).(*float64))
+		}
+	})
+
+	t.Run("table zero filled", func(t *testing.T) {
+		frame := sqlFrames(t, tableRows(), formatTable, frameOptions{ZeroFillNulls: true})[0]
+		if !frame.Fields[0].At(1).(time.Time).Equal(time.Unix(0, 0)) ||
+			frame.Fields[1].At(1).(float64) != 0 || frame.Fields[2].At(1).(string) != "" {
+			t.Fatalf("NULL row = %v %v %v", frame.Fields[0].At(1),
+				frame.Fields[1].At(1), frame.Fields[2].At(1))
+		}
+	})
+
+	for _, tc := range []struct {
+		name string
+		rows func() *sqlmock.Rows
+	}{
+		{name: "metric columns", rows: metricRows},
+		{name: "derived labels", rows: derivedRows},
+	} {
+		t.Run(tc.name+" nullable", func(t *testing.T) {
+			frames := sqlFrames(t, tc.rows(), formatTimeSeries, frameOptions{})
+			if len(frames) != 1 {
+				t.Fatalf("frames = %d, want 1", len(frames))
+			}
+			times, values := frames[0].Fields[0], frames[0].Fields[1]
+			if values.Type() != data.FieldTypeNullableFloat64 || values.Len() != 2 {
+				t.Fatalf("value field = %v with %d values", values.Type(), values.Len())
+			}
+			first, _ := times.ConcreteAt(0)
+			if got := first.(time.Time).Unix(); got != 1700000000 {
+				t.Fatalf("first time = %d, want 1700000000", got)
+			}
+			if *values.At(0).(*float64) != 1 || !isNullAt(values, 1) {
+				t.Fatalf("values = %v, %v, want 1 and null", values.At(0), values.At(1))
+			}
+		})
+
+		t.Run(tc.name+" zero filled", func(t *testing.T) {
+			frames := sqlFrames(t, tc.rows(), formatTimeSeries, frameOptions{ZeroFillNulls: true})
+			values := frames[0].Fields[1]
+			if values.Type() != data.FieldTypeFloat64 || values.At(1).(float64) != 0 {
+				t.Fatalf("value field = %v, NULL returned as %v", values.Type(), values.At(1))
+			}
+		})
+	}
+
+	t.Run("null time sorted last", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"METRIC_TIME_EPOCH", "METRIC_VALUE", "METRIC_NAME", "METRIC_TAGS"}).
+			AddRow(nil, "2", "up", "").
+			AddRow("1700000000", "1", "up", "")
+		times := sqlFrames(t, rows, formatTimeSeries, frameOptions{})[0].Fields[0]
+		if isNullAt(times, 0) || !isNullAt(times, 1) {
+			t.Fatalf("times = %v, %v, want time then null", times.At(0), times.At(1))
+		}
+	})
+}
+
+func TestNewOracleDatasource_NullValues(t *testing.T) {
+	for setting, want := range map[string]bool{"": false, "null": false, "zero": true, " Zero ": true} {
+		raw, _ := json.Marshal(map[string]interface{}{"nullValues": setting})
+		instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{JSONData: raw})
+		if err != nil {
+			t.Fatalf("NewOracleDatasource: %v", err)
+		}
+		ds := instance.(*OracleDatasource)
+		if ds.ZeroFillNulls != want || ds.getQueryConfig().ZeroFillNulls != want {
+			t.Errorf("nullValues %q: ZeroFillNulls = %v, want %v", setting, ds.ZeroFillNulls, want)
+		}
+	}
+}
+
+func TestSQLCell_NativeValues(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name string
+		kind sqlValueKind
+		src  interface{}
+		want interface{}
+	}{
+		{"time keeps zone", sqlTimeKind, time.Date(2024, 1, 2, 3, 4, 5, 123456789, paris),
+			time.Date(2024, 1, 2, 3, 4, 5, 123456789, paris)},
+		{"epoch float", sqlTimeKind, 1700000000.25, time.Unix(1700000000, 250000000)},
+		{"godror number", sqlNumberKind, godror.Number("12.5"), 12.5},
+		{"binary double", sqlNumberKind, 0.1, 0.1},
+		{"binary float", sqlNumberKind, float32(0.1), 0.1},
+		{"int keeps precision", sqlIntKind, int64(9007199254740993), int64(9007199254740993)},
+		{"int from number", sqlIntKind, godror.Number("9007199254740993"), int64(9007199254740993)},
+		{"interval", sqlDurationKind, 90 * time.Second, 90.0},
+		{"bool", sqlBoolKind, true, true},
+		{"raw bytes", sqlBinaryKind, []byte{0x01, 0xab}, "01ab"},
+		{"text", sqlStringKind, []byte("abc"), "abc"},
+		{"null", sqlNumberKind, nil, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cell := sqlCell{kind: tc.kind}
+			if err := cell.Scan(tc.src); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			value, err := cell.value(frameOptions{})
+			if err != nil {
+				t.Fatalf("value: %v", err)
+			}
+			field := newSQLField("f", nil, tc.kind, frameOptions{})
+			field.Append(value)
+			got, ok := field.ConcreteAt(0)
+			switch want := tc.want.(type) {
+			case nil:
+				if ok {
+					t.Fatalf("value = %v, want null", got)
+				}
+			case time.Time:
+				ts := got.(time.Time)
+				if !ts.Equal(want) || ts.Location().String() != want.Location().String() {
+					t.Fatalf("value = %v, want %v", ts, want)
+				}
+			default:
+				if got != want {
+					t.Fatalf("value = %v (%T), want %v (%T)", got, got, want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDataFrameFromRows_NativeTime(t *testing.T) {
+	zone := time.FixedZone("UTC+5", 5*3600)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, zone)
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("T").OfType("TIMESTAMP WITH TIME ZONE", ""),
+		sqlmock.NewColumn("V").OfType("DOUBLE", ""),
+	).AddRow(ts, 1.25)
+
+	frame := sqlFrames(t, rows, formatTable, frameOptions{})[0]
+	got := *frame.Fields[0].At(0).(*time.Time)
+	if !got.Equal(ts) || got.Location() != zone {
+		t.Fatalf("time = %v, want %v", got, ts)
+	}
+	if *frame.Fields[1].At(0).(*float64) != 1.25 {
+		t.Fatalf("value = %v, want 1.25", *frame.Fields[1].At(0).(*float64))
+	}
+}
+
+func TestGetDataFrameFromRows_NativeNumbers(t *testing.T) {
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("ID").OfType("NUMBER", godror.Number("")).WithPrecisionAndScale(38, 0),
+		sqlmock.NewColumn("N").OfType("NUMBER", int64(0)).WithPrecisionAndScale(18, 0),
+		sqlmock.NewColumn("V").OfType("NUMBER", godror.Number("")).WithPrecisionAndScale(10, 2),
+		sqlmock.NewColumn("D").OfType("DOUBLE", float64(0)),
+		sqlmock.NewColumn("W").OfType("NUMBER", godror.Number("")).WithPrecisionAndScale(38, 0),
+	).
+		AddRow(godror.Number("9007199254740993"), int64(9007199254740993),
+			godror.Number("12.25"), 0.1, godror.Number("42")).
+		AddRow(nil, nil, nil, nil, nil).
+		AddRow(godror.Number("1"), int64(1), godror.Number("1"), 1.0,
+			godror.Number("123456789012345678901234567890"))
+
+	frame := sqlFrames(t, rows, formatTable, frameOptions{})[0]
+	//above 2^53, kept exact in an int64
+	if got := *frame.Fields[0].At(0).(*int64); got != 9007199254740993 {
+		t.Fatalf("NUMBER(38) = %d, want 9007199254740993", got)
+	}
+	if got := *frame.Fields[1].At(0).(*int64); got != 9007199254740993 {
+		t.Fatalf("NUMBER(18) = %d, want 9007199254740993", got)
+	}
+	if got := *frame.Fields[2].At(0).(*float64); got != 12.25 {
+		t.Fatalf("NUMBER(10,2) = %v, want 12.25", got)
+	}
+	if got := *frame.Fields[3].At(0).(*float64); got != 0.1 {
+		t.Fatalf("DOUBLE = %v, want 0.1", got)
+	}
+	//wider than an int64, the column is returned as numbers rather than
+	//failing the query
+	if got := *frame.Fields[4].At(0).(*float64); got != 42 {
+		t.Fatalf("NUMBER(38) = %v, want 42", got)
+	}
+	if got := *frame.Fields[4].At(2).(*float64); got != 1.2345678901234568e29 {
+		t.Fatalf("NUMBER(38) = %v, want 1.2345678901234568e29", got)
+	}
+	for i, field := range frame.Fields {
+		if _, ok := field.ConcreteAt(1); ok {
+			t.Fatalf("field %d: NULL not returned as null", i)
+		}
+	}
+}
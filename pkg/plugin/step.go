@@ -0,0 +1,129 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// stepAuto is the step text of a query whose step follows the interval and
+// the max data points of the panel.
+const stepAuto = "auto"
+
+// maxPromDataPoints is the number of points per series above which the step
+// of a PromQL query is raised, as Prometheus refuses more.
+const maxPromDataPoints = 11000
+
+// parseStepText parses a step of the query editor in seconds. The step is
+// rounded up to whole seconds, the unit of the telemetry query API.
+func parseStepText(text string) (int64, error) {
+	secs, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || secs <= 0 || math.IsNaN(secs) || math.IsInf(secs, 0) {
+		return 0, fmt.Errorf("invalid step %q", text)
+	}
+	return int64(math.Ceil(secs)), nil
+}
+
+// parseScrapeInterval parses the scrape interval of the datasource settings,
+// seconds or a duration such as 15s or 1m.
+func parseScrapeInterval(text string) (time.Duration, error) {
+	if d, err := time.ParseDuration(strings.TrimSpace(text)); err == nil && d > 0 {
+		return d, nil
+	}
+	secs, err := parseStepText(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scrape interval %q", text)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+// resolveStep returns the step in seconds a query is run with, which is also
+// the value of $__interval in SQL queries.
+//
+// An auto step is the interval Grafana computed for the panel, raised so
+// that the time range holds no more than max data points, then raised to the
+// min step of the query, or the scrape interval of the datasource when the
+// query has none, and multiplied by the resolution factor. A fixed step is
+// only raised to the min step of the query. PromQL steps are raised last so
+// that a series holds no more than maxPromDataPoints.
+func resolveStep(qm *QueryModel, query backend.DataQuery,
+	scrapeInterval time.Duration) int64 {
+	rangeSecs := query.TimeRange.Duration().Seconds()
+	step := float64(qm.step)
+	if qm.step == 0 {
+		step = query.Interval.Seconds()
+		if query.MaxDataPoints > 0 && rangeSecs > 0 {
+			step = math.Max(step, rangeSecs/float64(query.MaxDataPoints))
+		}
+		if step <= 0 {
+			step = defaultStepSecs
+		}
+		minStep := float64(qm.minStep)
+		if minStep == 0 {
+			minStep = scrapeInterval.Seconds()
+		}
+		step = math.Max(step, minStep) * float64(qm.resolution)
+	} else {
+		step = math.Max(step, float64(qm.minStep))
+	}
+	if qm.isPromQL() && rangeSecs/step > maxPromDataPoints {
+		step = rangeSecs / maxPromDataPoints
+	}
+	return int64(math.Max(1, math.Ceil(step)))
+}
+
+// setFrameStep records the step a query was run with in the statistics of
+// its frames. The samples of PromQL series are step apart, the interval of
+// their time field lets Grafana show the gaps between samples.
+func setFrameStep(frames data.Frames, step int64, promql bool) {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.Stats = append(frame.Meta.Stats, data.QueryStat{
+			FieldConfig: data.FieldConfig{DisplayName: "Step", Unit: "s"},
+			Value:       float64(step),
+		})
+		if !promql || len(frame.Fields) == 0 ||
+			frame.Fields[0].Type().NonNullableType() != data.FieldTypeTime {
+			continue
+		}
+		config := frame.Fields[0].Config
+		if config == nil {
+			config = &data.FieldConfig{}
+		}
+		config.Interval = float64(step * 1000)
+		frame.Fields[0].SetConfig(config)
+	}
+}
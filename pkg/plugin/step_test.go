@@ -0,0 +1,197 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestResolveStep(t *testing.T) {
+	from := time.Unix(1700000000, 0)
+	hour := backend.TimeRange{From: from, To: from.Add(time.Hour)}
+	week := backend.TimeRange{From: from, To: from.Add(7 * 24 * time.Hour)}
+	tests := []struct {
+		name   string
+		json   string
+		query  backend.DataQuery
+		scrape time.Duration
+		want   int64
+	}{
+		{
+			name:  "auto from interval",
+			json:  `{"exprProm":"up"}`,
+			query: backend.DataQuery{TimeRange: hour, Interval: 5 * time.Second, MaxDataPoints: 1000},
+			want:  5,
+		},
+		{
+			name:  "auto from max data points",
+			json:  `{"exprProm":"up","stepTextProm":"auto"}`,
+			query: backend.DataQuery{TimeRange: hour, Interval: time.Second, MaxDataPoints: 100},
+			want:  36,
+		},
+		{
+			name:  "auto without panel",
+			json:  `{"exprProm":"up"}`,
+			query: backend.DataQuery{TimeRange: hour},
+			want:  defaultStepSecs,
+		},
+		{
+			name:   "scrape interval",
+			json:   `{"exprProm":"up"}`,
+			query:  backend.DataQuery{TimeRange: hour, Interval: 5 * time.Second},
+			scrape: 15 * time.Second,
+			want:   15,
+		},
+		{
+			name:   "min step over scrape interval",
+			json:   `{"exprProm":"up","minStep":"30"}`,
+			query:  backend.DataQuery{TimeRange: hour, Interval: 5 * time.Second},
+			scrape: 15 * time.Second,
+			want:   30,
+		},
+		{
+			name:  "resolution",
+			json:  `{"exprProm":"up","resolution":"1/10"}`,
+			query: backend.DataQuery{TimeRange: hour, Interval: 5 * time.Second},
+			want:  50,
+		},
+		{
+			name:  "fixed step",
+			json:  `{"exprProm":"up","stepTextProm":"7","resolution":"2"}`,
+			query: backend.DataQuery{TimeRange: hour, Interval: time.Minute},
+			want:  7,
+		},
+		{
+			name:  "fixed step under min step",
+			json:  `{"exprProm":"up","stepTextProm":"7","minStep":"20"}`,
+			query: backend.DataQuery{TimeRange: hour},
+			want:  20,
+		},
+		{
+			name:  "promql points capped",
+			json:  `{"exprProm":"up","stepTextProm":"1"}`,
+			query: backend.DataQuery{TimeRange: week},
+			want:  55, // 604800 / 11000 rounded up
+		},
+		{
+			name:  "sql points not capped",
+			json:  `{"queryLang":"sql","exprSql":"select 1 from dual","stepTextSql":"1"}`,
+			query: backend.DataQuery{TimeRange: week},
+			want:  1,
+		},
+		{
+			name:  "fraction rounded up",
+			json:  `{"exprProm":"up"}`,
+			query: backend.DataQuery{TimeRange: hour, Interval: 200 * time.Millisecond},
+			want:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.JSON = json.RawMessage(tt.json)
+			qm, err := parseQueryModel(tt.query)
+			if err != nil {
+				t.Fatalf("parseQueryModel: %v", err)
+			}
+			if got := resolveStep(qm, tt.query, tt.scrape); got != tt.want {
+				t.Fatalf("step = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryModel_StepErrors(t *testing.T) {
+	_, err := parseQueryModel(backend.DataQuery{JSON: json.RawMessage(
+		`{"exprProm":"up","stepTextProm":"0","minStep":"-5","resolution":"1/0"}`)})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, want := range []string{"stepTextProm:", "minStep:", "resolution:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not report %s", err, want)
+		}
+	}
+}
+
+func TestNewOracleDatasource_ScrapeInterval(t *testing.T) {
+	for setting, want := range map[string]time.Duration{
+		"": 0, "15s": 15 * time.Second, "1m": time.Minute, "30": 30 * time.Second,
+		"soon": 0,
+	} {
+		raw, _ := json.Marshal(map[string]interface{}{"scrapeInterval": setting})
+		instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{JSONData: raw})
+		if err != nil {
+			t.Fatalf("NewOracleDatasource: %v", err)
+		}
+		ds := instance.(*OracleDatasource)
+		if ds.getQueryConfig().ScrapeInterval != want {
+			t.Errorf("scrapeInterval %q: got %v, want %v", setting,
+				ds.ScrapeInterval, want)
+		}
+	}
+}
+
+func TestQuery_EffectiveStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`promql_range`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(36)).
+		WillReturnRows(promRangeRows("a"))
+	resp := query(context.Background(), backend.DataQuery{
+		RefID:         "A",
+		JSON:          json.RawMessage(`{"exprProm":"up"}`),
+		TimeRange:     testTimeRange,
+		Interval:      time.Second,
+		MaxDataPoints: 100,
+	}, db, queryConfig{DeploymentType: "ONPREM"})
+	if resp.Error != nil {
+		t.Fatalf("query: %v", resp.Error)
+	}
+	if len(resp.Frames) == 0 {
+		t.Fatalf("expected frames")
+	}
+	for _, frame := range resp.Frames {
+		stats := frame.Meta.Stats
+		if len(stats) != 1 || stats[0].DisplayName != "Step" || stats[0].Value != 36 {
+			t.Fatalf("stats = %+v, want the step", stats)
+		}
+		if config := frame.Fields[0].Config; config == nil || config.Interval != 36000 {
+			t.Fatalf("time field config = %+v, want a 36s interval", config)
+		}
+	}
+}
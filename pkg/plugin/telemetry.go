@@ -0,0 +1,105 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// telemetryQuery is a call of one of the telemetry query API functions
+// (promql_range, promql_label and promql_series of DBMS_TELEMETRY_QUERY or
+// DBMS_CLOUD_TELEMETRY_QUERY). The PromQL expression, label names and time
+// bounds are passed as bind variables and never spliced into the statement
+// text, so quotes in user input cannot change the statement.
+type telemetryQuery struct {
+	queryText string
+	args      []interface{}
+}
+
+// newPromQLRangeQuery returns the promql_range call evaluating expr between
+// start and end (epoch seconds) with the given step in seconds.
+func newPromQLRangeQuery(deploymentType string, expr string,
+	start int64, end int64, step int64) telemetryQuery {
+	return telemetryQuery{
+		queryText: getConstants("query_range_str", deploymentType),
+		args: []interface{}{
+			sql.Named("expr", expr),
+			sql.Named("start_ts", start),
+			sql.Named("end_ts", end),
+			sql.Named("step", step),
+		},
+	}
+}
+
+// newPromQLLabelQuery returns the promql_label call listing the values of
+// label between start and end (epoch seconds). A label of " " lists the
+// label names themselves.
+func newPromQLLabelQuery(deploymentType string, label string,
+	start int64, end int64) telemetryQuery {
+	return telemetryQuery{
+		queryText: getConstants("query_label_str", deploymentType),
+		args: []interface{}{
+			sql.Named("label_name", label),
+			sql.Named("start_ts", start),
+			sql.Named("end_ts", end),
+		},
+	}
+}
+
+// newPromQLSeriesQuery returns the promql_series call listing the series
+// matching the selector match between start and end (epoch seconds).
+func newPromQLSeriesQuery(deploymentType string, match string,
+	start int64, end int64) telemetryQuery {
+	return telemetryQuery{
+		queryText: getConstants("query_series_str", deploymentType),
+		args: []interface{}{
+			sql.Named("match", match),
+			sql.Named("start_ts", start),
+			sql.Named("end_ts", end),
+		},
+	}
+}
+
+// run executes the call on dbConn bound to ctx.
+func (tq telemetryQuery) run(ctx context.Context, dbConn *sql.DB) (
+	*sql.Rows, error) {
+	return dbConn.QueryContext(ctx, tq.queryText, tq.args...)
+}
+
+// String returns the statement text followed by its bind values, it is only
+// meant for logging.
+func (tq telemetryQuery) String() string {
+	str := tq.queryText
+	for _, arg := range tq.args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			str += fmt.Sprintf(" :%s=%q", named.Name, fmt.Sprint(named.Value))
+		}
+	}
+	return str
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestTelemetryQuery_BindsUserInput(t *testing.T) {
+	inputs := []string{
+		`up`,
+		`up{job='x'}`,
+		`rate(http_requests_total{code=~"5..",handler!~'/api/.*'}[5m])`,
+		`up{job="it's"} or vector(1)`,
+		`x') from dual union select password from dba_users --`,
+		`label\with\\backslashes`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			tests := []struct {
+				name string
+				tq   telemetryQuery
+				args []driver.Value
+			}{
+				{
+					name: "range",
+					tq:   newPromQLRangeQuery("ONPREM", input, 100, 200, 10),
+					args: []driver.Value{
+						sql.Named("expr", input),
+						sql.Named("start_ts", int64(100)),
+						sql.Named("end_ts", int64(200)),
+						sql.Named("step", int64(10)),
+					},
+				},
+				{
+					name: "label",
+					tq:   newPromQLLabelQuery("ADB", input, 100, 200),
+					args: []driver.Value{
+						sql.Named("label_name", input),
+						sql.Named("start_ts", int64(100)),
+						sql.Named("end_ts", int64(200)),
+					},
+				},
+				{
+					name: "series",
+					tq:   newPromQLSeriesQuery("ONPREM", input, 100, 200),
+					args: []driver.Value{
+						sql.Named("match", input),
+						sql.Named("start_ts", int64(100)),
+						sql.Named("end_ts", int64(200)),
+					},
+				},
+			}
+
+			for _, tc := range tests {
+				if strings.Contains(tc.tq.queryText, input) {
+					t.Fatalf("%s: user input spliced into statement %q",
+						tc.name, tc.tq.queryText)
+				}
+
+				db, mock, err := sqlmock.New()
+				if err != nil {
+					t.Fatalf("sqlmock.New: %v", err)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta(tc.tq.queryText)).
+					WithArgs(tc.args...).
+					WillReturnRows(sqlmock.NewRows([]string{"RESULT"}).AddRow("ok"))
+
+				rows, err := tc.tq.run(context.Background(), db)
+				if err != nil {
+					t.Fatalf("%s: run: %v", tc.name, err)
+				}
+				rows.Close()
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Fatalf("%s: sqlmock expectations: %v", tc.name, err)
+				}
+				db.Close()
+			}
+		})
+	}
+}
+
+func TestTelemetryQuery_String(t *testing.T) {
+	tq := newPromQLLabelQuery("ONPREM", `job'"`, 1, 2)
+	want := `select DBMS_TELEMETRY_QUERY.promql_label(:label_name,:start_ts,:end_ts) from dual` +
+		` :label_name="job'\"" :start_ts="1" :end_ts="2"`
+	if tq.String() != want {
+		t.Fatalf("String() = %s, want %s", tq.String(), want)
+	}
+}
+
+func TestQuery_PromQLWithQuotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expr := `sum by (job) (up{job='node', instance=~"host-.*"})`
+	jsonPayload := `{"status":"success","data":{"resultType":"matrix","result":[]}}`
+	mock.ExpectQuery(regexp.QuoteMeta(getConstants("query_range_str", "ADB"))).
+		WithArgs(sql.Named("expr", expr),
+			sql.Named("start_ts", int64(1700000000)),
+			sql.Named("end_ts", int64(1700003600)),
+			sql.Named("step", int64(10))).
+		WillReturnRows(sqlmock.NewRows([]string{"PROM_RESULT"}).AddRow(jsonPayload))
+
+	qJSON, _ := json.Marshal(map[string]interface{}{
+		"refId":        "A",
+		"queryLang":    "promql",
+		"exprProm":     expr,
+		"stepTextProm": "10",
+	})
+	q := backend.DataQuery{
+		JSON: qJSON,
+		TimeRange: backend.TimeRange{
+			From: time.Unix(1700000000, 0),
+			To:   time.Unix(1700003600, 0),
+		},
+	}
+
+	resp := query(context.Background(), q, db, queryConfig{DeploymentType: "ADB"})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
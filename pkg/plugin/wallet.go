@@ -0,0 +1,248 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Files looked for in an Oracle wallet directory.
+const (
+	walletAutoLoginFile = "cwallet.sso"
+	walletPKCS12File    = "ewallet.p12"
+	walletTnsnamesFile  = "tnsnames.ora"
+)
+
+// Limits of an uploaded wallet zip. Wallet files are a few kilobytes and the
+// zip downloaded from the cloud console holds about ten of them.
+const (
+	// maxWalletFileSize bounds the size of a single extracted file
+	maxWalletFileSize = 10 << 20
+	// maxWalletSize bounds the size of all the extracted files
+	maxWalletSize = 20 << 20
+	// maxWalletEntries bounds the number of entries of the zip
+	maxWalletEntries = 100
+)
+
+// materialiseWallet decodes the base64 encoded wallet zip uploaded with the
+// datasource settings and extracts it into a new temporary directory only
+// readable by the plugin process. Directories inside the zip are flattened,
+// the wallet files are looked up by name. The caller removes the directory
+// when the datasource instance is disposed.
+func materialiseWallet(walletZip string) (string, error) {
+	zipData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(walletZip))
+	if err != nil {
+		return "", fmt.Errorf("wallet zip is not valid base64: %w", err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return "", fmt.Errorf("wallet zip cannot be read: %w", err)
+	}
+	if len(zipReader.File) > maxWalletEntries {
+		return "", fmt.Errorf("wallet zip has %d entries, at most %d are allowed",
+			len(zipReader.File), maxWalletEntries)
+	}
+	walletDir, err := os.MkdirTemp("", "oracle-wallet-")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(walletDir, 0o700); err != nil {
+		os.RemoveAll(walletDir)
+		return "", err
+	}
+	remaining := int64(maxWalletSize)
+	for _, zipFile := range zipReader.File {
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+		// only the base name is kept, so entries like ../../x cannot be
+		// written outside of the wallet directory
+		name := filepath.Base(filepath.FromSlash(zipFile.Name))
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			continue
+		}
+		written, err := extractWalletFile(zipFile, filepath.Join(walletDir, name), remaining)
+		if err != nil {
+			os.RemoveAll(walletDir)
+			return "", err
+		}
+		remaining -= written
+	}
+	customLogger("info", "wallet extracted to", walletDir)
+	return walletDir, nil
+}
+
+// extractWalletFile writes one file of the wallet zip to path and returns its
+// size. remaining is what is left of maxWalletSize for this file.
+func extractWalletFile(zipFile *zip.File, path string, remaining int64) (int64, error) {
+	src, err := zipFile.Open()
+	if err != nil {
+		return 0, fmt.Errorf("wallet zip entry %s cannot be read: %w", zipFile.Name, err)
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	limit := int64(maxWalletFileSize)
+	if remaining < limit {
+		limit = remaining
+	}
+	written, err := io.Copy(dst, io.LimitReader(src, limit+1))
+	if err == nil && written > limit {
+		if limit == maxWalletFileSize {
+			err = fmt.Errorf("wallet zip entry %s is too large", zipFile.Name)
+		} else {
+			err = fmt.Errorf("wallet zip is too large, its files exceed %d bytes", maxWalletSize)
+		}
+	}
+	if errClose := dst.Close(); err == nil {
+		err = errClose
+	}
+	return written, err
+}
+
+// validateWallet checks that walletDir holds a wallet the Oracle Client can
+// open. The client libraries used by godror only open auto-login wallets
+// (cwallet.sso), a PKCS#12 wallet protected by a password cannot be used:
+// neither OCI nor godror take a wallet password, only the thin drivers of
+// the other languages do, so there is no secure setting for one. Such a
+// wallet is made auto-login with orapki, which adds a cwallet.sso.
+func validateWallet(walletDir string) error {
+	info, err := os.Stat(walletDir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("wallet location %s is not a directory", walletDir)
+	}
+	if _, err := os.Stat(filepath.Join(walletDir, walletAutoLoginFile)); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(walletDir, walletPKCS12File)); err == nil {
+		return fmt.Errorf("wallet at %s has no %s: the Oracle Client needs an "+
+			"auto-login wallet, a password protected %s is not supported, "+
+			"add a %s with: orapki wallet create -wallet <dir> -auto_login",
+			walletDir, walletAutoLoginFile, walletPKCS12File, walletAutoLoginFile)
+	}
+	return fmt.Errorf("no wallet found in %s", walletDir)
+}
+
+// resolveTNSAlias returns the connect descriptor of alias from the
+// tnsnames.ora found in walletDir.
+func resolveTNSAlias(walletDir string, alias string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(walletDir, walletTnsnamesFile))
+	if err != nil {
+		return "", fmt.Errorf("connection identifier %s cannot be resolved, "+
+			"wallet has no %s", alias, walletTnsnamesFile)
+	}
+	entries := parseTnsnames(string(content))
+	if descriptor, ok := entries[strings.ToLower(strings.TrimSpace(alias))]; ok {
+		return descriptor, nil
+	}
+	return "", fmt.Errorf("connection identifier %s not found in %s of the wallet",
+		alias, walletTnsnamesFile)
+}
+
+// parseTnsnames parses the content of a tnsnames.ora file into a map of lower
+// cased alias to connect descriptor. An entry can list several aliases
+// separated by commas.
+func parseTnsnames(content string) map[string]string {
+	entries := make(map[string]string)
+	var text strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		text.WriteString(line)
+		text.WriteString(" ")
+	}
+	rest := text.String()
+	for {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			return entries
+		}
+		names := rest[:eq]
+		rest = rest[eq+1:]
+		start := strings.Index(rest, "(")
+		if start < 0 {
+			return entries
+		}
+		depth := 0
+		end := -1
+		for i := start; i < len(rest) && end < 0; i++ {
+			switch rest[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return entries
+		}
+		descriptor := strings.TrimSpace(rest[start : end+1])
+		for _, name := range strings.Split(names, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" {
+				entries[name] = descriptor
+			}
+		}
+		rest = rest[end+1:]
+	}
+}
+
+var securityClauseRegex = regexp.MustCompile(`(?i)\(\s*security\s*=`)
+var walletDirectoryRegex = regexp.MustCompile(`(?i)my_wallet_directory\s*=`)
+
+// addWalletToDescriptor makes the connect descriptor use the wallet in
+// walletDir, adding MY_WALLET_DIRECTORY to its SECURITY section.
+func addWalletToDescriptor(descriptor string, walletDir string) (string, error) {
+	if walletDirectoryRegex.MatchString(descriptor) {
+		return descriptor, nil
+	}
+	walletClause := "(MY_WALLET_DIRECTORY=" + walletDir + ")"
+	if loc := securityClauseRegex.FindStringIndex(descriptor); loc != nil {
+		return descriptor[:loc[1]] + walletClause + descriptor[loc[1]:], nil
+	}
+	end := strings.LastIndex(descriptor, ")")
+	if end < 0 {
+		return "", errors.New("invalid connect descriptor")
+	}
+	return descriptor[:end] + "(SECURITY=" + walletClause + ")" +
+		descriptor[end:], nil
+}
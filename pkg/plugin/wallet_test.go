@@ -0,0 +1,365 @@
+// Copyright (c) 2015, 2026, Oracle and/or its affiliates.
+
+//-----------------------------------------------------------------------------
+//
+// This software is dual-licensed to you under the Universal Permissive License
+// (UPL) 1.0 as shown at https://oss.oracle.com/licenses/upl and Apache License
+// 2.0 as shown at http://www.apache.org/licenses/LICENSE-2.0. You may choose
+// either license.
+//
+// If you elect to accept the software under the Apache License, Version 2.0,
+// the following applies:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+//-----------------------------------------------------------------------------
+
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	godror "github.com/godror/godror"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const testTnsnames = `
+# generated by the cloud console
+mydb_high = (description= (retry_count=20)(retry_delay=3)(address=(protocol=tcps)(port=1522)(host=adb.example.com))(connect_data=(service_name=mydb_high.adb.example.com))(security=(ssl_server_dn_match=yes)))
+
+mydb_low,
+mydb_alias =
+  (DESCRIPTION =
+    (ADDRESS = (PROTOCOL = TCPS)(HOST = adb.example.com)(PORT = 1522))
+    (CONNECT_DATA = (SERVICE_NAME = mydb_low.adb.example.com))
+  )
+`
+
+// makeTestWalletZip returns a base64 encoded zip holding the given files.
+func makeTestWalletZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip create: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestMaterialiseWallet(t *testing.T) {
+	walletZip := makeTestWalletZip(t, map[string]string{
+		"Wallet_mydb/cwallet.sso":  "sso",
+		"Wallet_mydb/tnsnames.ora": testTnsnames,
+		"../../escape.txt":         "nope",
+	})
+
+	walletDir, err := materialiseWallet(walletZip)
+	if err != nil {
+		t.Fatalf("materialiseWallet: %v", err)
+	}
+	defer os.RemoveAll(walletDir)
+
+	info, err := os.Stat(walletDir)
+	if err != nil {
+		t.Fatalf("stat wallet dir: %v", err)
+	}
+	if info.Mode().Perm() != 0o700 {
+		t.Errorf("wallet dir mode = %v, want 0700", info.Mode().Perm())
+	}
+	for _, name := range []string{"cwallet.sso", "tnsnames.ora", "escape.txt"} {
+		fileInfo, err := os.Stat(filepath.Join(walletDir, name))
+		if err != nil {
+			t.Fatalf("expected %s in wallet dir: %v", name, err)
+		}
+		if fileInfo.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %v, want 0600", name, fileInfo.Mode().Perm())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(walletDir)), "escape.txt")); err == nil {
+		t.Fatalf("zip entry escaped the wallet directory")
+	}
+	if err := validateWallet(walletDir); err != nil {
+		t.Fatalf("validateWallet: %v", err)
+	}
+}
+
+func TestMaterialiseWallet_Invalid(t *testing.T) {
+	if _, err := materialiseWallet("not base64!"); err == nil {
+		t.Fatalf("expected error for invalid base64")
+	}
+	if _, err := materialiseWallet(base64.StdEncoding.EncodeToString([]byte("not a zip"))); err == nil {
+		t.Fatalf("expected error for invalid zip")
+	}
+}
+
+func TestMaterialiseWallet_Limits(t *testing.T) {
+	manyFiles := make(map[string]string)
+	for i := 0; i <= maxWalletEntries; i++ {
+		manyFiles[fmt.Sprintf("file%d.txt", i)] = "x"
+	}
+	largeFile := strings.Repeat("x", maxWalletFileSize/2+1)
+	tests := map[string]struct {
+		files   map[string]string
+		wantErr string
+	}{
+		"too many entries": {
+			files:   manyFiles,
+			wantErr: fmt.Sprintf("wallet zip has %d entries, at most %d are allowed", maxWalletEntries+1, maxWalletEntries),
+		},
+		"entry too large": {
+			files:   map[string]string{"cwallet.sso": strings.Repeat("x", maxWalletFileSize+1)},
+			wantErr: "wallet zip entry cwallet.sso is too large",
+		},
+		"total too large": {
+			files: map[string]string{
+				"a.p12": largeFile, "b.p12": largeFile, "c.p12": largeFile, "d.p12": largeFile,
+			},
+			wantErr: fmt.Sprintf("wallet zip is too large, its files exceed %d bytes", maxWalletSize),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			walletDir, err := materialiseWallet(makeTestWalletZip(t, tc.files))
+			if err == nil {
+				os.RemoveAll(walletDir)
+				t.Fatalf("expected error %q", tc.wantErr)
+			}
+			if err.Error() != tc.wantErr {
+				t.Fatalf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWallet(t *testing.T) {
+	dir := t.TempDir()
+	if err := validateWallet(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("expected error for missing directory")
+	}
+	if err := validateWallet(dir); err == nil || !strings.Contains(err.Error(), "no wallet found") {
+		t.Fatalf("error = %v, want no wallet found", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ewallet.p12"), []byte("p12"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateWallet(dir); err == nil || !strings.Contains(err.Error(), "auto-login") ||
+		!strings.Contains(err.Error(), "orapki wallet create") {
+		t.Fatalf("error = %v, want auto-login wallet error with the orapki command", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cwallet.sso"), []byte("sso"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateWallet(dir); err != nil {
+		t.Fatalf("validateWallet: %v", err)
+	}
+}
+
+func TestParseTnsnames(t *testing.T) {
+	entries := parseTnsnames(testTnsnames)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %v", len(entries), entries)
+	}
+	if !strings.Contains(entries["mydb_high"], "service_name=mydb_high.adb.example.com") {
+		t.Errorf("mydb_high = %q", entries["mydb_high"])
+	}
+	if entries["mydb_low"] != entries["mydb_alias"] || entries["mydb_low"] == "" {
+		t.Errorf("mydb_low = %q, mydb_alias = %q", entries["mydb_low"], entries["mydb_alias"])
+	}
+}
+
+func TestAddWalletToDescriptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor string
+		want       string
+	}{
+		{
+			name:       "existing security",
+			descriptor: "(description=(address=(host=h))(security=(ssl_server_dn_match=yes)))",
+			want:       "(description=(address=(host=h))(security=(MY_WALLET_DIRECTORY=/w)(ssl_server_dn_match=yes)))",
+		},
+		{
+			name:       "no security",
+			descriptor: "(DESCRIPTION=(ADDRESS=(HOST=h)))",
+			want:       "(DESCRIPTION=(ADDRESS=(HOST=h))(SECURITY=(MY_WALLET_DIRECTORY=/w)))",
+		},
+		{
+			name:       "wallet already set",
+			descriptor: "(DESCRIPTION=(SECURITY=(MY_WALLET_DIRECTORY=/other)))",
+			want:       "(DESCRIPTION=(SECURITY=(MY_WALLET_DIRECTORY=/other)))",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := addWalletToDescriptor(tc.descriptor, "/w")
+			if err != nil {
+				t.Fatalf("addWalletToDescriptor: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_WalletConnectString(t *testing.T) {
+	walletDir := t.TempDir()
+	for name, content := range map[string]string{
+		"cwallet.sso":  "sso",
+		"tnsnames.ora": testTnsnames,
+	} {
+		if err := os.WriteFile(filepath.Join(walletDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(cc *connectionConfig)
+		want      string
+		wantError string
+	}{
+		{
+			name: "basic uses tcps",
+			modify: func(cc *connectionConfig) {
+				cc.Port = "1522"
+			},
+			want: "tcps://dbhost:1522/orclpdb1?wallet_location=" + walletDir,
+		},
+		{
+			name: "easy connect",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "tcps://adb.example.com:1522/svc"
+				cc.RetryCount = 3
+			},
+			want: "tcps://adb.example.com:1522/svc?retry_count=3&wallet_location=" + walletDir,
+		},
+		{
+			name: "alias from wallet tnsnames",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "MYDB_HIGH"
+			},
+			want: "(description= (retry_count=20)(retry_delay=3)(address=(protocol=tcps)(port=1522)(host=adb.example.com))(connect_data=(service_name=mydb_high.adb.example.com))(security=(MY_WALLET_DIRECTORY=" + walletDir + ")(ssl_server_dn_match=yes)))",
+		},
+		{
+			name: "unknown alias",
+			modify: func(cc *connectionConfig) {
+				cc.QueryAuth = "TNS"
+				cc.ConnectString = "otherdb_high"
+			},
+			wantError: "connection identifier otherdb_high not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cc := makeTestConnectionConfig()
+			cc.WalletLocation = walletDir
+			tc.modify(&cc)
+			got, err := cc.getConnectString()
+			if tc.wantError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantError) {
+					t.Fatalf("error = %v, want %q", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getConnectString: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewOracleDatasource_UploadedWallet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	mock.ExpectClose()
+
+	var got godror.ConnectionParams
+	orig := dbConnector
+	dbConnector = func(_ context.Context, params godror.ConnectionParams) (*sql.DB, error) {
+		got = params
+		return db, nil
+	}
+	defer func() { dbConnector = orig }()
+
+	raw, _ := json.Marshal(map[string]interface{}{
+		"queryAuth":       "TNS",
+		"deploymentType":  "ADB",
+		"dbUser":          "admin",
+		"dbConnectString": "mydb_low",
+		"walletLocation":  "/ignored/when/uploaded",
+	})
+	instance, err := NewOracleDatasource(backend.DataSourceInstanceSettings{
+		JSONData: raw,
+		DecryptedSecureJSONData: map[string]string{
+			"dbPassword": "pw",
+			"walletZip": makeTestWalletZip(t, map[string]string{
+				"cwallet.sso":  "sso",
+				"tnsnames.ora": testTnsnames,
+			}),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewOracleDatasource: %v", err)
+	}
+	ds := instance.(*OracleDatasource)
+	if ds.walletDir == "" {
+		t.Fatalf("expected wallet to be extracted")
+	}
+	walletDir := ds.walletDir
+
+	if _, err := ds.getDBPool(context.Background()); err != nil {
+		t.Fatalf("getDBPool: %v", err)
+	}
+	if !strings.Contains(got.ConnectString, "MY_WALLET_DIRECTORY="+walletDir) ||
+		!strings.Contains(got.ConnectString, "mydb_low.adb.example.com") {
+		t.Fatalf("connect string = %q", got.ConnectString)
+	}
+
+	ds.Dispose()
+	if _, err := os.Stat(walletDir); !os.IsNotExist(err) {
+		t.Fatalf("expected wallet directory to be removed on Dispose, stat: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("sqlmock expectations: %v", err)
+	}
+}
@@ -45,6 +45,36 @@ const DEPLOYMENT_OPTIONS: Array<SelectableValue<string>> = [
   { label: 'ADB', value: 'ADB' },
 ];
 
+//how NaN samples of PromQL results are returned
+const NAN_VALUES_OPTIONS: Array<SelectableValue<string>> = [
+  { label: 'NaN', value: 'nan' },
+  { label: 'Null', value: 'null' },
+];
+
+//how NULL values of SQL results are returned
+const NULL_VALUES_OPTIONS: Array<SelectableValue<string>> = [
+  { label: 'Null', value: 'null' },
+  { label: 'Zero', value: 'zero' },
+];
+
+//numeric settings, unset when their field is empty so that the backend
+//applies its default
+type NumberSetting =
+  | 'poolMinSessions'
+  | 'poolMaxSessions'
+  | 'poolIdleTimeout'
+  | 'poolMaxLifetime'
+  | 'queryTimeout'
+  | 'maxParallelQueries'
+  | 'retryCount'
+  | 'connectTimeout'
+  | 'transportConnectTimeout';
+
+//text settings, unset when their field is empty
+type TextSetting = 'walletLocation' | 'scrapeInterval' | 'timeZone';
+//settings chosen from a list
+type SelectSetting = 'nanValues' | 'nullValues';
+
 const getSelectValue = (options: Array<SelectableValue<string>>, value: string) =>
   options.find((option) => option.value === value) || options[0];
 
@@ -107,17 +137,103 @@ export class ConfigEditor extends PureComponent<Props, State> {
     onOptionsChange({ ...options, jsonData });
   };
 
+  onNumberChange = (key: NumberSetting) => (event: ChangeEvent<HTMLInputElement>) => {
+    const { onOptionsChange, options } = this.props;
+    const value = event.target.value.trim();
+    const jsonData = {
+      ...options.jsonData,
+      [key]: value === '' ? undefined : Number(value),
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  onTextChange = (key: TextSetting) => (event: ChangeEvent<HTMLInputElement>) => {
+    const { onOptionsChange, options } = this.props;
+    const value = event.target.value.trim();
+    const jsonData = {
+      ...options.jsonData,
+      [key]: value === '' ? undefined : value,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  onSelectChange = (key: SelectSetting) => (option: SelectableValue<string>) => {
+    const { onOptionsChange, options } = this.props;
+    const jsonData = {
+      ...options.jsonData,
+      [key]: option.value,
+    };
+    onOptionsChange({ ...options, jsonData });
+  };
+
+  renderNumberField(key: NumberSetting, label: string, placeholder: string, tooltip: string) {
+    const value = this.props.options.jsonData[key];
+    return (
+      <div className="gf-form">
+        <FormField
+          label={label}
+          labelWidth={14}
+          inputWidth={12}
+          type="number"
+          min="0"
+          onChange={this.onNumberChange(key)}
+          value={value === undefined ? '' : String(value)}
+          placeholder={placeholder}
+          tooltip={tooltip}
+        />
+      </div>
+    );
+  }
+
   // Secure field (only sent to the backend)
   onPasswordChange = (event: ChangeEvent<HTMLInputElement>) => {
     const { onOptionsChange, options } = this.props;
     onOptionsChange({
       ...options,
       secureJsonData: {
+        ...options.secureJsonData,
         dbPassword: event.target.value,
       },
     });
   };
 
+  // Secure field, the wallet zip is sent base64 encoded and never read back
+  onWalletZipChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const file = event.target.files?.[0];
+    if (!file) {
+      return;
+    }
+    const reader = new FileReader();
+    reader.onload = () => {
+      const { onOptionsChange, options } = this.props;
+      //the data url is data:application/zip;base64,<zip>
+      const dataUrl = String(reader.result);
+      onOptionsChange({
+        ...options,
+        secureJsonData: {
+          ...options.secureJsonData,
+          walletZip: dataUrl.substring(dataUrl.indexOf(',') + 1),
+        },
+      });
+    };
+    reader.readAsDataURL(file);
+  };
+
+  onResetWalletZip = () => {
+    const { onOptionsChange, options } = this.props;
+    onOptionsChange({
+      ...options,
+      secureJsonFields: {
+        ...options.secureJsonFields,
+        walletZip: false,
+      },
+      secureJsonData: {
+        ...options.secureJsonData,
+        walletZip: '',
+      },
+    });
+  };
+
   onResetPassword = () => {
     const { onOptionsChange, options } = this.props;
     onOptionsChange({
@@ -251,6 +367,145 @@ export class ConfigEditor extends PureComponent<Props, State> {
             </>
           )}
         </div>
+
+        <h3 className="page-heading">Wallet</h3>
+        <div className="gf-form">
+          <FormField
+            label="Wallet Location"
+            labelWidth={14}
+            inputWidth={20}
+            onChange={this.onTextChange('walletLocation')}
+            value={jsonData.walletLocation || ''}
+            placeholder="Wallet directory on the Grafana host"
+            tooltip="Directory of an auto-login wallet (cwallet.sso) used for TLS connections"
+          />
+        </div>
+        <div className="gf-form">
+          <InlineFormLabel
+            width={14}
+            tooltip="Wallet zip, as downloaded from the cloud console, used instead of the wallet location. It needs an auto-login cwallet.sso, password protected wallets are not supported."
+          >
+            Wallet Zip
+          </InlineFormLabel>
+          {secureJsonFields?.walletZip || secureJsonData.walletZip ? (
+            <>
+              <input type="text" className="gf-form-input width-12" disabled={true} value="configured" />
+              <button type="button" className="btn btn-secondary gf-form-btn" onClick={this.onResetWalletZip}>
+                Reset Wallet
+              </button>
+            </>
+          ) : (
+            <input
+              type="file"
+              accept=".zip"
+              aria-label="Wallet Zip"
+              className="gf-form-input width-20"
+              onChange={this.onWalletZipChange}
+            />
+          )}
+        </div>
+
+        <h3 className="page-heading">Connection</h3>
+        {this.renderNumberField(
+          'retryCount',
+          'Retry Count',
+          'No retries if unset',
+          'Number of times a failed connection attempt is retried'
+        )}
+        {this.renderNumberField(
+          'connectTimeout',
+          'Connect Timeout',
+          'Seconds',
+          'Seconds after which an attempt to connect to the database fails'
+        )}
+        {this.renderNumberField(
+          'transportConnectTimeout',
+          'Transport Timeout',
+          'Seconds',
+          'Seconds after which an attempt to open the network connection fails'
+        )}
+
+        <h3 className="page-heading">Session Pool</h3>
+        {this.renderNumberField('poolMinSessions', 'Min Sessions', 'Default:1', 'Sessions kept open by the pool')}
+        {this.renderNumberField('poolMaxSessions', 'Max Sessions', 'Default:10', 'Upper bound of the open sessions')}
+        {this.renderNumberField(
+          'poolIdleTimeout',
+          'Idle Timeout',
+          'Seconds, Default:300',
+          'Seconds after which an idle session is closed'
+        )}
+        {this.renderNumberField(
+          'poolMaxLifetime',
+          'Max Lifetime',
+          'Seconds, Default:3600',
+          'Seconds after which a session is closed and opened again'
+        )}
+
+        <h3 className="page-heading">Queries</h3>
+        <div className="gf-form">
+          <InlineFormLabel width={14} tooltip="How the NaN samples of PromQL results are returned">
+            PromQL NaN Values
+          </InlineFormLabel>
+          <InlineField>
+            <Select
+              className={'select-container'}
+              isSearchable={false}
+              options={NAN_VALUES_OPTIONS}
+              value={getSelectValue(NAN_VALUES_OPTIONS, jsonData.nanValues || 'nan')}
+              onChange={this.onSelectChange('nanValues')}
+              width={24}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form">
+          <InlineFormLabel width={14} tooltip="How the NULL values of SQL results are returned">
+            SQL NULL Values
+          </InlineFormLabel>
+          <InlineField>
+            <Select
+              className={'select-container'}
+              isSearchable={false}
+              options={NULL_VALUES_OPTIONS}
+              value={getSelectValue(NULL_VALUES_OPTIONS, jsonData.nullValues || 'null')}
+              onChange={this.onSelectChange('nullValues')}
+              width={24}
+            />
+          </InlineField>
+        </div>
+        {this.renderNumberField(
+          'queryTimeout',
+          'Query Timeout',
+          'Seconds, no timeout if unset',
+          'Seconds after which a query is cancelled, queries can set their own'
+        )}
+        {this.renderNumberField(
+          'maxParallelQueries',
+          'Max Parallel Queries',
+          'Default:4',
+          'Number of the queries of a panel run at the same time'
+        )}
+        <div className="gf-form">
+          <FormField
+            label="Scrape Interval"
+            labelWidth={14}
+            inputWidth={12}
+            onChange={this.onTextChange('scrapeInterval')}
+            value={jsonData.scrapeInterval || ''}
+            placeholder="eg. 15s"
+            tooltip="Interval the metrics are collected at, the min step of auto steps"
+          />
+        </div>
+        <div className="gf-form">
+          <FormField
+            label="Time Zone"
+            labelWidth={14}
+            inputWidth={12}
+            onChange={this.onTextChange('timeZone')}
+            value={jsonData.timeZone || ''}
+            placeholder="eg. Europe/Paris"
+            tooltip="Time zone of the DATE and TIMESTAMP values of the data, a region name or an offset such as +02:00, the session time zone if unset"
+          />
+        </div>
       </div>
     );
   }
@@ -28,20 +28,68 @@
 // after selecting our plugin.
 
 import React, { ChangeEvent, PureComponent } from 'react';
-import { InlineFormLabel, TextArea, Select, LegacyForms } from '@grafana/ui';
+import { InlineFormLabel, TextArea, Select } from '@grafana/ui';
 import { QueryEditorProps, SelectableValue } from '@grafana/data';
 import { DataSource } from './datasource';
 import { DataSourceOptionsObj, QueryObj, InData } from './types';
 
 import { AutoCompleteContainer, Input, AutoCompleteItem, AutoCompleteItemButton } from './styles';
 
-const { Switch } = LegacyForms;
-
 const QUERYLANG_OPTIONS: Array<SelectableValue<string>> = [
   { label: 'PROMQL', value: 'promql' },
   { label: 'SQL', value: 'sql' },
 ];
 
+//formats of the results of sql queries, auto returns a time series when the
+//columns hold one and a table otherwise
+const FORMAT_OPTIONS: Array<SelectableValue<string>> = [
+  { label: 'Table', value: 'table' },
+  { label: 'Time series', value: 'time_series' },
+  { label: 'Logs', value: 'logs' },
+  { label: 'Auto', value: 'auto' },
+];
+
+//format of a query, queries saved before the format was added used the
+//convertSqlResults switch, on by default
+export const queryFormat = (query: QueryObj): string => {
+  if (query.format) {
+    return query.format;
+  }
+  return query.convertSqlResults === false ? 'table' : 'time_series';
+};
+
+//resolution factors of auto steps, 1/2 doubles the step
+const RESOLUTION_OPTIONS: Array<SelectableValue<string>> = [1, 2, 3, 4, 5, 10].map((factor) => ({
+  label: `1/${factor}`,
+  value: `1/${factor}`,
+}));
+
+//units of the time column, found by its type when not set
+const TIME_UNIT_OPTIONS: Array<SelectableValue<string>> = [
+  { label: 'Auto', value: '' },
+  { label: 'Epoch seconds', value: 's' },
+  { label: 'Epoch milliseconds', value: 'ms' },
+  { label: 'Epoch nanoseconds', value: 'ns' },
+  { label: 'DATE', value: 'date' },
+  { label: 'TIMESTAMP', value: 'timestamp' },
+];
+
+//column fields of the query holding one column name
+type ColumnField = 'timeColumn' | 'metricColumn' | 'labelsJsonColumn';
+//column fields of the query holding a list of column names
+type ColumnListField = 'valueColumns' | 'labelColumns';
+
+//isStepText returns true for the steps the backend accepts, empty or auto
+//for the interval of the panel or a positive number of seconds
+export const isStepText = (text?: string): boolean => {
+  const value = (text ?? '').trim();
+  if (value === '' || value.toLowerCase() === 'auto') {
+    return true;
+  }
+  const secs = Number(value);
+  return isFinite(secs) && secs > 0;
+};
+
 type Props = QueryEditorProps<DataSource, QueryObj, DataSourceOptionsObj>;
 
 interface QueryEditorState {
@@ -100,16 +148,13 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
     onRunQuery();
   };
 
-  //This function fetches the value of toggle which determines
-  //whether to convert the sql results into timeseries format.
-  //Basically it changes a flag called "convertSqlResults" which is
-  //used in backend to determine whether to return tabular
-  //results or dataframe results for the current sql qurey.
-  onInstantChange = (e: React.SyntheticEvent<HTMLInputElement>) => {
-    const instant = (e.target as HTMLInputElement).checked;
+  //This function sets the format the results of the sql query are
+  //returned in. It replaces the "convertSqlResults" switch, which is
+  //kept in step for the versions of the backend that only know it.
+  onFormatChange = (option: SelectableValue<string>) => {
     const { onChange, query, onRunQuery } = this.props;
-    //changes the value of convertSqlResults by fetching it from ui
-    onChange({ ...query, convertSqlResults: instant });
+    //changes the value of format by fetching it from UI
+    onChange({ ...query, format: option.value, convertSqlResults: option.value !== 'table' });
     // executes the query
     onRunQuery();
   };
@@ -205,21 +250,105 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
     onChange({ ...query, stepTextSql: value });
   };
 
-  // This function fires the query when stepText is changed.
+  // This function fires the query when stepText is changed. Steps
+  // the backend would reject are cleared, the step is then auto.
   onStepBlur = () => {
     const { onChange, query } = this.props;
 
-    let vsql = Number(this.props.query.stepTextSql);
-    if (isNaN(vsql) || vsql < 10) {
-      onChange({ ...query, stepTextSql: '10' });
+    if (!isStepText(query.stepTextSql) || !isStepText(query.stepTextProm)) {
+      onChange({
+        ...query,
+        stepTextSql: isStepText(query.stepTextSql) ? query.stepTextSql : '',
+        stepTextProm: isStepText(query.stepTextProm) ? query.stepTextProm : '',
+      });
     }
 
-    let vprom = Number(this.props.query.stepTextProm);
-    if (isNaN(vprom) || vprom < 10) {
-      onChange({ ...query, stepTextProm: '10' });
+    const { onRunQuery } = this.props;
+    onRunQuery();
+  };
+
+  //This function sets the value of minStep.
+  onMinStepChanged = (e: ChangeEvent<HTMLInputElement>) => {
+    const value = e.target.value;
+    const { onChange, query } = this.props;
+    //changes the value of minStep by fetching it from UI
+    onChange({ ...query, minStep: value });
+  };
+
+  // This function fires the query when minStep is changed, a min step
+  // the backend would reject is cleared.
+  onMinStepBlur = () => {
+    const { onChange, query, onRunQuery } = this.props;
+    const minStep = query.minStep ?? '';
+    if (minStep.trim().toLowerCase() === 'auto' || !isStepText(minStep)) {
+      onChange({ ...query, minStep: '' });
     }
+    onRunQuery();
+  };
 
-    const { onRunQuery } = this.props;
+  //This function sets the value of queryTimeout.
+  onQueryTimeoutChanged = (e: ChangeEvent<HTMLInputElement>) => {
+    const value = e.target.value;
+    const { onChange, query } = this.props;
+    //changes the value of queryTimeout by fetching it from UI
+    onChange({ ...query, queryTimeout: value });
+  };
+
+  // This function fires the query when queryTimeout is changed, a
+  // timeout the backend would reject is cleared and the timeout of the
+  // datasource applies.
+  onQueryTimeoutBlur = () => {
+    const { onChange, query, onRunQuery } = this.props;
+    const timeout = Number(query.queryTimeout);
+    if (query.queryTimeout && !(isFinite(timeout) && timeout > 0)) {
+      onChange({ ...query, queryTimeout: '' });
+    }
+    onRunQuery();
+  };
+
+  //This function sets the resolution factor of auto steps.
+  onResolutionChange = (option: SelectableValue<string>) => {
+    const { onChange, query, onRunQuery } = this.props;
+    onChange({ ...query, resolution: option.value });
+    // executes the query
+    onRunQuery();
+  };
+
+  //This function sets the column of a field naming one column of the
+  //sql time series.
+  onColumnChanged = (field: ColumnField) => (e: ChangeEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, [field]: e.target.value.trim() });
+  };
+
+  //This function sets the columns of a field naming a list of columns
+  //of the sql time series, they are entered separated by commas.
+  onColumnListChanged = (field: ColumnListField) => (e: ChangeEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    const columns = e.target.value.split(',').map((column) => column.trim());
+    onChange({ ...query, [field]: columns });
+  };
+
+  // This function drops the empty columns of the lists and fires the
+  // query when a column field is changed.
+  onColumnBlur = () => {
+    const { onChange, query, onRunQuery } = this.props;
+    const valueColumns = (query.valueColumns ?? []).filter((column) => column !== '');
+    const labelColumns = (query.labelColumns ?? []).filter((column) => column !== '');
+    if (
+      valueColumns.length !== (query.valueColumns ?? []).length ||
+      labelColumns.length !== (query.labelColumns ?? []).length
+    ) {
+      onChange({ ...query, valueColumns, labelColumns });
+    }
+    onRunQuery();
+  };
+
+  //This function sets the unit of the time column.
+  onTimeColumnUnitChange = (option: SelectableValue<string>) => {
+    const { onChange, query, onRunQuery } = this.props;
+    onChange({ ...query, timeColumnUnit: option.value });
+    // executes the query
     onRunQuery();
   };
 
@@ -265,7 +394,10 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
     var stepTextProm = this.props.query.stepTextProm;
     var stepTextSql = this.props.query.stepTextSql;
     var prefetchCountText = this.props.query.prefetchCountText;
-    var checkedVal = this.props.query.convertSqlResults;
+    var minStep = this.props.query.minStep;
+    var resolution = this.props.query.resolution || '1/1';
+    var timeColumnUnit = this.props.query.timeColumnUnit ?? '';
+    var format = queryFormat(this.props.query);
 
     var curLang = this.props.query.queryLang ?? 'promql';
 
@@ -322,11 +454,18 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
                   onBlur={this.onSqlBlur}
                   label="Query Text"
                 />
-                <Switch //toggle to convert sql results to dataframe format
-                  label="Time Series Mode"
-                  checked={checkedVal === undefined ? true : checkedVal}
-                  onChange={this.onInstantChange}
-                />
+                <div className="gf-form" style={{ paddingTop: '5px' }}>
+                  <InlineFormLabel width={7}>Format as</InlineFormLabel>
+                  <div style={{ width: '150px' }}>
+                    <Select
+                      className={'select-container'}
+                      isSearchable={false}
+                      options={FORMAT_OPTIONS}
+                      value={FORMAT_OPTIONS.find((option) => option.value === format)}
+                      onChange={this.onFormatChange}
+                    />
+                  </div>
+                </div>
               </div>
             )}
           </div>
@@ -385,11 +524,8 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
                   onChange={this.onStepTextChangedProm}
                   onBlur={this.onStepBlur}
                   className="gf-form-input width-32"
-                  placeholder="Steps Size in Seconds, Min:10 Default:10"
-                  type="number"
-                  min="1"
-                  max="100000"
-                  step="1"
+                  placeholder="Step Size in Seconds, Default:auto"
+                  type="text"
                 />
               </div>
             </div>
@@ -421,11 +557,8 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
                   onChange={this.onStepTextChangedSql}
                   onBlur={this.onStepBlur}
                   className="gf-form-input width-32"
-                  placeholder="Steps Size in Seconds, Min:10 Default:10"
-                  type="number"
-                  min="10"
-                  max="100000"
-                  step="1"
+                  placeholder="Step Size in Seconds, Default:auto"
+                  type="text"
                 />
               </div>
             </div>
@@ -446,8 +579,139 @@ export class QueryEditor extends PureComponent<Props, QueryEditorState> {
                 />
               </div>
             </div>
+            <div className="gf-form">
+              <InlineFormLabel width={7} tooltip="Column of the time of the time series, found by type if unset">
+                Time Column
+              </InlineFormLabel>
+              <div style={{ marginLeft: '25px', width: '200px' }}>
+                <Input
+                  id="timeColumn"
+                  value={this.props.query.timeColumn || ''}
+                  onChange={this.onColumnChanged('timeColumn')}
+                  onBlur={this.onColumnBlur}
+                  className="gf-form-input"
+                  placeholder="eg. METRIC_TIME"
+                  type="text"
+                />
+              </div>
+              <InlineFormLabel width={7}>Time Unit</InlineFormLabel>
+              <div style={{ width: '200px' }}>
+                <Select
+                  className={'select-container'}
+                  isSearchable={false}
+                  options={TIME_UNIT_OPTIONS}
+                  value={TIME_UNIT_OPTIONS.find((option) => option.value === timeColumnUnit)}
+                  onChange={this.onTimeColumnUnitChange}
+                />
+              </div>
+            </div>
+            <div className="gf-form">
+              <InlineFormLabel width={7} tooltip="Numeric columns of the values, separated by commas">
+                Value Columns
+              </InlineFormLabel>
+              <div style={{ marginLeft: '25px', width: '200px' }}>
+                <Input
+                  id="valueColumns"
+                  value={(this.props.query.valueColumns ?? []).join(',')}
+                  onChange={this.onColumnListChanged('valueColumns')}
+                  onBlur={this.onColumnBlur}
+                  className="gf-form-input"
+                  placeholder="eg. CPU,MEM"
+                  type="text"
+                />
+              </div>
+              <InlineFormLabel width={7} tooltip="Column of the metric name of the series">
+                Metric Column
+              </InlineFormLabel>
+              <div style={{ width: '200px' }}>
+                <Input
+                  id="metricColumn"
+                  value={this.props.query.metricColumn || ''}
+                  onChange={this.onColumnChanged('metricColumn')}
+                  onBlur={this.onColumnBlur}
+                  className="gf-form-input"
+                  placeholder="eg. METRIC_NAME"
+                  type="text"
+                />
+              </div>
+            </div>
+            <div className="gf-form">
+              <InlineFormLabel width={7} tooltip="Columns of the labels of the series, separated by commas">
+                Label Columns
+              </InlineFormLabel>
+              <div style={{ marginLeft: '25px', width: '200px' }}>
+                <Input
+                  id="labelColumns"
+                  value={(this.props.query.labelColumns ?? []).join(',')}
+                  onChange={this.onColumnListChanged('labelColumns')}
+                  onBlur={this.onColumnBlur}
+                  className="gf-form-input"
+                  placeholder="eg. HOST,DC"
+                  type="text"
+                />
+              </div>
+              <InlineFormLabel width={7} tooltip="Column holding the labels of the series as a JSON object">
+                Labels JSON
+              </InlineFormLabel>
+              <div style={{ width: '200px' }}>
+                <Input
+                  id="labelsJsonColumn"
+                  value={this.props.query.labelsJsonColumn || ''}
+                  onChange={this.onColumnChanged('labelsJsonColumn')}
+                  onBlur={this.onColumnBlur}
+                  className="gf-form-input"
+                  placeholder="eg. METRIC_TAGS"
+                  type="text"
+                />
+              </div>
+            </div>
           </div>
         ) : null}
+
+        <div className="gf-form">
+          <InlineFormLabel width={7} tooltip="Lower bound of the step, the scrape interval of the datasource if unset">
+            Min Step
+          </InlineFormLabel>
+          <div style={{ marginLeft: '25px', width: '150px' }}>
+            <Input
+              id="minStep"
+              value={minStep || ''}
+              onChange={this.onMinStepChanged}
+              onBlur={this.onMinStepBlur}
+              className="gf-form-input"
+              placeholder="Seconds"
+              type="text"
+            />
+          </div>
+          <InlineFormLabel width={7} tooltip="Resolution of auto steps, 1/2 doubles the step">
+            Resolution
+          </InlineFormLabel>
+          <div style={{ width: '100px' }}>
+            <Select
+              className={'select-container'}
+              isSearchable={false}
+              options={RESOLUTION_OPTIONS}
+              value={RESOLUTION_OPTIONS.find((option) => option.value === resolution)}
+              onChange={this.onResolutionChange}
+            />
+          </div>
+        </div>
+        <div className="gf-form">
+          <InlineFormLabel width={7} tooltip="Timeout of the query in seconds, the one of the datasource if unset">
+            Timeout
+          </InlineFormLabel>
+          <div style={{ marginLeft: '25px', width: '150px' }}>
+            <Input
+              id="queryTimeout"
+              value={this.props.query.queryTimeout || ''}
+              onChange={this.onQueryTimeoutChanged}
+              onBlur={this.onQueryTimeoutBlur}
+              className="gf-form-input"
+              placeholder="Timeout in Seconds"
+              type="text"
+            />
+          </div>
+        </div>
       </div>
     );
   }
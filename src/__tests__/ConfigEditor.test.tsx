@@ -25,7 +25,7 @@
 //-----------------------------------------------------------------------------
 
 import React from 'react';
-import { render, screen, fireEvent } from '@testing-library/react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
 import { ConfigEditor } from '../ConfigEditor';
 
 jest.mock('@grafana/ui', () => {
@@ -220,6 +220,164 @@ describe('ConfigEditor', () => {
     );
   });
 
+  it('updates the session pool settings as numbers', () => {
+    const { onOptionsChange } = setup({ jsonData: { poolMaxSessions: 10 } });
+
+    expect(screen.getByDisplayValue('10')).toBeInTheDocument();
+
+    fireEvent.change(screen.getByPlaceholderText('Default:1'), {
+      target: { value: '2' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ poolMinSessions: 2 }),
+      })
+    );
+
+    fireEvent.change(screen.getByPlaceholderText('Default:10'), {
+      target: { value: '' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ poolMaxSessions: undefined }),
+      })
+    );
+  });
+
+  it('updates the query timeout', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('Seconds, no timeout if unset'), {
+      target: { value: '30' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ queryTimeout: 30 }),
+      })
+    );
+  });
+
+  it('updates the max parallel queries', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('Default:4'), {
+      target: { value: '8' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ maxParallelQueries: 8 }),
+      })
+    );
+  });
+
+  it('updates the connection retries', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('No retries if unset'), {
+      target: { value: '3' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ retryCount: 3 }),
+      })
+    );
+  });
+
+  it('updates the wallet location', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('Wallet directory on the Grafana host'), {
+      target: { value: '/etc/wallet' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ walletLocation: '/etc/wallet' }),
+      })
+    );
+  });
+
+  it('uploads the wallet zip base64 encoded', async () => {
+    const { onOptionsChange } = setup({ secureJsonData: { dbPassword: 'tiger' } });
+
+    const file = new File(['zip'], 'wallet.zip', { type: 'application/zip' });
+    fireEvent.change(screen.getByLabelText('Wallet Zip'), { target: { files: [file] } });
+
+    await waitFor(() =>
+      expect(onOptionsChange).toHaveBeenCalledWith(
+        expect.objectContaining({
+          secureJsonData: { dbPassword: 'tiger', walletZip: 'emlw' },
+        })
+      )
+    );
+  });
+
+  it('resets a configured wallet zip', () => {
+    const { onOptionsChange } = setup({ secureJsonFields: { walletZip: true } });
+
+    fireEvent.click(screen.getByText('Reset Wallet'));
+
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        secureJsonFields: expect.objectContaining({ walletZip: false }),
+        secureJsonData: expect.objectContaining({ walletZip: '' }),
+      })
+    );
+  });
+
+  it('updates how NaN samples are returned', () => {
+    const { onOptionsChange } = setup();
+
+    // third select = PromQL NaN Values
+    fireEvent.change(screen.getAllByTestId('mock-select')[2], {
+      target: { value: 'null' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ nanValues: 'null' }),
+      })
+    );
+  });
+
+  it('updates how NULL values are returned', () => {
+    const { onOptionsChange } = setup();
+
+    // fourth select = SQL NULL Values
+    fireEvent.change(screen.getAllByTestId('mock-select')[3], {
+      target: { value: 'zero' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ nullValues: 'zero' }),
+      })
+    );
+  });
+
+  it('updates the scrape interval', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('eg. 15s'), {
+      target: { value: '30s' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ scrapeInterval: '30s' }),
+      })
+    );
+  });
+
+  it('updates the time zone', () => {
+    const { onOptionsChange } = setup();
+
+    fireEvent.change(screen.getByPlaceholderText('eg. Europe/Paris'), {
+      target: { value: '+02:00' },
+    });
+    expect(onOptionsChange).toHaveBeenCalledWith(
+      expect.objectContaining({
+        jsonData: expect.objectContaining({ timeZone: '+02:00' }),
+      })
+    );
+  });
+
   it('resets secure fields when password is reset', () => {
     const { onOptionsChange } = setup({
       secureJsonFields: { dbPassword: true },
@@ -26,7 +26,7 @@
 
 import React from 'react';
 import { render, screen, fireEvent, waitFor } from '@testing-library/react';
-import { QueryEditor } from '../QueryEditor';
+import { QueryEditor, queryFormat, isStepText } from '../QueryEditor';
 import { QueryObj } from '../types';
 /* ---------- mocks ---------- */
 const mockDatasource = {
@@ -67,7 +67,7 @@ describe('QueryEditor', () => {
   it('renders SQL editor when queryLang is sql', () => {
     setup({ queryLang: 'sql' });
     expect(screen.getByPlaceholderText('Enter Sql Query')).toBeInTheDocument();
-    expect(screen.getByText('Time Series Mode')).toBeInTheDocument();
+    expect(screen.getByText('Format as')).toBeInTheDocument();
   });
   /* ===== PROMQL behavior ===== */
   it('updates promql expression when typing', () => {
@@ -89,12 +89,34 @@ describe('QueryEditor', () => {
     });
     expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ legendFormatProm: '{{host}}' }));
   });
-  it('enforces minimum step size on blur (promql)', () => {
-    const { onChange, onRunQuery } = setup({ stepTextProm: '2' });
-    fireEvent.blur(screen.getByPlaceholderText('Steps Size in Seconds, Min:10 Default:10'));
-    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ stepTextProm: '10' }));
+  it('clears an invalid step on blur (promql)', () => {
+    const { onChange, onRunQuery } = setup({ stepTextProm: 'abc' });
+    fireEvent.blur(screen.getByPlaceholderText('Step Size in Seconds, Default:auto'));
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ stepTextProm: '' }));
     expect(onRunQuery).toHaveBeenCalled();
   });
+  it('accepts auto, empty and small steps', () => {
+    expect(isStepText('')).toBe(true);
+    expect(isStepText(undefined)).toBe(true);
+    expect(isStepText('auto')).toBe(true);
+    expect(isStepText('2')).toBe(true);
+    expect(isStepText('0.5')).toBe(true);
+    expect(isStepText('0')).toBe(false);
+    expect(isStepText('-5')).toBe(false);
+    expect(isStepText('10s')).toBe(false);
+  });
+  it('updates the min step and clears an invalid one', () => {
+    const { onChange, onRunQuery } = setup({ minStep: 'auto' });
+    fireEvent.change(screen.getByPlaceholderText('Seconds'), { target: { value: '30' } });
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ minStep: '30' }));
+    fireEvent.blur(screen.getByPlaceholderText('Seconds'));
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ minStep: '' }));
+    expect(onRunQuery).toHaveBeenCalled();
+  });
+  it('shows the resolution', () => {
+    setup({ resolution: '1/5' });
+    expect(screen.getByText('1/5')).toBeInTheDocument();
+  });
   /* ===== SQL behavior ===== */
   it('updates sql text when typing', () => {
     const { onChange } = setup({ queryLang: 'sql' });
@@ -103,14 +125,15 @@ describe('QueryEditor', () => {
     });
     expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ exprSql: 'select * from dual' }));
   });
-  it('toggles Time Series Mode and runs query', () => {
-    const { onChange, onRunQuery } = setup({
-      queryLang: 'sql',
-      convertSqlResults: false,
-    });
-    fireEvent.click(screen.getByLabelText('Time Series Mode'));
-    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ convertSqlResults: true }));
-    expect(onRunQuery).toHaveBeenCalled();
+  it('shows the format of queries saved with the Time Series Mode switch', () => {
+    setup({ queryLang: 'sql', convertSqlResults: false });
+    expect(screen.getByText('Table')).toBeInTheDocument();
+  });
+  it('initialises the format from convertSqlResults', () => {
+    expect(queryFormat({ refId: 'A' })).toBe('time_series');
+    expect(queryFormat({ refId: 'A', convertSqlResults: true })).toBe('time_series');
+    expect(queryFormat({ refId: 'A', convertSqlResults: false })).toBe('table');
+    expect(queryFormat({ refId: 'A', convertSqlResults: false, format: 'logs' })).toBe('logs');
   });
   it('updates SQL prefetch count', () => {
     const { onChange, onRunQuery } = setup({ queryLang: 'sql' });
@@ -119,6 +142,30 @@ describe('QueryEditor', () => {
     expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ prefetchCountText: '200' }));
     expect(onRunQuery).toHaveBeenCalled();
   });
+  it('updates the time series columns', () => {
+    const { onChange, onRunQuery } = setup({ queryLang: 'sql' });
+    fireEvent.change(screen.getByPlaceholderText('eg. METRIC_TIME'), { target: { value: 'LOGGED_AT' } });
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ timeColumn: 'LOGGED_AT' }));
+    fireEvent.change(screen.getByPlaceholderText('eg. CPU,MEM'), { target: { value: 'CPU, MEM' } });
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ valueColumns: ['CPU', 'MEM'] }));
+    fireEvent.change(screen.getByPlaceholderText('eg. METRIC_TAGS'), { target: { value: 'TAGS' } });
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ labelsJsonColumn: 'TAGS' }));
+    fireEvent.blur(screen.getByPlaceholderText('eg. HOST,DC'));
+    expect(onRunQuery).toHaveBeenCalled();
+  });
+  it('drops empty columns of the lists on blur', () => {
+    const { onChange } = setup({ queryLang: 'sql', labelColumns: ['HOST', ''] });
+    fireEvent.blur(screen.getByPlaceholderText('eg. HOST,DC'));
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ labelColumns: ['HOST'] }));
+  });
+  it('updates the query timeout and clears an invalid one', () => {
+    const { onChange, onRunQuery } = setup({ queryTimeout: '0' });
+    fireEvent.change(screen.getByPlaceholderText('Timeout in Seconds'), { target: { value: '30' } });
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ queryTimeout: '30' }));
+    fireEvent.blur(screen.getByPlaceholderText('Timeout in Seconds'));
+    expect(onChange).toHaveBeenCalledWith(expect.objectContaining({ queryTimeout: '' }));
+    expect(onRunQuery).toHaveBeenCalled();
+  });
   /* ===== Autocomplete ===== */
   it('fetches label suggestions when typing promql', async () => {
     const { onChange } = setup();
@@ -144,11 +191,11 @@ describe('QueryEditor', () => {
     });
   });
 
-  it('keeps step size when value is >= min', () => {
-    const { onChange } = setup({ stepTextProm: '20' });
+  it('keeps auto and steps under 10 seconds', () => {
+    const { onChange } = setup({ stepTextProm: '2', stepTextSql: 'auto' });
 
-    fireEvent.blur(screen.getByPlaceholderText('Steps Size in Seconds, Min:10 Default:10'));
+    fireEvent.blur(screen.getByPlaceholderText('Step Size in Seconds, Default:auto'));
 
-    expect(onChange).not.toHaveBeenCalledWith(expect.objectContaining({ stepTextProm: '10' }));
+    expect(onChange).not.toHaveBeenCalled();
   });
 });
@@ -28,6 +28,7 @@ jest.mock('@grafana/runtime', () => ({
   getTemplateSrv: jest.fn(() => ({
     replace: jest.fn((v: string) => v),
     getAdhocFilters: jest.fn(() => []),
+    getVariables: jest.fn(() => []),
   })),
 
   DataSourceWithBackend: class {
@@ -40,25 +41,14 @@ jest.mock('@grafana/runtime', () => ({
         }),
       };
     }
+
+    getResource() {
+      return Promise.resolve({ data: [] });
+    }
   },
 }));
 
-const mockQueryResponse = (bufferValue: any) => ({
-  toPromise: jest.fn().mockResolvedValue({
-    data: [
-      {
-        fields: [
-          {
-            values: {
-              buffer: [bufferValue],
-            },
-          },
-        ],
-      },
-    ],
-  }),
-});
-
+import { getTemplateSrv } from '@grafana/runtime';
 import { DataSource } from '../datasource';
 
 describe('DataSource', () => {
@@ -143,20 +133,80 @@ it('applyTemplateVariables replaces expressions safely', () => {
   expect(result.exprProm).toBe('metric_prom');
 });
 
+it('applyTemplateVariables passes the values of the dashboard variables', () => {
+  const ds = new DataSource({} as any);
+  const current: Record<string, string | string[]> = {
+    '${host}': 'db1',
+    '${region}': ['eu-west', 'us-east'],
+  };
+  const templateSrv = {
+    replace: jest.fn((v: string, _scoped?: any, format?: any) => (format ? format(current[v]) : v)),
+    getAdhocFilters: jest.fn(() => []),
+    getVariables: jest.fn(() => [{ name: 'host' }, { name: 'region', multi: true }]),
+  };
+  const original = (getTemplateSrv as jest.Mock).getMockImplementation();
+  (getTemplateSrv as jest.Mock).mockImplementation(() => templateSrv);
+
+  try {
+    const result = ds.applyTemplateVariables({ exprSql: 'select 1 from dual where host = $host' } as any);
+
+    //a multi-value variable is a list, each value is bound by the backend
+    expect(result.variables).toEqual({ host: 'db1', region: ['eu-west', 'us-east'] });
+    //the variables are bound by the backend, never replaced in the sql text
+    expect(result.exprSql).toBe('select 1 from dual where host = $host');
+    expect(templateSrv.replace).not.toHaveBeenCalledWith('select 1 from dual where host = $host', expect.anything(), expect.anything());
+  } finally {
+    (getTemplateSrv as jest.Mock).mockImplementation(original);
+  }
+});
+
+it('applyTemplateVariables replaces the global variables in sql queries', () => {
+  const ds = new DataSource({} as any);
+  const globals: Record<string, string> = {
+    '$__from': '1700000000000',
+    '${__to:date}': '2023-11-14T22:13:20.000Z',
+    '${__user.login}': 'admin',
+  };
+  const templateSrv = {
+    replace: jest.fn((v: string) => globals[v] ?? v),
+    getAdhocFilters: jest.fn(() => []),
+    getVariables: jest.fn(() => [{ name: 'host' }]),
+  };
+  const original = (getTemplateSrv as jest.Mock).getMockImplementation();
+  (getTemplateSrv as jest.Mock).mockImplementation(() => templateSrv);
+
+  try {
+    const result = ds.applyTemplateVariables({
+      exprSql:
+        "select $__from, '${__to:date}', '${__user.login}', $__interval from t where $__timeFilter(ts) and host = $host",
+    } as any);
+
+    //the dashboard variables and the macros of the backend are kept
+    expect(result.exprSql).toBe(
+      "select 1700000000000, '2023-11-14T22:13:20.000Z', 'admin', $__interval from t where $__timeFilter(ts) and host = $host"
+    );
+    expect(templateSrv.replace).not.toHaveBeenCalledWith('$host', undefined);
+    expect(templateSrv.replace).not.toHaveBeenCalledWith('$__interval', undefined);
+  } finally {
+    (getTemplateSrv as jest.Mock).mockImplementation(original);
+  }
+});
+
 it('getTagKeys returns metric keys', async () => {
   const ds = new DataSource({} as any);
 
-  jest.spyOn(ds, 'query').mockReturnValue(mockQueryResponse(JSON.stringify({ data: ['job', 'instance'] })) as any);
+  const getResource = jest.spyOn(ds, 'getResource').mockResolvedValue({ data: ['job', 'instance'] });
 
   const result = await ds.getTagKeys();
 
+  expect(getResource).toHaveBeenCalledWith('labels');
   expect(result).toEqual([{ text: 'job' }, { text: 'instance' }]);
 });
 
-it('getTagKeys returns empty array if buffer is not string', async () => {
+it('getTagKeys returns empty array if data is not a list', async () => {
   const ds = new DataSource({} as any);
 
-  jest.spyOn(ds, 'query').mockReturnValue(mockQueryResponse(123) as any);
+  jest.spyOn(ds, 'getResource').mockResolvedValue({ data: 123 });
 
   const result = await ds.getTagKeys();
   expect(result).toEqual([]);
@@ -165,10 +215,11 @@ it('getTagKeys returns empty array if buffer is not string', async () => {
 it('getTagValues returns values for key', async () => {
   const ds = new DataSource({} as any);
 
-  jest.spyOn(ds, 'query').mockReturnValue(mockQueryResponse(JSON.stringify({ data: ['us-east', 'us-west'] })) as any);
+  const getResource = jest.spyOn(ds, 'getResource').mockResolvedValue({ data: ['us-east', 'us-west'] });
 
   const result = await ds.getTagValues({ key: 'region' });
 
+  expect(getResource).toHaveBeenCalledWith('label/region/values');
   expect(result).toEqual([{ text: 'us-east' }, { text: 'us-west' }]);
 });
 
@@ -176,22 +227,8 @@ it('metricFindQuery returns formatted metric names', async () => {
   const ds = new DataSource({} as any);
 
   jest.spyOn(ds, 'fetchMetricNames').mockResolvedValue({
-    data: [
-      {
-        fields: [
-          {
-            values: {
-              buffer: [
-                JSON.stringify({
-                  data: [{ __name__: 'up', job: 'node', instance: 'localhost' }],
-                }),
-              ],
-            },
-          },
-        ],
-      },
-    ],
-  } as any);
+    data: [{ __name__: 'up', job: 'node', instance: 'localhost' }],
+  });
 
   const result = await ds.metricFindQuery({
     query: 'up',
@@ -209,10 +246,11 @@ it('fetchStaticLabels returns label list', async () => {
   jest.spyOn(ds, 'getFromStr').mockReturnValue('123456');
   jest.spyOn(ds, 'getToStr').mockReturnValue('123999');
 
-  jest.spyOn(ds, 'query').mockReturnValue(mockQueryResponse(JSON.stringify({ data: ['cpu', 'memory'] })) as any);
+  const getResource = jest.spyOn(ds, 'getResource').mockResolvedValue({ data: ['cpu', 'memory'] });
 
   const result = await ds.fetchStaticLabels();
 
+  expect(getResource).toHaveBeenCalledWith('metrics', { start: 123, end: 123 });
   expect(result).toEqual([{ name: 'cpu' }, { name: 'memory' }]);
 });
 
@@ -235,9 +273,7 @@ it('fetchStaticLabels returns empty array on error', async () => {
   jest.spyOn(ds, 'getFromStr').mockReturnValue('123');
   jest.spyOn(ds, 'getToStr').mockReturnValue('456');
 
-  jest.spyOn(ds, 'query').mockImplementation(() => {
-    throw new Error('fail');
-  });
+  jest.spyOn(ds, 'getResource').mockRejectedValue(new Error('fail'));
 
   const consoleSpy = jest.spyOn(console, 'error').mockImplementation();
 
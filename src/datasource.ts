@@ -26,7 +26,7 @@
 
 import addLabelToQuery from './AddLabelToQuery';
 
-import { MetricFindValue, DataSourceInstanceSettings } from '@grafana/data';
+import { MetricFindValue, DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
 //For providing support of query variable we need to import MetricFindValue
 
 import { DataSourceOptionsObj, QueryObj, VariableQueryObject, InData } from './types';
@@ -35,6 +35,13 @@ import { DataSourceOptionsObj, QueryObj, VariableQueryObject, InData } from './t
 import { getTemplateSrv, DataSourceWithBackend } from '@grafana/runtime';
 //For providing support of custom variable we need to import getTemplateSrv
 
+//the references to variables in the syntax of Grafana, $name, [[name:format]]
+//and ${name.path:format}
+const variableRegex = /\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\${(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?}/g;
+
+//the global variables the backend expands as sql macros
+const backendMacros = ['__interval', '__interval_ms', '__range_s', '__range_ms', '__rate_interval'];
+
 export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOptionsObj> {
   constructor(instanceSettings: DataSourceInstanceSettings<DataSourceOptionsObj>) {
     super(instanceSettings);
@@ -49,14 +56,6 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
     return strret;
   }
 
-  extractValue(response: any) {
-    return (
-      response?.data?.[0]?.fields?.[0]?.values?.get?.(0) ??
-      response?.data?.[0]?.fields?.[0]?.values?.buffer?.[0] ??
-      response?.data?.[0]?.fields?.[0]?.values?.[0]
-    );
-  }
-
   //get to time value from selected range
   getToStr() {
     const templateSrv = getTemplateSrv();
@@ -97,32 +96,13 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
     }
 
     try {
-      const response = await this.query({
-        targets: [
-          {
-            refId: 'fetchLabels',
-            rawQueryText: '',
-            expr: '',
-            exprProm: '',
-            exprSql: '',
-            timeFrom: fromms,
-            timeTo: toms,
-          },
-        ],
-      } as any).toPromise();
-
-      const frame = response?.data?.[0];
-      if (!frame || !frame.fields?.[0]) {
-        return [];
-      }
-
-      const buffer = this.extractValue(response);
-      if (typeof buffer !== 'string') {
-        return [];
-      }
+      //the time range is in milliseconds, the backend resources take seconds
+      const response = await this.getResource('metrics', {
+        start: Math.floor(Number(fromms) / 1000),
+        end: Math.floor(Number(toms) / 1000),
+      });
 
-      const parsed = JSON.parse(buffer);
-      const arr_tags = parsed?.data;
+      const arr_tags = response?.data;
       if (!Array.isArray(arr_tags)) {
         return [];
       }
@@ -139,8 +119,12 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
   //the grafana panel. It gets the variables details through
   //getTemplateSrv() and uses its replace method to replace occurances
   //of the defined variables in current query.Also this function is called
-  //at time of loading plugin so we fetch labels with this functions help
-  applyTemplateVariables(query: QueryObj) {
+  //at time of loading plugin so we fetch labels with this functions help.
+  //The dashboard variables are not replaced in sql queries, they are sent
+  //with the query and bound as :name by the backend, which also binds $name
+  //references of older queries. The global variables are replaced, see
+  //interpolateGlobalVariables.
+  applyTemplateVariables(query: QueryObj, scopedVars?: ScopedVars) {
     //this part is to load labels in cache initially
     const templateSrv = getTemplateSrv();
     const adhocFilters = (getTemplateSrv() as any).getAdhocFilters(this.name);
@@ -151,13 +135,46 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
     const nextQuery: QueryObj = {
       ...query,
       expr: applyTemplate(query.expr),
-      exprSql: applyTemplate(this.applyAdhocFilters(query.exprSql, adhocFilters)),
+      exprSql: this.interpolateGlobalVariables(this.applyAdhocFilters(query.exprSql, adhocFilters), scopedVars),
       exprProm: applyTemplate(this.applyAdhocFilters(query.exprProm, adhocFilters)),
+      variables: this.getVariableValues(),
     };
 
     return nextQuery;
   }
 
+  //replaces the global variables of Grafana, $__from, ${__to:date},
+  //$__dashboard, $__user.login..., in the text of a sql query, they are not
+  //dashboard variables the backend could bind. The ones the backend expands
+  //as macros from the step it resolves are kept, as are the macros.
+  private interpolateGlobalVariables(sql: string, scopedVars?: ScopedVars): string {
+    const templateSrv = getTemplateSrv();
+    return sql.replace(variableRegex, (match: string, name1?: string, name2?: string, _format?: string, name3?: string) => {
+      const name = name1 ?? name2 ?? name3 ?? '';
+      if (!name.startsWith('__') || backendMacros.includes(name)) {
+        return match;
+      }
+      return templateSrv.replace(match, scopedVars);
+    });
+  }
+
+  //current values of the dashboard variables, SQL queries use them as bind
+  //variables, :name. The values of a multi-value variable are sent as a list,
+  //the backend binds each of them so that IN (:name) matches any of them
+  private getVariableValues(): Record<string, string | string[]> {
+    const templateSrv = getTemplateSrv();
+    const values: Record<string, string | string[]> = {};
+    templateSrv.getVariables().forEach((variable: any) => {
+      let value: string | string[] = '';
+      templateSrv.replace('${' + variable.name + '}', {}, (v: string | string[]) => {
+        value = v ?? '';
+        return '';
+      });
+      values[variable.name] = value;
+    });
+    return values;
+  }
+
   private serializeVariableValue(variables: any): string {
     if (typeof variables === 'string') {
       return variables;
@@ -173,29 +190,8 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
   async getTagKeys() {
     const values: MetricFindValue[] = [];
     //calling this api to fetch the keys
-    const response = await this.query({
-      targets: [
-        {
-          refId: 'getKeysForAdHocFilter',
-          rawQueryText: '',
-          expr: '',
-          exprProm: '',
-          exprSql: '',
-          timeColumns: [],
-        },
-      ],
-    } as any).toPromise();
-
-    if (response?.error) {
-      throw new Error(response.error.message);
-    }
-    const buffer = this.extractValue(response);
-    if (typeof buffer !== 'string') {
-      return values;
-    }
-
-    const parsed = JSON.parse(buffer);
-    const arr_tags = parsed?.data;
+    const response = await this.getResource('labels');
+    const arr_tags = response?.data;
     if (!Array.isArray(arr_tags)) {
       return values;
     }
@@ -209,30 +205,8 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
 
   async getTagValues(options: any = {}) {
     const values: MetricFindValue[] = [];
-    const response = await this.query({
-      targets: [
-        {
-          refId: 'getValueforKeyAdHocFilter',
-          rawQueryText: options.key,
-          expr: '',
-          exprSql: '',
-          exprProm: '',
-          timeColumns: [],
-        },
-      ],
-    } as any).toPromise();
-
-    if (response?.error) {
-      throw new Error(response.error.message);
-    }
-
-    const buffer = this.extractValue(response);
-    if (typeof buffer !== 'string') {
-      return values;
-    }
-
-    const parsed = JSON.parse(buffer);
-    const arr_tags = parsed?.data;
+    const response = await this.getResource(`label/${encodeURIComponent(options.key)}/values`);
+    const arr_tags = response?.data;
     if (!Array.isArray(arr_tags)) {
       return values;
     }
@@ -247,50 +221,25 @@ export class DataSource extends DataSourceWithBackend<QueryObj, DataSourceOption
     return typeof value === 'string' ? value.replace(/\\/g, '\\\\').replace(/'/g, "\\\\'") : value;
   }
 
-  // this method fetches the tags corresponding to the promql query given for
-  // query variable.  In backend we check the refId of query and if its
-  //'metricFindQuery' we simply return tags for selected promql query
-  async fetchMetricNames(query: string, queryLanguage: string) {
+  // this method fetches the series matching the promql selector given for
+  // query variable from the series resource of the backend
+  async fetchMetricNames(query: string) {
     let fromms = this.getFromStr();
     let toms = this.getToStr();
     // changing millisecs epoch to secs epoch by dropping last 3 characters
     let fromstr = fromms.substring(0, fromms.length - 3);
     let tostr = toms.substring(0, toms.length - 3);
-    const query_with_time = query + '&start=' + fromstr + '&end=' + tostr;
-    const response = await this.query({
-      targets: [
-        {
-          refId: 'metricFindQuery',
-          rawQueryText: query_with_time,
-          queryLang: queryLanguage,
-          expr: query_with_time,
-          timeColumns: [],
-        },
-      ],
-    } as any).toPromise();
-
-    if (response?.error) {
-      throw new Error(response.error.message);
-    }
-    return response;
+    return this.getResource('series', { 'match[]': query, start: fromstr, end: tostr });
   }
   //this method first calls fetchMetricNames to get tags for the promql query
   //that is used for query variable support and then it does some manipulation
   //and makes array called values of type MetricFindValue which is
   //returned and rendered as options list in query variable in grafana.
   async metricFindQuery(query: VariableQueryObject, options?: any): Promise<MetricFindValue[]> {
-    // Retrieve DataQueryResponse based on query.
-    const response = await this.fetchMetricNames(query.query, query.queryLang);
+    // Retrieve the series matching the query.
+    const response = await this.fetchMetricNames(query.query);
     const values: MetricFindValue[] = [];
-    //values.push({ text: 'up{instance="slc.us.oracle.com:9100",job="node"}' });
-    //    var arr_tags = JSON.parse(response['data'][0].fields[0].values.buffer[0]).result_output.data;
-    const buffer = this.extractValue(response);
-    if (typeof buffer !== 'string') {
-      return values;
-    }
-
-    const parsed = JSON.parse(buffer);
-    const arr_tags = parsed?.data;
+    const arr_tags = response?.data;
     if (!Array.isArray(arr_tags)) {
       return values;
     }
@@ -29,6 +29,10 @@ import { DataQuery, DataSourceJsonData } from '@grafana/data';
 //This is the interface for query which contains all the fiels required with
 //query that are sent from frontend to backend
 export interface QueryObj extends DataQuery {
+  //version of the query model, queries without one are migrated by the backend
+  version?: number;
+  //result format, time_series, table, logs or auto
+  format?: string;
   //fields promql
   exprProm?: string;
   legendFormatProm?: string;
@@ -39,11 +43,29 @@ export interface QueryObj extends DataQuery {
   stepTextSql?: string;
   prefetchCountText?: string;
   convertSqlResults?: boolean;
+  //columns of the sql time series, found by type and name when not set
+  timeColumn?: string;
+  //s, ms, ns (epoch numbers), date or timestamp
+  timeColumnUnit?: string;
+  valueColumns?: string[];
+  metricColumn?: string;
+  labelColumns?: string[];
+  //column holding the labels as a JSON object
+  labelsJsonColumn?: string;
   //common fields
   timeRange?: string;
   queryLang?: string;
   expr?: string;
   pointsFillSecs?: string;
+  //lower bound of the step in seconds, and resolution factor of an auto
+  //step, 1/1 to 1/10
+  minStep?: string;
+  resolution?: string;
+  //timeout in seconds, overrides the datasource queryTimeout when set
+  queryTimeout?: string;
+  //current values of the dashboard variables, bound as :name in SQL queries,
+  //the values of multi-value variables as a list
+  variables?: Record<string, string | string[]>;
 }
 
 export const defaultQuery: Partial<QueryObj> = {};
@@ -60,6 +82,30 @@ export interface DataSourceOptionsObj extends DataSourceJsonData {
   //for db datasource type
   dbUser?: string;
   dbConnectString?: string;
+  //Easy Connect Plus options, timeouts are in seconds
+  retryCount?: number;
+  connectTimeout?: number;
+  transportConnectTimeout?: number;
+  //directory of the wallet used for TLS connections
+  walletLocation?: string;
+  //session pool settings, timeouts are in seconds
+  poolMinSessions?: number;
+  poolMaxSessions?: number;
+  poolIdleTimeout?: number;
+  poolMaxLifetime?: number;
+  //default query timeout in seconds
+  queryTimeout?: number;
+  //number of queries of a panel run concurrently
+  maxParallelQueries?: number;
+  //how NaN samples of PromQL results are returned, 'nan' (default) or 'null'
+  nanValues?: string;
+  //how NULL values of SQL results are returned, 'null' (default) or 'zero'
+  nullValues?: string;
+  //interval the metrics are collected at, e.g. 15s, min step of auto steps
+  scrapeInterval?: string;
+  //time zone of the DATE and TIMESTAMP values of the data, a region name such
+  //as Europe/Paris or an offset such as +02:00, the session time zone if unset
+  timeZone?: string;
 }
 
 /**
@@ -68,6 +114,8 @@ export interface DataSourceOptionsObj extends DataSourceJsonData {
 export interface SecureJsonData {
   //secure fields for db datasource type
   dbPassword?: string;
+  //base64 encoded wallet zip
+  walletZip?: string;
 }
 // for query variable we created the following interface
 export interface VariableQueryObject {